@@ -0,0 +1,6 @@
+package system
+
+const (
+	AppName    = "system"
+	AppRecycle = "recycle"
+)
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/system"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/system/logic"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/system/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/response"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"go.uber.org/zap"
+)
+
+var _ router.GinService = (*RecycleHandler)(nil)
+var recycleHandler = &RecycleHandler{}
+
+type RecycleHandler struct {
+	l   *zap.Logger
+	svc *logic.RecycleLogic
+}
+
+func (h *RecycleHandler) PublicRegistry(gin.IRouter) {
+
+}
+
+// AuthRegistry 注册认证接口
+func (h *RecycleHandler) AuthRegistry(r gin.IRouter) {
+	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppRecycle))
+	{
+		group.GET("", h.resources)
+		group.GET("/:resource", h.list)
+		group.POST("/:resource/:id/restore", h.restore)
+		group.DELETE("/:resource/:id", h.purge)
+	}
+}
+
+func (h *RecycleHandler) resources(c *gin.Context) {
+	response.SuccessSlice(c, h.svc.Resources(c))
+}
+
+func (h *RecycleHandler) list(c *gin.Context) {
+	var req types2.RecycleResourceReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var query types2.RecycleQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("查询参数: %s, %+v", req.Resource, query))
+	list, err := h.svc.List(c, req, query)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *RecycleHandler) restore(c *gin.Context) {
+	var req types2.RecycleRecordReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("恢复参数: %+v", req))
+	if err := h.svc.Restore(c, req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *RecycleHandler) purge(c *gin.Context) {
+	var req types2.RecycleRecordReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("彻底删除参数: %+v", req))
+	if err := h.svc.Purge(c, req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *RecycleHandler) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppRecycle)
+}
+
+// Config 配置函数，在这里注入依赖，并且初始化实例，供其他函数使用。
+func (h *RecycleHandler) Config() {
+	h.l = global.L.Named(apps.AppName).Named(apps.AppRecycle).Named("handler")
+	h.svc = logic.NewRecycleLogic()
+}
+
+func init() {
+	router.RegistryGinRouter(recycleHandler)
+}
@@ -0,0 +1,143 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/system"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/system/service"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/system/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/sql"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"reflect"
+)
+
+var _ service.RecycleService = (*RecycleLogic)(nil)
+
+// 已删除记录的查询条件，未删除的记录 deleted_at 为 0
+const deletedCondition = "deleted_at <> 0"
+
+type RecycleLogic struct {
+	l  *zap.Logger
+	db *gorm.DB
+}
+
+// Resources 返回支持回收站的资源
+func (l *RecycleLogic) Resources(c *gin.Context) []*types2.RecycleResourceResp {
+	list := make([]*types2.RecycleResourceResp, 0)
+	for _, r := range model.Recyclables() {
+		list = append(list, &types2.RecycleResourceResp{Name: r.Name, Title: r.Title})
+	}
+	return list
+}
+
+func (l *RecycleLogic) resource(name string) (*model.Recyclable, error) {
+	r, ok := model.GetRecyclable(name)
+	if !ok {
+		return nil, fmt.Errorf("不支持的资源: %s", name)
+	}
+	return r, nil
+}
+
+// List 分页查询资源已删除的记录，最近删除的在前
+func (l *RecycleLogic) List(c *gin.Context, req types2.RecycleResourceReq, query types2.RecycleQueryReq) (*types.QueryResponse, error) {
+	r, err := l.resource(req.Resource)
+	if err != nil {
+		return nil, err
+	}
+	list := reflect.Zero(reflect.SliceOf(reflect.TypeOf(r.Model))).Interface()
+	db := l.db.WithContext(c).Unscoped().Model(r.Model).Where(deletedCondition).
+		Order(fmt.Sprintf("deleted_at %s", query.Sort))
+	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询已删除的%s失败: %s", r.Title, err.Error()))
+		return nil, fmt.Errorf("查询已删除的%s失败", r.Title)
+	}
+	return queryRes, nil
+}
+
+// deleted 查询资源已删除的记录
+func (l *RecycleLogic) deleted(tx *gorm.DB, r *model.Recyclable, id uint) (interface{}, error) {
+	record := reflect.New(reflect.TypeOf(r.Model).Elem()).Interface()
+	if err := tx.Unscoped().Where(deletedCondition).Where("id = ?", id).First(record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("回收站中不存在该%s", r.Title)
+		}
+		l.l.Error(fmt.Sprintf("查询已删除的%s失败: %s", r.Title, err.Error()))
+		return nil, fmt.Errorf("查询已删除的%s失败", r.Title)
+	}
+	return record, nil
+}
+
+// Restore 恢复已删除的记录，恢复前校验关联数据，唯一字段已被占用时恢复失败
+func (l *RecycleLogic) Restore(c *gin.Context, req types2.RecycleRecordReq) error {
+	r, err := l.resource(req.Resource)
+	if err != nil {
+		return err
+	}
+	err = l.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		record, err := l.deleted(tx, r, req.Id)
+		if err != nil {
+			return err
+		}
+		if r.Validate != nil {
+			if err := r.Validate(tx, record); err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Model(r.Model).Where("id = ?", req.Id).UpdateColumn("deleted_at", 0).Error; err != nil {
+			var mysqlErr *mysqlDriver.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+				return fmt.Errorf("恢复%s失败: 存在相同的%s，请先修改或删除后再恢复", r.Title, r.Title)
+			}
+			l.l.Error(fmt.Sprintf("恢复%s失败: %s", r.Title, err.Error()))
+			return fmt.Errorf("恢复%s失败", r.Title)
+		}
+		l.l.Info(fmt.Sprintf("恢复%s成功, id: %d", r.Title, req.Id))
+		return nil
+	})
+	if err == nil && r.Restored != nil {
+		r.Restored(c)
+	}
+	return err
+}
+
+// Purge 彻底删除回收站中的记录，未删除的记录不能直接彻底删除
+func (l *RecycleLogic) Purge(c *gin.Context, req types2.RecycleRecordReq) error {
+	r, err := l.resource(req.Resource)
+	if err != nil {
+		return err
+	}
+	result := l.db.WithContext(c).Unscoped().Where(deletedCondition).Where("id = ?", req.Id).Delete(r.Model)
+	if err := result.Error; err != nil {
+		l.l.Error(fmt.Sprintf("彻底删除%s失败: %s", r.Title, err.Error()))
+		return fmt.Errorf("彻底删除%s失败", r.Title)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("回收站中不存在该%s", r.Title)
+	}
+	l.l.Info(fmt.Sprintf("彻底删除%s成功, id: %d", r.Title, req.Id))
+	return nil
+}
+
+// Config 只需要保证 全局对象Config和全局Logger已经加载完成
+func (l *RecycleLogic) Config() {
+	l.l = global.L.Named(apps.AppName).Named(apps.AppRecycle).Named("logic")
+	l.db = global.DB.GetDb()
+}
+
+func (l *RecycleLogic) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppRecycle)
+}
+
+func NewRecycleLogic() *RecycleLogic {
+	return &RecycleLogic{
+		l:  global.L.Named(apps.AppName).Named(apps.AppRecycle).Named("logic"),
+		db: global.DB.GetDb(),
+	}
+}
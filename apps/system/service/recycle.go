@@ -0,0 +1,14 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/system/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+)
+
+type RecycleService interface {
+	Resources(*gin.Context) []*types2.RecycleResourceResp
+	List(*gin.Context, types2.RecycleResourceReq, types2.RecycleQueryReq) (*types.QueryResponse, error)
+	Restore(*gin.Context, types2.RecycleRecordReq) error
+	Purge(*gin.Context, types2.RecycleRecordReq) error
+}
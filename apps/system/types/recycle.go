@@ -0,0 +1,21 @@
+package types
+
+import "github.com/yanshicheng/ikube-gin-xjob/common/types"
+
+type RecycleResourceReq struct {
+	Resource string `json:"resource" uri:"resource" binding:"required"`
+}
+
+type RecycleRecordReq struct {
+	Resource string `json:"resource" uri:"resource" binding:"required"`
+	Id       uint   `json:"id" uri:"id" binding:"required,number"`
+}
+
+type RecycleQueryReq struct {
+	types.Pagination
+}
+
+type RecycleResourceResp struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
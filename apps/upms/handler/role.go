@@ -7,6 +7,7 @@ import (
 	"github.com/yanshicheng/ikube-gin-xjob/apps/upms/logic"
 	"github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
 	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/upms/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/middleware"
 	"github.com/yanshicheng/ikube-gin-xjob/common/response"
 	"github.com/yanshicheng/ikube-gin-xjob/common/types"
 	"github.com/yanshicheng/ikube-gin-xjob/global"
@@ -32,9 +33,16 @@ func (h *RoleHandler) AuthRegistry(r gin.IRouter) {
 	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppRole))
 	{
 		group.GET("/", h.list)
-		group.POST("/", h.create)
-		group.PUT("/:id", h.put)
-		group.DELETE("/:id", h.delete)
+		group.GET("/:id/parents", h.parents)
+		group.GET("/:id/permissions", h.permissions)
+	}
+	// 角色的数据权限决定账号可见的数据，上级角色决定角色的菜单和权限，只允许管理员修改
+	admin := group.Group("", middleware.RequireAdmin())
+	{
+		admin.POST("/", h.create)
+		admin.PUT("/:id", h.put)
+		admin.DELETE("/:id", h.delete)
+		admin.PUT("/:id/parents", h.setParents)
 	}
 }
 
@@ -106,6 +114,58 @@ func (h *RoleHandler) delete(c *gin.Context) {
 	response.SuccessMap(c, nil)
 }
 
+func (h *RoleHandler) parents(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Parents(c, id)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, list)
+}
+
+func (h *RoleHandler) setParents(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.RoleParentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("设置上级角色: %+v, 角色id: %d", req, id.Id))
+	list, err := h.svc.SetParents(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, list)
+}
+
+func (h *RoleHandler) permissions(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	resp, err := h.svc.Permissions(c, id)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, resp)
+}
+
 func (h *RoleHandler) Name() string {
 	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppRole)
 }
@@ -7,10 +7,12 @@ import (
 	"github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
 	"github.com/yanshicheng/ikube-gin-xjob/apps/upms/service"
 	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/upms/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
 	"github.com/yanshicheng/ikube-gin-xjob/common/types"
 	"github.com/yanshicheng/ikube-gin-xjob/global"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"strings"
 )
 
 var _ service.MenuService = (*MenuLogic)(nil)
@@ -20,9 +22,32 @@ type MenuLogic struct {
 	db *gorm.DB
 }
 
+// List 查询菜单树，按 name 或 title 包含匹配时保留匹配菜单的上级菜单
 func (l *MenuLogic) List(ctx *gin.Context, req types2.MenuSearchReq) ([]*model.Menu, error) {
-
-	return nil, nil
+	var menus []*model.Menu
+	if err := l.db.WithContext(ctx).Order("order_no ASC, id ASC").Find(&menus).Error; err != nil {
+		l.l.Error(fmt.Sprintf("菜单查询失败, err: %v", err))
+		return nil, fmt.Errorf("菜单查询失败")
+	}
+	var result []*model.Menu
+	if req.Name == "" {
+		t := tree.Build(menus, nil)
+		if ids := t.OrphanIds(); len(ids) > 0 {
+			l.l.Warn(fmt.Sprintf("菜单 %v 的上级菜单不存在，作为顶层菜单返回", ids))
+		}
+		if ids := t.CycleIds(); len(ids) > 0 {
+			l.l.Warn(fmt.Sprintf("菜单的上级关系存在环，在菜单 %v 处断开后作为顶层菜单返回", ids))
+		}
+		result = t.Forest()
+	} else {
+		result = tree.Search(menus, func(m *model.Menu) bool {
+			return strings.Contains(m.Name, req.Name) || strings.Contains(m.Title, req.Name)
+		}, nil)
+	}
+	if result == nil {
+		result = []*model.Menu{}
+	}
+	return result, nil
 }
 
 func (l *MenuLogic) Create(ctx *gin.Context, req *model.Menu) error {
@@ -34,17 +59,55 @@ func (l *MenuLogic) Create(ctx *gin.Context, req *model.Menu) error {
 			return fmt.Errorf("菜单创建失败，parentId 不存在")
 		}
 	}
-
-	if err := l.db.WithContext(ctx).Create(req).Error; err != nil {
+	// 未指定类型时为页面，只有按钮保留权限标识
+	if req.Type == 0 {
+		req.Type = model.MenuTypePage
+	}
+	if req.Type != model.MenuTypeButton {
+		req.Permission = ""
+	}
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := model.CheckMenuType(tx, req); err != nil {
+			return err
+		}
+		return tx.Create(req).Error
+	})
+	if err != nil {
 		l.l.Error(fmt.Sprintf("菜单创建失败, err: %v", err))
-		return fmt.Errorf("菜单创建失败")
+		return fmt.Errorf("菜单创建失败，%w", err)
 	}
 	return nil
 }
 func (l *MenuLogic) Put(ctx *gin.Context, id types.SearchId, req *model.Menu) (*model.Menu, error) {
-	if err := l.db.WithContext(ctx).Model(&model.Menu{}).Where("id = ?", id.Id).Updates(req).Error; err != nil {
+	var current model.Menu
+	if err := l.db.WithContext(ctx).Model(&model.Menu{}).Where("id = ?", id.Id).First(&current).Error; err != nil {
 		l.l.Error(fmt.Sprintf("菜单更新失败, err: %v", err))
-		return nil, fmt.Errorf("菜单更新失败")
+		return nil, fmt.Errorf("菜单更新失败，菜单不存在")
+	}
+	// 未指定类型和上级菜单时沿用原来的值，按类型规则校验修改后的菜单
+	if req.Type == 0 {
+		req.Type = current.Type
+	}
+	if req.ParentId == nil {
+		req.ParentId = current.ParentId
+	}
+	if req.Type != model.MenuTypeButton {
+		req.Permission = ""
+	}
+	req.ID = id.Id
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := model.CheckMenuType(tx, req); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Menu{}).Where("id = ?", id.Id).Updates(req).Error; err != nil {
+			return err
+		}
+		// 改为非按钮时清空权限标识，Updates 不会更新零值
+		return tx.Model(&model.Menu{}).Where("id = ?", id.Id).Update("permission", req.Permission).Error
+	})
+	if err != nil {
+		l.l.Error(fmt.Sprintf("菜单更新失败, err: %v", err))
+		return nil, fmt.Errorf("菜单更新失败，%w", err)
 	}
 	// 查询出最新的记录
 	if err := l.db.WithContext(ctx).Model(&model.Menu{}).Where("id = ?", id.Id).First(req).Error; err != nil {
@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	apps "github.com/yanshicheng/ikube-gin-xjob/apps/upms"
@@ -10,17 +11,34 @@ import (
 	"github.com/yanshicheng/ikube-gin-xjob/common/sql"
 	"github.com/yanshicheng/ikube-gin-xjob/common/types"
 	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var _ service.RoleService = (*RoleLogic)(nil)
 
+var (
+	roleTable       = (&model.Role{}).TableName()
+	roleParentTable = (&model.RoleParent{}).TableName()
+)
+
 type RoleLogic struct {
 	l  *zap.Logger
 	db *gorm.DB
 }
 
+// RoleScopeChecker 检查当前账号能否设置角色的数据权限，数据权限由用户应用计算，未注册时不检查
+type RoleScopeChecker func(c *gin.Context, db *gorm.DB, role *model.Role) error
+
+var roleScopeChecker RoleScopeChecker
+
+// RegistryRoleScopeChecker 注册角色数据权限的检查函数
+func RegistryRoleScopeChecker(checker RoleScopeChecker) {
+	roleScopeChecker = checker
+}
+
 func (r *RoleLogic) List(c *gin.Context, search types2.RoleSearchReq) (*types.QueryResponse, error) {
 	var list []*model.Role
 	db := r.db.WithContext(c).Model(&model.Role{})
@@ -39,6 +57,13 @@ func (r *RoleLogic) List(c *gin.Context, search types2.RoleSearchReq) (*types.Qu
 	return queryRes, nil
 }
 func (r *RoleLogic) Create(c *gin.Context, req *model.Role) error {
+	// 未指定数据权限时只能看到本人
+	if req.DataScope == 0 {
+		req.DataScope = model.DataScopeSelf
+	}
+	if err := r.checkScope(c, req); err != nil {
+		return err
+	}
 	if err := r.db.WithContext(c).Create(req).Error; err != nil {
 		r.l.Error(fmt.Sprintf("创建角色失败: %s", err.Error()))
 		return fmt.Errorf("创建角色失败")
@@ -46,8 +71,18 @@ func (r *RoleLogic) Create(c *gin.Context, req *model.Role) error {
 	return nil
 }
 func (r *RoleLogic) Put(c *gin.Context, search types.SearchId, req *types2.RoleUpdateRequest) (*model.Role, error) {
-	// 只允许修改名称
-	if err := r.db.WithContext(c).Model(&model.Role{}).Where("id = ?", search.Id).Updates(model.Role{Name: req.Name}).Error; err != nil {
+	// 只允许修改名称和数据权限
+	columns := []string{"name"}
+	if req.DataScope != 0 {
+		columns = append(columns, "data_scope", "organization_ids")
+	}
+	role := model.Role{Name: req.Name, DataScope: req.DataScope, OrganizationIds: req.OrganizationIds}
+	if req.DataScope != 0 {
+		if err := r.checkScope(c, &role); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.db.WithContext(c).Model(&model.Role{}).Where("id = ?", search.Id).Select(columns).Updates(&role).Error; err != nil {
 		r.l.Error(fmt.Sprintf("修改角色失败: %s", err.Error()))
 		return nil, fmt.Errorf("修改角色失败")
 	}
@@ -60,19 +95,36 @@ func (r *RoleLogic) Put(c *gin.Context, search types.SearchId, req *types2.RoleU
 }
 
 func (r *RoleLogic) Delete(c *gin.Context, id types.SearchId) error {
-	// 检查 ikubexjob_user_account 表中是否存在这个 role_id
+	// 检查 ikubexjob_user_account_role 表中是否存在这个 role_id
 	var count int64
-	err := r.db.Table("ikubexjob_user_account").Where("role_id = ?", id.Id).Count(&count).Error
+	err := r.db.Table("ikubexjob_user_account_role").Where("role_id = ? AND deleted_at = 0", id.Id).Count(&count).Error
 	if err != nil {
 		r.l.Error(fmt.Sprintf("查询角色失败: %s", err.Error()))
 		return fmt.Errorf("查询角色失败")
 	}
 
+	// 用户组绑定的角色同样视为使用中
+	if count == 0 {
+		if err := r.db.Table("ikubexjob_user_group_role").Where("role_id = ? AND deleted_at = 0", id.Id).Count(&count).Error; err != nil {
+			r.l.Error(fmt.Sprintf("查询角色失败: %s", err.Error()))
+			return fmt.Errorf("查询角色失败")
+		}
+	}
 	// 如果存在引用，则不删除并返回错误
 	if count > 0 {
-		r.l.Error(fmt.Sprintf("无法删除角色，因为它在 ikubexjob_user_account 表中仍有引用"))
+		r.l.Error(fmt.Sprintf("无法删除角色，因为它在 ikubexjob_user_account_role 表中仍有引用"))
 		return fmt.Errorf("无法删除角色，角色正在使用中")
 	}
+	// 被其他角色继承时同样不能删除，否则继承它的角色会失去菜单和权限
+	if err := r.db.WithContext(c).Model(&model.RoleParent{}).
+		Joins(fmt.Sprintf("JOIN %s r ON r.id = %s.role_id AND r.deleted_at = 0", roleTable, roleParentTable)).
+		Where(roleParentTable+".parent_id = ?", id.Id).Count(&count).Error; err != nil {
+		r.l.Error(fmt.Sprintf("查询角色失败: %s", err.Error()))
+		return fmt.Errorf("查询角色失败")
+	}
+	if count > 0 {
+		return fmt.Errorf("无法删除角色，角色被其他角色继承")
+	}
 	result := r.db.WithContext(c).Model(&model.Role{}).Where("id = ?", id.Id).Delete(&model.Role{})
 	if err := result.Error; err != nil {
 		r.l.Error(fmt.Sprintf("删除角色失败: %s", err.Error()))
@@ -83,9 +135,260 @@ func (r *RoleLogic) Delete(c *gin.Context, id types.SearchId) error {
 		r.l.Error("删除角色失败: 未找到指定的角色")
 		return fmt.Errorf("删除角色失败: 未找到指定的角色")
 	}
+	model.InvalidateRole(c, id.Id)
 	return nil
 }
 
+// Parents 查询角色直接继承的上级角色
+func (r *RoleLogic) Parents(c *gin.Context, id types.SearchId) ([]*model.Role, error) {
+	if _, err := r.role(c, id.Id); err != nil {
+		return nil, err
+	}
+	list := []*model.Role{}
+	if err := r.db.WithContext(c).
+		Where(fmt.Sprintf("id IN (SELECT parent_id FROM %s WHERE role_id = ? AND deleted_at = 0)", roleParentTable), id.Id).
+		Order("id").Find(&list).Error; err != nil {
+		r.l.Error(fmt.Sprintf("查询上级角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询上级角色失败")
+	}
+	return list, nil
+}
+
+// SetParents 设置角色继承的上级角色，角色自身以及继承它的角色的缓存随之失效
+func (r *RoleLogic) SetParents(c *gin.Context, id types.SearchId, req *types2.RoleParentReq) ([]*model.Role, error) {
+	ids := make([]uint, 0, len(req.ParentIds))
+	for _, parentId := range req.ParentIds {
+		if parentId == id.Id {
+			return nil, fmt.Errorf("角色不能继承自身")
+		}
+		if !containsId(ids, parentId) {
+			ids = append(ids, parentId)
+		}
+	}
+	// 只能继承当前账号已经拥有的角色，避免借助继承获得其他角色的菜单和权限
+	owned, err := r.ownedRoleIds(c)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// 锁定角色表，避免并发设置形成环
+		var roles []*model.Role
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&roles).Error; err != nil {
+			return fmt.Errorf("查询角色失败")
+		}
+		names := make(map[uint]string, len(roles))
+		for _, role := range roles {
+			names[role.ID] = role.Name
+		}
+		if _, ok := names[id.Id]; !ok {
+			return fmt.Errorf("角色不存在")
+		}
+		for _, parentId := range ids {
+			if _, ok := names[parentId]; !ok {
+				return fmt.Errorf("上级角色 %d 不存在", parentId)
+			}
+			if !containsId(owned, parentId) {
+				return fmt.Errorf("当前账号没有角色 %s，不能继承", names[parentId])
+			}
+		}
+		// 已删除角色的继承关系同样参与环检测，否则从回收站恢复后可能形成环
+		var edges []*model.RoleParent
+		if err := tx.Where("role_id <> ?", id.Id).Find(&edges).Error; err != nil {
+			return fmt.Errorf("查询上级角色失败")
+		}
+		parents := make(map[uint][]uint)
+		for _, edge := range edges {
+			parents[edge.RoleId] = append(parents[edge.RoleId], edge.ParentId)
+		}
+		for _, parentId := range ids {
+			if reachable(parents, parentId, id.Id) {
+				return fmt.Errorf("角色 %s 已经继承 %s，不能形成环", names[parentId], names[id.Id])
+			}
+		}
+		remove := tx.Where("role_id = ?", id.Id)
+		if len(ids) > 0 {
+			remove = remove.Where("parent_id NOT IN ?", ids)
+		}
+		if err := remove.Delete(&model.RoleParent{}).Error; err != nil {
+			return err
+		}
+		var existing []uint
+		if err := tx.Model(&model.RoleParent{}).Where("role_id = ?", id.Id).Pluck("parent_id", &existing).Error; err != nil {
+			return err
+		}
+		for _, parentId := range ids {
+			if containsId(existing, parentId) {
+				continue
+			}
+			if err := tx.Create(&model.RoleParent{RoleId: id.Id, ParentId: parentId}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.l.Error(fmt.Sprintf("设置上级角色失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("设置上级角色失败: %w", err)
+	}
+	model.InvalidateRole(c, id.Id)
+	return r.Parents(c, id)
+}
+
+// Permissions 查询角色生效的菜单和权限，包含从上级角色继承的部分，并标明每一项的来源角色
+func (r *RoleLogic) Permissions(c *gin.Context, id types.SearchId) (*types2.RolePermissionsResp, error) {
+	role, err := r.role(c, id.Id)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := model.RoleAncestors(c, r.db, role.ID)
+	if err != nil {
+		r.l.Error(fmt.Sprintf("查询上级角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询上级角色失败")
+	}
+	roleIds := []uint{role.ID}
+	for _, ancestor := range ancestors {
+		roleIds = append(roleIds, ancestor.RoleId)
+	}
+	var roles []*model.Role
+	if err := r.db.WithContext(c).Where("id IN ?", roleIds).Find(&roles).Error; err != nil {
+		r.l.Error(fmt.Sprintf("查询上级角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询上级角色失败")
+	}
+	names := make(map[uint]string, len(roles))
+	for _, item := range roles {
+		names[item.ID] = item.Name
+	}
+	resp := &types2.RolePermissionsResp{
+		RoleId:    role.ID,
+		RoleName:  role.Name,
+		Ancestors: []*types2.PermissionSource{},
+		Menus:     []*types2.EffectiveMenu{},
+		Upms:      []*types2.EffectiveUpms{},
+	}
+	sources := map[uint]*types2.PermissionSource{
+		role.ID: {RoleId: role.ID, RoleName: role.Name, Path: []string{role.Name}},
+	}
+	for _, ancestor := range ancestors {
+		source := &types2.PermissionSource{RoleId: ancestor.RoleId, RoleName: names[ancestor.RoleId], Inherited: true, Path: []string{role.Name}}
+		for _, roleId := range ancestor.Path {
+			source.Path = append(source.Path, names[roleId])
+		}
+		sources[ancestor.RoleId] = source
+		resp.Ancestors = append(resp.Ancestors, source)
+	}
+	var roleMenus []*model.RoleMenu
+	if err := r.db.WithContext(c).Where("role_id IN ?", roleIds).Find(&roleMenus).Error; err != nil {
+		r.l.Error(fmt.Sprintf("查询角色菜单失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询角色菜单失败")
+	}
+	if len(roleMenus) > 0 {
+		menuSources := make(map[uint][]*types2.PermissionSource)
+		menuIds := make([]uint, 0, len(roleMenus))
+		for _, roleMenu := range roleMenus {
+			if _, ok := menuSources[roleMenu.MenuId]; !ok {
+				menuIds = append(menuIds, roleMenu.MenuId)
+			}
+			menuSources[roleMenu.MenuId] = append(menuSources[roleMenu.MenuId], sources[roleMenu.RoleId])
+		}
+		var menus []*model.Menu
+		if err := r.db.WithContext(c).Where("id IN ?", menuIds).Order("order_no ASC, id ASC").Find(&menus).Error; err != nil {
+			r.l.Error(fmt.Sprintf("查询角色菜单失败: %s", err.Error()))
+			return nil, fmt.Errorf("查询角色菜单失败")
+		}
+		for _, menu := range menus {
+			resp.Menus = append(resp.Menus, &types2.EffectiveMenu{
+				Id:         menu.ID,
+				Name:       menu.Name,
+				Title:      menu.Title,
+				Path:       menu.Path,
+				Type:       menu.Type,
+				Permission: menu.Permission,
+				Sources:    menuSources[menu.ID],
+			})
+		}
+	}
+	var upms []*model.Upms
+	if err := r.db.WithContext(c).Where("role_id IN ?", roleIds).Order("menu_id, id").Find(&upms).Error; err != nil {
+		r.l.Error(fmt.Sprintf("查询角色权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询角色权限失败")
+	}
+	for _, item := range upms {
+		resp.Upms = append(resp.Upms, &types2.EffectiveUpms{Upms: item, Source: sources[item.RoleId]})
+	}
+	return resp, nil
+}
+
+// role 查询角色
+func (r *RoleLogic) role(c *gin.Context, id uint) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.WithContext(c).Where("id = ?", id).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("角色不存在")
+		}
+		r.l.Error(fmt.Sprintf("查询角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询角色失败")
+	}
+	return &role, nil
+}
+
+// ownedRoleIds 当前账号拥有的角色，包含令牌中的角色及其全部上级角色
+func (r *RoleLogic) ownedRoleIds(c *gin.Context) ([]uint, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	ids := []uint{}
+	if len(claims.Application.Role) == 0 {
+		return ids, nil
+	}
+	if err := r.db.WithContext(c).Model(&model.Role{}).Where("name IN ?", claims.Application.Role).Pluck("id", &ids).Error; err != nil {
+		r.l.Error(fmt.Sprintf("查询当前账号角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询当前账号角色失败")
+	}
+	ids, err = model.RoleAncestorIds(c, r.db, ids)
+	if err != nil {
+		r.l.Error(fmt.Sprintf("查询上级角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询上级角色失败")
+	}
+	return ids, nil
+}
+
+// checkScope 角色的数据权限不能超过当前账号，避免借助角色扩大数据权限
+func (r *RoleLogic) checkScope(c *gin.Context, role *model.Role) error {
+	if roleScopeChecker == nil {
+		return nil
+	}
+	return roleScopeChecker(c, r.db, role)
+}
+
+// reachable 沿上级关系从 from 出发能否到达 to
+func reachable(parents map[uint][]uint, from, to uint) bool {
+	visited := map[uint]bool{from: true}
+	for stack := []uint{from}; len(stack) > 0; {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == to {
+			return true
+		}
+		for _, parent := range parents[id] {
+			if !visited[parent] {
+				visited[parent] = true
+				stack = append(stack, parent)
+			}
+		}
+	}
+	return false
+}
+
+func containsId(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
 // Config 只需要保证 全局对象Config和全局Logger已经加载完成
 func (r *RoleLogic) Config() {
 	r.l = global.L.Named(apps.AppName).Named(apps.AppRole).Named("logic")
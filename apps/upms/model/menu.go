@@ -0,0 +1,63 @@
+package model
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CheckMenuType 校验菜单类型：按钮必须有权限标识且只能是页面的下级菜单，不能再有下级菜单，
+// 有按钮的菜单只能是页面，未删除按钮的权限标识唯一。menu.ID 为 0 时为新建菜单。
+// 非按钮的权限标识都为空，不能使用唯一索引，校验按钮时锁定全部按钮，调用方必须在同一事务内保存菜单
+func CheckMenuType(tx *gorm.DB, menu *Menu) error {
+	var parent *Menu
+	if menu.TreeParentId() != 0 {
+		parent = &Menu{}
+		if err := tx.Where("id = ?", menu.TreeParentId()).First(parent).Error; err != nil {
+			return fmt.Errorf("上级菜单 %d 不存在", menu.TreeParentId())
+		}
+		if parent.Type == MenuTypeButton {
+			return fmt.Errorf("按钮 %s 不能有下级菜单", parent.Name)
+		}
+	}
+	if menu.Type != MenuTypeButton {
+		if menu.ID == 0 || menu.Type == MenuTypePage {
+			return nil
+		}
+		var count int64
+		if err := tx.Model(&Menu{}).Where("parent_id = ? AND type = ?", menu.ID, MenuTypeButton).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("菜单下有按钮，只能是页面")
+		}
+		return nil
+	}
+	if menu.Permission == "" {
+		return fmt.Errorf("按钮必须设置权限标识")
+	}
+	if parent == nil || parent.Type != MenuTypePage {
+		return fmt.Errorf("按钮只能是页面的下级菜单")
+	}
+	var count int64
+	if menu.ID != 0 {
+		if err := tx.Model(&Menu{}).Where("parent_id = ?", menu.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("菜单有下级菜单，不能改为按钮")
+		}
+	}
+	// 锁定按钮，避免并发保存相同权限标识的按钮
+	var buttons []*Menu
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "permission").
+		Where("type = ?", MenuTypeButton).Find(&buttons).Error; err != nil {
+		return err
+	}
+	for _, button := range buttons {
+		if button.ID != menu.ID && button.Permission == menu.Permission {
+			return fmt.Errorf("权限标识 %s 已经存在", menu.Permission)
+		}
+	}
+	return nil
+}
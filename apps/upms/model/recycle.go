@@ -0,0 +1,53 @@
+package model
+
+import (
+	"fmt"
+	"github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"gorm.io/gorm"
+)
+
+func init() {
+	model.RegisterRecyclable(
+		&model.Recyclable{Name: "role", Title: "角色", Model: &Role{}, Restored: invalidateRoles},
+		&model.Recyclable{Name: "menu", Title: "菜单", Model: &Menu{}, Validate: validateMenuRestore},
+		&model.Recyclable{Name: "upms", Title: "权限", Model: &Upms{}, Validate: validateUpmsRestore},
+	)
+}
+
+// exists 查询未删除的记录是否存在
+func exists(tx *gorm.DB, m interface{}, id uint) (bool, error) {
+	var count int64
+	if err := tx.Model(m).Where("id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// validateMenuRestore 上级菜单必须存在，按钮的上级菜单仍然是页面且权限标识没有被占用
+func validateMenuRestore(tx *gorm.DB, record interface{}) error {
+	menu := record.(*Menu)
+	if menu.ParentId != nil && *menu.ParentId != 0 {
+		if ok, err := exists(tx, &Menu{}, *menu.ParentId); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("上级菜单 %d 不存在或已删除，请先恢复上级菜单", *menu.ParentId)
+		}
+	}
+	return CheckMenuType(tx, menu)
+}
+
+// validateUpmsRestore 权限关联的角色和菜单必须存在
+func validateUpmsRestore(tx *gorm.DB, record interface{}) error {
+	upms := record.(*Upms)
+	if ok, err := exists(tx, &Role{}, upms.RoleId); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("权限关联的角色 %d 不存在或已删除，请先恢复角色", upms.RoleId)
+	}
+	if ok, err := exists(tx, &Menu{}, upms.MenuId); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("权限关联的菜单 %d 不存在或已删除，请先恢复菜单", upms.MenuId)
+	}
+	return nil
+}
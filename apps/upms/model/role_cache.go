@@ -0,0 +1,174 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/upms"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 角色继承关系缓存在进程内，按角色保存它的全部上级角色和继承路径，菜单和权限不缓存，修改后立即生效。
+// 角色的上级变更后丢弃该角色以及全部继承它的角色，启用 redis 时通过发布订阅通知其他实例，
+// 通知丢失时缓存最迟在有效期后更新
+const (
+	roleCacheTTL     = 5 * time.Minute
+	roleCacheChannel = "ikubexjob:role:changed" // 变更通知，消息为变更的角色 ID，0 表示全部角色
+)
+
+// RoleAncestor 角色继承的上级角色，Path 为从角色的直接上级到该上级角色的继承路径，存在多条路径时取最短的一条
+type RoleAncestor struct {
+	RoleId uint
+	Path   []uint
+}
+
+type roleCacheEntry struct {
+	ancestors []*RoleAncestor
+	expireAt  time.Time
+}
+
+type roleCache struct {
+	mu         sync.RWMutex
+	entries    map[uint]*roleCacheEntry
+	generation uint64 // 每次丢弃缓存时加一，加载期间发生变更时不保存加载结果
+	listen     sync.Once
+}
+
+var roleAncestorCache = &roleCache{entries: map[uint]*roleCacheEntry{}}
+
+func roleCacheLogger() *zap.Logger {
+	return global.L.Named(apps.AppName).Named(apps.AppRole).Named("cache")
+}
+
+// RoleAncestors 查询角色的全部上级角色，按继承层级排序，已删除的角色不生效也不再向上展开
+func RoleAncestors(ctx context.Context, db *gorm.DB, id uint) ([]*RoleAncestor, error) {
+	c := roleAncestorCache
+	c.listen.Do(c.subscribe)
+	c.mu.RLock()
+	entry, generation := c.entries[id], c.generation
+	c.mu.RUnlock()
+	if entry != nil && time.Now().Before(entry.expireAt) {
+		return entry.ancestors, nil
+	}
+	ancestors, err := loadRoleAncestors(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.generation == generation {
+		c.entries[id] = &roleCacheEntry{ancestors: ancestors, expireAt: time.Now().Add(roleCacheTTL)}
+	}
+	c.mu.Unlock()
+	return ancestors, nil
+}
+
+// RoleAncestorIds 查询角色自身及其全部上级角色的 ID
+func RoleAncestorIds(ctx context.Context, db *gorm.DB, ids []uint) ([]uint, error) {
+	result := make([]uint, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	add := func(id uint) {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	for _, id := range ids {
+		add(id)
+		ancestors, err := RoleAncestors(ctx, db, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, ancestor := range ancestors {
+			add(ancestor.RoleId)
+		}
+	}
+	return result, nil
+}
+
+// loadRoleAncestors 逐层向上查找上级角色，先找到的路径最短
+func loadRoleAncestors(ctx context.Context, db *gorm.DB, id uint) ([]*RoleAncestor, error) {
+	roleTable := (&Role{}).TableName()
+	parentTable := (&RoleParent{}).TableName()
+	ancestors := []*RoleAncestor{}
+	paths := map[uint][]uint{id: {}}
+	for frontier := []uint{id}; len(frontier) > 0; {
+		var edges []*RoleParent
+		if err := db.WithContext(ctx).Model(&RoleParent{}).
+			Select(parentTable+".role_id", parentTable+".parent_id").
+			Joins(fmt.Sprintf("JOIN %s r ON r.id = %s.parent_id AND r.deleted_at = 0", roleTable, parentTable)).
+			Where(parentTable+".role_id IN ?", frontier).
+			Order(parentTable + ".parent_id").
+			Find(&edges).Error; err != nil {
+			return nil, err
+		}
+		frontier = nil
+		for _, edge := range edges {
+			if _, ok := paths[edge.ParentId]; ok {
+				continue
+			}
+			path := append(append([]uint{}, paths[edge.RoleId]...), edge.ParentId)
+			paths[edge.ParentId] = path
+			ancestors = append(ancestors, &RoleAncestor{RoleId: edge.ParentId, Path: path})
+			frontier = append(frontier, edge.ParentId)
+		}
+	}
+	return ancestors, nil
+}
+
+// InvalidateRole 角色的上级变更后调用，丢弃角色自身以及全部继承它的角色，id 为 0 时丢弃全部角色
+func InvalidateRole(ctx context.Context, id uint) {
+	roleAncestorCache.drop(id)
+	if global.RDB == nil {
+		return
+	}
+	if err := global.RDB.GetClient().Publish(ctx, roleCacheChannel, id).Err(); err != nil {
+		roleCacheLogger().Error(fmt.Sprintf("发布角色变更通知失败: %s", err.Error()))
+	}
+}
+
+// invalidateRoles 回收站恢复角色后丢弃全部角色
+func invalidateRoles(ctx context.Context) {
+	InvalidateRole(ctx, 0)
+}
+
+// drop 丢弃进程内的缓存，继承了该角色的角色其上级角色中一定包含该角色
+func (c *roleCache) drop(id uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if id == 0 {
+		c.entries = map[uint]*roleCacheEntry{}
+		return
+	}
+	delete(c.entries, id)
+	for roleId, entry := range c.entries {
+		for _, ancestor := range entry.ancestors {
+			if ancestor.RoleId == id {
+				delete(c.entries, roleId)
+				break
+			}
+		}
+	}
+}
+
+// subscribe 订阅其他实例的变更通知，连接断开时由客户端自动重连
+func (c *roleCache) subscribe() {
+	if global.RDB == nil {
+		return
+	}
+	pubsub := global.RDB.GetClient().Subscribe(context.Background(), roleCacheChannel)
+	go func() {
+		for msg := range pubsub.Channel() {
+			id, err := strconv.ParseUint(msg.Payload, 10, 64)
+			if err != nil {
+				roleCacheLogger().Warn(fmt.Sprintf("角色变更通知格式错误: %s", msg.Payload))
+				continue
+			}
+			c.drop(uint(id))
+		}
+	}()
+}
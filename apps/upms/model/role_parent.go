@@ -0,0 +1,23 @@
+package model
+
+import "github.com/yanshicheng/ikube-gin-xjob/common/model"
+
+func init() {
+	model.Register(&RoleParent{})
+}
+
+// RoleParent 角色继承的上级角色，角色拥有全部上级角色的菜单和权限，一个角色可以继承多个角色，不能形成环
+type RoleParent struct {
+	model.Model
+	RoleId   uint `json:"roleId" gorm:"type:int;not null;comment:角色ID"`
+	ParentId uint `json:"parentId" gorm:"type:int;not null;index;comment:上级角色ID"`
+}
+
+func (r *RoleParent) TableName() string {
+	return "ikubexjob_upms_role_parent"
+}
+
+// UniqueIndexes 同一角色不能重复继承同一上级角色
+func (r *RoleParent) UniqueIndexes() map[string][]string {
+	return map[string][]string{"uk_role_parent": {"role_id", "parent_id"}}
+}
@@ -4,7 +4,10 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
 	"gorm.io/gorm"
+	"strconv"
 )
 
 // 角色表，角色菜单关联表，角色账户关联表， 权限表，
@@ -12,27 +15,27 @@ func init() {
 	model.Register(&Menu{}, &Role{}, &RoleMenu{}, &Upms{})
 }
 
-const MenuLevel = 3
-
 type Menu struct {
 	model.Model
-	Path             string  `json:"path" form:"path" binding:"required,max=32" gorm:"type:varchar(32);not null;comment:路由路径"`
-	Name             string  `json:"name" form:"name" binding:"required,max=32" gorm:"type:varchar(32);not null;unique;comment:唯一标识名称" `
-	Component        string  `json:"component" form:"component" binding:"required,max=255" gorm:"type:varchar(255);not null;comment:组件路径" `
-	Redirect         string  `json:"redirect,omitempty" form:"redirect" binding:"max=255" gorm:"type:varchar(255);comment:重定向路径" `
-	Title            string  `json:"title" form:"title" binding:"required,max=26" gorm:"type:varchar(26);not null;comment:菜单标题" `
-	Icon             string  `json:"icon"  form:"icon" binding:"max=32" gorm:"type:varchar(32);comment:菜单图标" `
-	Expanded         bool    `json:"expanded"  form:"expanded" binding:"boolean" gorm:"type:tinyint(1);default:false;comment:是否默认展开" `
-	OrderNo          *int    `json:"orderNo" form:"orderNo" binding:"required,number" gorm:"type:tinyint;not null;comment:菜单顺序编号" `
-	Hidden           bool    `json:"hidden"  form:"hidden" binding:"required" gorm:"default:false;comment:是否隐藏菜单"`
-	HiddenBreadcrumb bool    `json:"hiddenBreadcrumb" form:"hiddenBreadcrumb" binding:"boolean" gorm:"type:tinyint(1);default:false;comment:是否隐藏面包屑"`
-	Single           bool    `json:"single" form:"single" binding:"boolean" gorm:"type:tinyint(1);default:false;comment:是否单级菜单显示"`
-	FrameSrc         string  `json:"frameSrc" form:"frameSrc" binding:"max=255" gorm:"type:varchar(255);comment:内嵌iframe的地址"`
-	FrameBlank       bool    `json:"frameBlank" form:"frameBlank" binding:"boolean" gorm:"type:tinyint(1);default:false;comment:内嵌iframe是否新窗口打开" `
-	KeepAlive        bool    `json:"keepAlive" form:"keepAlive" binding:"boolean" gorm:"type:tinyint(1);default:true;comment:开启keep-alive"`
-	ParentId         *uint   `json:"parentId" form:"parentId"  binding:"required,number" gorm:"type:int;not null;comment:父级"` // 关联父级路由
-	Level            int     `json:"level" form:"level" gorm:"type:int;not null;comment:层级"`
-	Children         []*Menu `gorm:"-" json:"children"` // 子路由，不存储在数据库中，只用于加载和显示
+	Type             MenuType `json:"type" form:"type" binding:"omitempty,oneof=1 2 3" gorm:"type:tinyint;not null;default:2;comment:菜单类型"` // 为空时为页面
+	Path             string   `json:"path" form:"path" binding:"required_unless=Type 3,max=32" gorm:"type:varchar(32);not null;comment:路由路径"`
+	Name             string   `json:"name" form:"name" binding:"required,max=32" gorm:"type:varchar(32);not null;comment:唯一标识名称" `
+	Component        string   `json:"component" form:"component" binding:"required_unless=Type 3,max=255" gorm:"type:varchar(255);not null;comment:组件路径" `
+	Permission       string   `json:"permission,omitempty" form:"permission" binding:"required_if=Type 3,max=64" gorm:"type:varchar(64);not null;default:'';comment:按钮权限标识"` // 只有按钮有权限标识
+	Redirect         string   `json:"redirect,omitempty" form:"redirect" binding:"max=255" gorm:"type:varchar(255);comment:重定向路径" `
+	Title            string   `json:"title" form:"title" binding:"required,max=26" gorm:"type:varchar(26);not null;comment:菜单标题" `
+	Icon             string   `json:"icon"  form:"icon" binding:"max=32" gorm:"type:varchar(32);comment:菜单图标" `
+	Expanded         bool     `json:"expanded"  form:"expanded" binding:"boolean" gorm:"type:tinyint(1);default:false;comment:是否默认展开" `
+	OrderNo          *int     `json:"orderNo" form:"orderNo" binding:"required,number" gorm:"type:tinyint;not null;comment:菜单顺序编号" `
+	Hidden           bool     `json:"hidden"  form:"hidden" binding:"required" gorm:"default:false;comment:是否隐藏菜单"`
+	HiddenBreadcrumb bool     `json:"hiddenBreadcrumb" form:"hiddenBreadcrumb" binding:"boolean" gorm:"type:tinyint(1);default:false;comment:是否隐藏面包屑"`
+	Single           bool     `json:"single" form:"single" binding:"boolean" gorm:"type:tinyint(1);default:false;comment:是否单级菜单显示"`
+	FrameSrc         string   `json:"frameSrc" form:"frameSrc" binding:"max=255" gorm:"type:varchar(255);comment:内嵌iframe的地址"`
+	FrameBlank       bool     `json:"frameBlank" form:"frameBlank" binding:"boolean" gorm:"type:tinyint(1);default:false;comment:内嵌iframe是否新窗口打开" `
+	KeepAlive        bool     `json:"keepAlive" form:"keepAlive" binding:"boolean" gorm:"type:tinyint(1);default:true;comment:开启keep-alive"`
+	ParentId         *uint    `json:"parentId" form:"parentId"  binding:"required,number" gorm:"type:int;not null;comment:父级"` // 关联父级路由
+	Level            int      `json:"level" form:"level" gorm:"type:int;not null;comment:层级"`
+	Children         []*Menu  `gorm:"-" json:"children"` // 子路由，不存储在数据库中，只用于加载和显示
 
 }
 
@@ -40,11 +43,27 @@ func (*Menu) TableName() string {
 	return "ikubexjob_upms_menu"
 }
 
+func (m *Menu) TreeId() uint                     { return m.ID }
+func (m *Menu) TreeChildren() []*Menu            { return m.Children }
+func (m *Menu) SetTreeChildren(children []*Menu) { m.Children = children }
+
+// TreeParentId 上级菜单 ID，未设置时为顶层菜单
+func (m *Menu) TreeParentId() uint {
+	if m.ParentId == nil {
+		return 0
+	}
+	return *m.ParentId
+}
+
+// UniqueIndexes 菜单标识名称唯一
+func (*Menu) UniqueIndexes() map[string][]string {
+	return map[string][]string{"uk_name": {"name"}}
+}
+
 // BeforeCreate 机构表 创建钩子函数
 func (o *Menu) BeforeCreate(tx *gorm.DB) error {
 	// 检查是否有父节点，如果没有父节点，则为根节点
-	ParentId := int(*o.ParentId)
-	if ParentId != 0 {
+	if o.TreeParentId() != 0 {
 		// 如果 ParentID 不为0，说明此节点有父节点
 
 		var parent Menu
@@ -56,14 +75,16 @@ func (o *Menu) BeforeCreate(tx *gorm.DB) error {
 			return err // 返回错误，中断创建操作
 		}
 
-		// 如果父节点查询成功，设置当前节点的层级为父节点层级 + 1
-		o.Level = parent.Level + 1
-
-		// 检查层级是否超过5
-		if o.Level > MenuLevel {
-			// 如果层级超过5，返回错误
-			return fmt.Errorf("cannot add beyond level %d", MenuLevel)
+		// 如果父节点查询成功，设置当前节点的层级为父节点层级 + 1，层级不能超过上限，按钮不占用层级
+		if o.Type == MenuTypeButton {
+			o.Level = parent.Level + 1
+			return nil
+		}
+		level, err := tree.ChildLevel(parent.Level, global.C.Menu.MaxLevel)
+		if err != nil {
+			return err
 		}
+		o.Level = level
 	} else {
 		// 如果 ParentID 为0，说明此节点没有父节点，即它是一个根节点
 		o.Level = 1 // 设置根节点的层级为1
@@ -74,28 +95,40 @@ func (o *Menu) BeforeCreate(tx *gorm.DB) error {
 
 type Role struct {
 	model.Model
-	Name string `json:"name" form:"name" binding:"required,alphanum,max=32" gorm:"type:varchar(32);not null;unique;comment:角色"`
+	Name            string    `json:"name" form:"name" binding:"required,alphanum,max=32" gorm:"type:varchar(32);not null;comment:角色"`
+	DataScope       DataScope `json:"dataScope" form:"dataScope" binding:"omitempty,oneof=1 2 3 4 5" gorm:"type:tinyint;not null;default:5;comment:数据权限范围"`                 // 为空时仅本人
+	OrganizationIds []uint    `json:"organizationIds" form:"organizationIds" binding:"required_if=DataScope 4" gorm:"type:varchar(1024);serializer:json;comment:自定义数据权限机构"` // 数据权限为自定义时生效
 }
 
 func (r *Role) TableName() string {
 	return "ikubexjob_upms_role"
 }
 
+// UniqueIndexes 角色名称唯一
+func (r *Role) UniqueIndexes() map[string][]string {
+	return map[string][]string{"uk_name": {"name"}}
+}
+
 type RoleMenu struct {
 	model.Model
-	RoleId uint `json:"roleId" form:"roleId" binding:"required,number" gorm:"type:int;not null;uniqueIndex:idx_role_menu;comment:角色" `
-	MenuId uint `json:"menuId" form:"menuId" binding:"required,number" gorm:"type:int;not null;uniqueIndex:idx_role_menu;comment:菜单" `
+	RoleId uint `json:"roleId" form:"roleId" binding:"required,number" gorm:"type:int;not null;comment:角色" `
+	MenuId uint `json:"menuId" form:"menuId" binding:"required,number" gorm:"type:int;not null;comment:菜单" `
 }
 
 func (r *RoleMenu) TableName() string {
 	return "ikubexjob_upms_role_menu"
 }
 
+// UniqueIndexes 同一角色不能重复绑定同一菜单
+func (r *RoleMenu) UniqueIndexes() map[string][]string {
+	return map[string][]string{"idx_role_menu": {"role_id", "menu_id"}}
+}
+
 type Upms struct {
 	model.Model
-	Name     string     `json:"name" form:"name" binding:"required,max=32" gorm:"type:varchar(32);not null;unique;comment:权限名称"`
-	RoleId   uint       `json:"roleId" form:"roleId" binding:"required,number" gorm:"type:int;not null;role_menu_unique;comment:角色"`
-	MenuId   uint       `json:"menuId" form:"menuId" binding:"required,number" gorm:"type:int;not null;uniqueIndex:role_menu_unique;comment:菜单" `
+	Name     string     `json:"name" form:"name" binding:"required,max=32" gorm:"type:varchar(32);not null;comment:权限名称"`
+	RoleId   uint       `json:"roleId" form:"roleId" binding:"required,number" gorm:"type:int;not null;comment:角色"`
+	MenuId   uint       `json:"menuId" form:"menuId" binding:"required,number" gorm:"type:int;not null;comment:菜单" `
 	Resource string     `json:"resource" form:"resource" binding:"required,max=255" gorm:"type:varchar(255);not null;comment:资源"`
 	Type     ActionType `json:"type" form:"type"  binding:"required,oneof=0 1" gorm:"type:tinyint;not null;comment:操作类型"`
 }
@@ -104,6 +137,34 @@ func (u *Upms) TableName() string {
 	return "ikubexjob_upms_upms"
 }
 
+// UniqueIndexes 权限名称唯一，同一角色的同一菜单只能有一条权限
+func (u *Upms) UniqueIndexes() map[string][]string {
+	return map[string][]string{
+		"uk_name":          {"name"},
+		"role_menu_unique": {"role_id", "menu_id"},
+	}
+}
+
+// DataScope 角色的数据权限范围，账号拥有多个角色时取并集
+type DataScope uint
+
+const (
+	DataScopeAll                     DataScope = 1 // 全部数据
+	DataScopeOrganization            DataScope = 2 // 本机构
+	DataScopeOrganizationAndChildren DataScope = 3 // 本机构及下级机构
+	DataScopeCustom                  DataScope = 4 // 自定义机构
+	DataScopeSelf                    DataScope = 5 // 仅本人
+)
+
+// MenuType 菜单类型，按钮只能是页面的下级菜单，不能再有下级菜单
+type MenuType uint
+
+const (
+	MenuTypeDirectory MenuType = 1 // 目录
+	MenuTypePage      MenuType = 2 // 页面
+	MenuTypeButton    MenuType = 3 // 按钮，通过权限标识控制页面中的操作
+)
+
 // ActionType  定义 ActionType 类型
 type ActionType uint
 
@@ -125,13 +186,22 @@ func (a *ActionType) String() string {
 	}
 }
 
-// Scan 实现  接口
+// Scan 实现  接口，数据库驱动读取整数时返回 int64，部分驱动返回字节切片
 func (a *ActionType) Scan(value interface{}) error {
-	v, ok := value.(uint)
-	if !ok {
+	switch v := value.(type) {
+	case int64:
+		*a = ActionType(v)
+	case uint:
+		*a = ActionType(v)
+	case []byte:
+		n, err := strconv.ParseUint(string(v), 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid value for ActionType: %v", value)
+		}
+		*a = ActionType(n)
+	default:
 		return fmt.Errorf("invalid value for ActionType: %v", value)
 	}
-	*a = ActionType(v)
 	return nil
 }
 
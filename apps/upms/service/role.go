@@ -13,4 +13,7 @@ type RoleService interface {
 	Create(*gin.Context, *model.Role) error
 	Put(*gin.Context, types.SearchId, *types2.RoleUpdateRequest) (*model.Role, error)
 	Delete(*gin.Context, types.SearchId) error
+	Parents(*gin.Context, types.SearchId) ([]*model.Role, error)
+	SetParents(*gin.Context, types.SearchId, *types2.RoleParentReq) ([]*model.Role, error)
+	Permissions(*gin.Context, types.SearchId) (*types2.RolePermissionsResp, error)
 }
@@ -1,17 +1,59 @@
-package types
-
-import "github.com/yanshicheng/ikube-gin-xjob/common/types"
-
-type RoleSearchReq struct {
-	Name string `json:"name" form:"name" uri:"name"`
-	types.Pagination
-}
-
-type RoleUpdateRequest struct {
-	Name string `json:"name" form:"name" binding:"required,max=32"`
-}
-
-type RoleAccountBindRequest struct {
-	AccountId []uint `json:"accountId" form:"accountId" binding:"required"`
-	RoleId    uint   `json:"roleId" form:"roleId" binding:"required,number"`
-}
+package types
+
+import (
+	"github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+)
+
+type RoleSearchReq struct {
+	Name string `json:"name" form:"name" uri:"name"`
+	types.Pagination
+}
+
+type RoleUpdateRequest struct {
+	Name            string          `json:"name" form:"name" binding:"required,max=32"`
+	DataScope       model.DataScope `json:"dataScope" form:"dataScope" binding:"omitempty,oneof=1 2 3 4 5"` // 为空时不修改数据权限
+	OrganizationIds []uint          `json:"organizationIds" form:"organizationIds" binding:"required_if=DataScope 4"`
+}
+
+type RoleAccountBindRequest struct {
+	AccountId []uint `json:"accountId" form:"accountId" binding:"required"`
+	RoleId    uint   `json:"roleId" form:"roleId" binding:"required,number"`
+}
+
+type RoleParentReq struct {
+	ParentIds []uint `json:"parentIds" form:"parentIds"` // 为空时不再继承任何角色
+}
+
+// PermissionSource 授权来源，Inherited 为 false 时直接授予角色自身，否则 Path 为从角色到来源角色的继承路径
+type PermissionSource struct {
+	RoleId    uint     `json:"roleId"`
+	RoleName  string   `json:"roleName"`
+	Inherited bool     `json:"inherited"`
+	Path      []string `json:"path"`
+}
+
+// EffectiveMenu 角色生效的菜单，同一菜单可以同时来自多个角色
+type EffectiveMenu struct {
+	Id         uint                `json:"id"`
+	Name       string              `json:"name"`
+	Title      string              `json:"title"`
+	Path       string              `json:"path"`
+	Type       model.MenuType      `json:"type"`
+	Permission string              `json:"permission,omitempty"` // 按钮的权限标识
+	Sources    []*PermissionSource `json:"sources"`
+}
+
+// EffectiveUpms 角色生效的权限
+type EffectiveUpms struct {
+	*model.Upms
+	Source *PermissionSource `json:"source"`
+}
+
+type RolePermissionsResp struct {
+	RoleId    uint                `json:"roleId"`
+	RoleName  string              `json:"roleName"`
+	Ancestors []*PermissionSource `json:"ancestors"` // 继承的全部上级角色
+	Menus     []*EffectiveMenu    `json:"menus"`
+	Upms      []*EffectiveUpms    `json:"upms"`
+}
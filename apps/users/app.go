@@ -1,9 +1,13 @@
-package users
-
-const (
-	AppName         = "portal"
-	AppOrganization = "organization"
-	AppPosition     = "position"
-	AppAccount      = "account"
-	AppRole         = "role"
-)
+package users
+
+const (
+	AppName         = "portal"
+	AppOrganization = "organization"
+	AppPosition     = "position"
+	AppAccount      = "account"
+	AppRole         = "role"
+	AppToken        = "token"
+	AppSession      = "session"
+	AppEvent        = "securityEvent"
+	AppGroup        = "group"
+)
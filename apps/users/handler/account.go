@@ -1,187 +1,473 @@
-package handler
-
-import (
-	"fmt"
-	"github.com/gin-gonic/gin"
-	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
-	"github.com/yanshicheng/ikube-gin-xjob/apps/users/logic"
-	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
-	"github.com/yanshicheng/ikube-gin-xjob/common/response"
-	"github.com/yanshicheng/ikube-gin-xjob/common/types"
-	"github.com/yanshicheng/ikube-gin-xjob/global"
-	"github.com/yanshicheng/ikube-gin-xjob/router"
-	"go.uber.org/zap"
-)
-
-var _ router.GinService = (*AccountHandler)(nil)
-var accountHandler = &AccountHandler{}
-
-type AccountHandler struct {
-	l   *zap.Logger
-	svc *logic.AccountLogic
-}
-
-func (h *AccountHandler) PublicRegistry(r gin.IRouter) {
-	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppAccount))
-	group.POST("/login", h.login)
-	group.POST("/logout", h.login)
-	// 重置密码接口
-	group.POST("/changePassword", h.changePassword)
-
-}
-
-// AuthRegistry 注册认证接口
-func (h *AccountHandler) AuthRegistry(r gin.IRouter) {
-	// 分组路由
-	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppAccount))
-	{
-		group.GET("/", h.list)
-		group.GET("/:id", h.get)
-		group.POST("/", h.create)
-		group.PUT("/:id", h.put)
-		group.DELETE("/:id", h.delete)
-		group.POST("resetPassword", h.resetPassword)
-	}
-
-}
-
-func (h *AccountHandler) changePassword(c *gin.Context) {
-	var req types2.AccountChangePasswordReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
-		response.FailedParam(c, err)
-		return
-	}
-	if err := h.svc.ChangePassword(c, &req); err != nil {
-		response.FailedStr(c, err.Error())
-		return
-	}
-	response.SuccessStr(c, "密码修改成功!")
-}
-func (h *AccountHandler) resetPassword(c *gin.Context) {
-	var req types2.AccountRestPasswordReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
-		response.FailedParam(c, err)
-		return
-	}
-	if err := h.svc.RestPassword(c, &req); err != nil {
-		response.FailedStr(c, err.Error())
-		return
-	}
-	response.SuccessStr(c, "密码重置成功!")
-}
-
-func (h *AccountHandler) login(c *gin.Context) {
-	var req types2.AccountLoginReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
-		response.FailedParam(c, err)
-		return
-	}
-	if token, errCode, err := h.svc.Login(c, &req); err != nil {
-		response.FailedCode(c, errCode, err.Error())
-		return
-	} else {
-		response.SuccessMap(c, token)
-	}
-}
-
-func (h *AccountHandler) get(c *gin.Context) {
-	var id types.SearchId
-	if err := c.ShouldBindUri(&id); err != nil {
-		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
-		response.FailedParam(c, err)
-		return
-	}
-	if account, err := h.svc.Get(c, id); err != nil {
-		response.FailedStr(c, err.Error())
-		return
-	} else {
-		response.SuccessMap(c, account)
-	}
-
-}
-
-func (h *AccountHandler) list(c *gin.Context) {
-	var search types2.AccountQueryReq
-	if err := c.ShouldBindQuery(&search); err != nil {
-		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
-		response.FailedParam(c, err)
-		return
-	}
-	if accounts, err := h.svc.List(c, search); err != nil {
-		response.FailedStr(c, err.Error())
-		return
-	} else {
-		response.SuccessSlice(c, accounts)
-	}
-	response.SuccessSlice(c, nil)
-}
-
-func (h *AccountHandler) create(c *gin.Context) {
-	var position types2.AccountCreateReq
-	if err := c.ShouldBindJSON(&position); err != nil {
-		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
-		response.FailedParam(c, err)
-		return
-	}
-	if account, err := h.svc.Create(c, &position); err != nil {
-		response.FailedStr(c, err.Error())
-		return
-	} else {
-		response.SuccessMap(c, account)
-	}
-}
-
-func (h *AccountHandler) put(c *gin.Context) {
-	var account types2.AccountCreateReq
-	if err := c.ShouldBindJSON(&account); err != nil {
-		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
-		response.FailedParam(c, err)
-		return
-	}
-	var id types.SearchId
-	if err := c.ShouldBindUri(&id); err != nil {
-		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
-		response.FailedParam(c, err)
-		return
-	}
-	h.l.Debug(fmt.Sprintf("修改参数: %+v, 修改id: %d", account, id))
-	if newPosition, err := h.svc.Put(c, id, &account); err != nil {
-		response.FailedStr(c, err.Error())
-		return
-	} else {
-		response.SuccessMap(c, newPosition)
-	}
-
-}
-
-func (h *AccountHandler) delete(c *gin.Context) {
-	var id types.SearchId
-	if err := c.ShouldBindUri(&id); err != nil {
-		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
-		response.FailedParam(c, err)
-		return
-	}
-	h.l.Debug(fmt.Sprintf("删除id: %d", id))
-	if err := h.svc.Delete(c, id); err != nil {
-		response.FailedStr(c, err.Error())
-		return
-	}
-	response.SuccessMap(c, nil)
-}
-
-func (h *AccountHandler) Name() string {
-	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppAccount)
-}
-
-// Config 配置函数，在这里注入依赖，并且初始化实例，供其他函数使用。
-func (h *AccountHandler) Config() {
-	h.l = global.L.Named(apps.AppName).Named(apps.AppAccount).Named("handler")
-	h.svc = router.GetLogic(h.Name()).(*logic.AccountLogic)
-}
-
-func init() {
-	router.RegistryGinRouter(accountHandler)
-}
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/logic"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/response"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+var _ router.GinService = (*AccountHandler)(nil)
+var accountHandler = &AccountHandler{}
+
+type AccountHandler struct {
+	l   *zap.Logger
+	svc *logic.AccountLogic
+}
+
+func (h *AccountHandler) PublicRegistry(r gin.IRouter) {
+	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppAccount))
+	group.POST("/login", h.login)
+	group.POST("/refresh", h.refresh)
+	// 重置密码接口
+	group.POST("/changePassword", h.changePassword)
+	// 找回密码接口
+	group.POST("/password/forgot", h.forgotPassword)
+	group.POST("/password/reset", h.resetPasswordByToken)
+
+}
+
+// AuthRegistry 注册认证接口
+func (h *AccountHandler) AuthRegistry(r gin.IRouter) {
+	// 分组路由
+	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppAccount))
+	{
+		group.GET("/", h.list)
+		group.GET("/export", h.export)
+		group.POST("/import", h.importAccounts)
+		group.GET("/:id", h.get)
+		group.POST("/", h.create)
+		group.PUT("/:id", h.put)
+		group.DELETE("/:id", h.delete)
+		// 汇报关系
+		group.GET("/:id/managers", h.managers)
+		group.GET("/:id/reports", h.reports)
+		group.PUT("/:id/handover", h.handover)
+		// 调动和任职历史
+		group.POST("/:id/transfers", h.transfer)
+		group.GET("/:id/transfers", h.transfers)
+		group.DELETE("/:id/transfers/:transferId", h.cancelTransfer)
+		group.GET("/:id/assignments", h.assignments)
+		// 离职
+		group.POST("/:id/offboard", h.offboard)
+		group.GET("/:id/offboarding", h.offboarding)
+		group.POST("resetPassword", h.resetPassword)
+		group.POST("/logout", h.logout)
+		group.POST("/icon", h.changeIcon)
+		// 当前登录账号的自助接口
+		me := group.Group("/me")
+		me.GET("", h.me)
+		me.PUT("", h.updateMe)
+		me.POST("/icon", h.changeIcon)
+		me.POST("/email", h.requestEmailChange)
+		me.POST("/email/verify", h.verifyEmailChange)
+		me.POST("/password", h.changeMyPassword)
+		me.GET("/roles", h.myRoles)
+		me.GET("/menus", h.myMenus)
+		me.GET("/events", h.myEvents)
+		me.GET("/managers", h.myManagers)
+		me.GET("/reports", h.myReports)
+	}
+
+}
+
+func (h *AccountHandler) managers(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Managers(c, id)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *AccountHandler) reports(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var query types2.AccountReportQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Reports(c, id, query)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *AccountHandler) handover(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.AccountHandoverReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.Handover(c, id, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "转交成功")
+}
+
+func (h *AccountHandler) transfer(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.AccountTransferReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	transfer, err := h.svc.Transfer(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, transfer)
+}
+
+func (h *AccountHandler) transfers(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var query types2.AccountTransferQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Transfers(c, id, query)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *AccountHandler) cancelTransfer(c *gin.Context) {
+	var req types2.AccountTransferCancelReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.CancelTransfer(c, req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "取消成功")
+}
+
+func (h *AccountHandler) assignments(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Assignments(c, id)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *AccountHandler) offboard(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.AccountOffboardReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	offboarding, err := h.svc.Offboard(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, offboarding)
+}
+
+func (h *AccountHandler) offboarding(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	offboarding, err := h.svc.Offboarding(c, id)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, offboarding)
+}
+
+func (h *AccountHandler) changePassword(c *gin.Context) {
+	var req types2.AccountChangePasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.ChangePassword(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "密码修改成功!")
+}
+func (h *AccountHandler) forgotPassword(c *gin.Context) {
+	var req types2.AccountForgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.ForgotPassword(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "如果账号存在，重置密码邮件已发送，请查收!")
+}
+
+func (h *AccountHandler) resetPasswordByToken(c *gin.Context) {
+	var req types2.AccountResetPasswordByTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.ResetPasswordByToken(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "密码重置成功，请重新登录!")
+}
+
+func (h *AccountHandler) resetPassword(c *gin.Context) {
+	var req types2.AccountRestPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.RestPassword(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "密码重置成功!")
+}
+
+func (h *AccountHandler) changeIcon(c *gin.Context) {
+	if resp, err := h.svc.ChangeIcon(c); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, resp)
+	}
+}
+
+func (h *AccountHandler) login(c *gin.Context) {
+	var req types2.AccountLoginReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if token, errCode, err := h.svc.Login(c, &req); err != nil {
+		response.FailedCode(c, errCode, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, token)
+	}
+}
+
+func (h *AccountHandler) refresh(c *gin.Context) {
+	var req types2.AccountRefreshReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if token, errCode, err := h.svc.Refresh(c, &req); err != nil {
+		response.FailedCode(c, errCode, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, token)
+	}
+}
+
+func (h *AccountHandler) logout(c *gin.Context) {
+	if err := h.svc.Logout(c); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "退出登录成功!")
+}
+
+func (h *AccountHandler) get(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if account, err := h.svc.Get(c, id); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, account)
+	}
+
+}
+
+func (h *AccountHandler) list(c *gin.Context) {
+	var search types2.AccountQueryReq
+	if err := c.ShouldBindQuery(&search); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if accounts, err := h.svc.List(c, search); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessSlice(c, accounts)
+	}
+}
+
+func (h *AccountHandler) importAccounts(c *gin.Context) {
+	var req types2.AccountImportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if resp, err := h.svc.Import(c, req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, resp)
+	}
+}
+
+func (h *AccountHandler) export(c *gin.Context) {
+	var req types2.AccountExportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	resp, err := h.svc.Export(c, req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", resp.FileName))
+	c.Data(http.StatusOK, resp.ContentType, resp.Data)
+}
+
+func (h *AccountHandler) create(c *gin.Context) {
+	var position types2.AccountCreateReq
+	if err := c.ShouldBindJSON(&position); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if account, err := h.svc.Create(c, &position); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, account)
+	}
+}
+
+func (h *AccountHandler) put(c *gin.Context) {
+	var account types2.AccountCreateReq
+	if err := c.ShouldBindJSON(&account); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("修改参数: %+v, 修改id: %d", account, id))
+	if newPosition, err := h.svc.Put(c, id, &account); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, newPosition)
+	}
+
+}
+
+func (h *AccountHandler) delete(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("删除id: %d", id))
+	if err := h.svc.Delete(c, id); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *AccountHandler) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppAccount)
+}
+
+// Config 配置函数，在这里注入依赖，并且初始化实例，供其他函数使用。
+func (h *AccountHandler) Config() {
+	h.l = global.L.Named(apps.AppName).Named(apps.AppAccount).Named("handler")
+	h.svc = router.GetLogic(h.Name()).(*logic.AccountLogic)
+}
+
+func init() {
+	router.RegistryGinRouter(accountHandler)
+}
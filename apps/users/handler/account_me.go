@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/response"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+)
+
+func (h *AccountHandler) me(c *gin.Context) {
+	if account, err := h.svc.Me(c); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, account)
+	}
+}
+
+func (h *AccountHandler) updateMe(c *gin.Context) {
+	var req types2.AccountProfileUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if account, err := h.svc.UpdateMe(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, account)
+	}
+}
+
+func (h *AccountHandler) requestEmailChange(c *gin.Context) {
+	var req types2.AccountEmailChangeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.RequestEmailChange(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "验证码已发送到新邮箱!")
+}
+
+func (h *AccountHandler) verifyEmailChange(c *gin.Context) {
+	var req types2.AccountEmailVerifyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.VerifyEmailChange(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "邮箱修改成功!")
+}
+
+func (h *AccountHandler) changeMyPassword(c *gin.Context) {
+	var req types2.AccountMePasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.ChangeMyPassword(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessStr(c, "密码修改成功!")
+}
+
+func (h *AccountHandler) myRoles(c *gin.Context) {
+	if roles, err := h.svc.MyRoles(c); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessSlice(c, roles)
+	}
+}
+
+func (h *AccountHandler) myMenus(c *gin.Context) {
+	if menus, err := h.svc.MyMenus(c); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, menus)
+	}
+}
+
+func (h *AccountHandler) myEvents(c *gin.Context) {
+	var query types2.SecurityEventQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if list, err := h.svc.MyEvents(c, query); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessSlice(c, list)
+	}
+}
+
+func (h *AccountHandler) myManagers(c *gin.Context) {
+	list, err := h.svc.MyManagers(c)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *AccountHandler) myReports(c *gin.Context) {
+	var query types2.AccountReportQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.MyReports(c, query)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
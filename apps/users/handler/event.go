@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/logic"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/middleware"
+	"github.com/yanshicheng/ikube-gin-xjob/common/response"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"go.uber.org/zap"
+)
+
+var _ router.GinService = (*SecurityEventHandler)(nil)
+var securityEventHandler = &SecurityEventHandler{}
+
+type SecurityEventHandler struct {
+	l   *zap.Logger
+	svc *logic.SecurityEventLogic
+}
+
+func (h *SecurityEventHandler) PublicRegistry(gin.IRouter) {
+
+}
+
+// AuthRegistry 注册认证接口
+func (h *SecurityEventHandler) AuthRegistry(r gin.IRouter) {
+	// 分组路由
+	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppEvent))
+	{
+		// 管理员查询数据权限范围内账号的安全事件，查询本人的安全事件使用个人中心
+		group.GET("/", middleware.RequireAdmin(), h.list)
+	}
+}
+
+func (h *SecurityEventHandler) list(c *gin.Context) {
+	var query types2.SecurityEventQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("查询参数: %+v", query))
+	if list, err := h.svc.List(c, query); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessSlice(c, list)
+	}
+}
+
+func (h *SecurityEventHandler) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppEvent)
+}
+
+// Config 配置函数，在这里注入依赖，并且初始化实例，供其他函数使用。
+func (h *SecurityEventHandler) Config() {
+	h.l = global.L.Named(apps.AppName).Named(apps.AppEvent).Named("handler")
+	h.svc = router.GetLogic(h.Name()).(*logic.SecurityEventLogic)
+}
+
+func init() {
+	router.RegistryGinRouter(securityEventHandler)
+}
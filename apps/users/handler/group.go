@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/logic"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/middleware"
+	"github.com/yanshicheng/ikube-gin-xjob/common/response"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"go.uber.org/zap"
+)
+
+var _ router.GinService = (*GroupHandler)(nil)
+var groupHandler = &GroupHandler{}
+
+type GroupHandler struct {
+	l   *zap.Logger
+	svc *logic.GroupLogic
+}
+
+func (h *GroupHandler) PublicRegistry(gin.IRouter) {
+
+}
+
+// AuthRegistry 注册认证接口
+func (h *GroupHandler) AuthRegistry(r gin.IRouter) {
+	// 分组路由
+	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppGroup))
+	{
+		group.GET("/", h.list)
+		group.GET("/:id/accounts", h.accounts)
+		group.GET("/:id/groups", h.children)
+		group.GET("/:id/roles", h.roles)
+	}
+	// 修改用户组会改变成员的角色，只允许管理员操作
+	admin := group.Group("", middleware.RequireAdmin())
+	{
+		admin.POST("/", h.create)
+		admin.PUT("/:id", h.put)
+		admin.DELETE("/:id", h.delete)
+		admin.POST("/:id/accounts", h.addAccounts)
+		admin.DELETE("/:id/accounts", h.removeAccounts)
+		admin.PUT("/:id/groups", h.setChildren)
+		admin.PUT("/:id/roles", h.setRoles)
+	}
+}
+
+func (h *GroupHandler) list(c *gin.Context) {
+	var query types2.GroupQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.List(c, query)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *GroupHandler) create(c *gin.Context) {
+	var req model.Group
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.Create(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, req)
+}
+
+func (h *GroupHandler) put(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req model.Group
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	group, err := h.svc.Put(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, group)
+}
+
+func (h *GroupHandler) delete(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.Delete(c, id); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *GroupHandler) accounts(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var query types2.GroupAccountQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Accounts(c, id, query)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *GroupHandler) addAccounts(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.GroupAccountReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.AddAccounts(c, id, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *GroupHandler) removeAccounts(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.GroupAccountReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if err := h.svc.RemoveAccounts(c, id, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *GroupHandler) children(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Children(c, id)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *GroupHandler) setChildren(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.GroupChildReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.SetChildren(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *GroupHandler) roles(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Roles(c, id)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *GroupHandler) setRoles(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.GroupRoleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.SetRoles(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *GroupHandler) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppGroup)
+}
+
+// Config 配置函数，在这里注入依赖，并且初始化实例，供其他函数使用。
+func (h *GroupHandler) Config() {
+	h.l = global.L.Named(apps.AppName).Named(apps.AppGroup).Named("handler")
+	h.svc = router.GetLogic(h.Name()).(*logic.GroupLogic)
+}
+
+func init() {
+	router.RegistryGinRouter(groupHandler)
+}
@@ -12,8 +12,13 @@ import (
 	"github.com/yanshicheng/ikube-gin-xjob/global"
 	"github.com/yanshicheng/ikube-gin-xjob/router"
 	"go.uber.org/zap"
+	"net/http"
+	"strconv"
 )
 
+// organizationVersionHeader 机构树版本号响应头
+const organizationVersionHeader = "X-Organization-Version"
+
 var _ router.GinService = (*OrganizationHandler)(nil)
 var organizationHandler = &OrganizationHandler{}
 
@@ -32,10 +37,22 @@ func (h *OrganizationHandler) AuthRegistry(r gin.IRouter) {
 	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppOrganization))
 	{
 		group.GET("/", h.list)
+		group.GET("/version", h.version)
+		group.GET("/types", h.types)
+		group.GET("/headcount", h.headcount)
+		group.GET("/export", h.export)
 		group.GET("/:id", h.get)
 		group.POST("/", h.create)
 		group.PUT("/:id", h.put)
 		group.DELETE("/:id", h.delete)
+		group.GET("/:id/stats", h.stats)
+		group.PUT("/:id/move", h.move)
+		group.GET("/:id/moves", h.moves)
+		group.POST("/:id/merge", h.merge)
+		group.POST("/:id/dissolve", h.dissolve)
+		group.GET("/:id/managers", h.managers)
+		group.PUT("/:id/managers", h.setManagers)
+		group.GET("/:id/members", h.members)
 	}
 }
 func (h *OrganizationHandler) get(c *gin.Context) {
@@ -63,14 +80,30 @@ func (h *OrganizationHandler) list(c *gin.Context) {
 	}
 	h.l.Debug(fmt.Sprintf("查询参数: %v", search))
 
-	if s, err := h.svc.List(c, search); err != nil {
+	if s, version, err := h.svc.List(c, search); err != nil {
 		h.l.Error(fmt.Sprintf("数据查询失败: %s", err))
 		response.FailedStr(c, err.Error())
 	} else {
+		// 客户端记录版本号，通过版本接口判断机构树是否变化
+		c.Header(organizationVersionHeader, strconv.FormatInt(version, 10))
 		response.SuccessSlice(c, s)
 	}
 }
 
+func (h *OrganizationHandler) version(c *gin.Context) {
+	resp, err := h.svc.Version(c)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	c.Header(organizationVersionHeader, strconv.FormatInt(resp.Version, 10))
+	response.SuccessMap(c, resp)
+}
+
+func (h *OrganizationHandler) types(c *gin.Context) {
+	response.SuccessSlice(c, h.svc.Types(c))
+}
+
 func (h *OrganizationHandler) create(c *gin.Context) {
 	var org model.Organization
 	if err := c.ShouldBindJSON(&org); err != nil {
@@ -126,6 +159,198 @@ func (h *OrganizationHandler) delete(c *gin.Context) {
 	response.SuccessMap(c, nil)
 }
 
+func (h *OrganizationHandler) move(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.OrganizationMoveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	org, err := h.svc.Move(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, org)
+}
+
+func (h *OrganizationHandler) merge(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.OrganizationRestructureReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	resp, err := h.svc.Merge(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, resp)
+}
+
+func (h *OrganizationHandler) dissolve(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.OrganizationRestructureReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	resp, err := h.svc.Dissolve(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, resp)
+}
+
+func (h *OrganizationHandler) stats(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	stats, err := h.svc.Stats(c, id)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, stats)
+}
+
+func (h *OrganizationHandler) moves(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	var query types2.OrganizationMoveQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Moves(c, id, query)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *OrganizationHandler) managers(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Managers(c, id)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *OrganizationHandler) setManagers(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.OrganizationManagerReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.SetManagers(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *OrganizationHandler) members(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	var query types2.OrganizationMembersQueryReq
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	list, err := h.svc.Members(c, id, query)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessSlice(c, list)
+}
+
+func (h *OrganizationHandler) headcount(c *gin.Context) {
+	var req types2.OrganizationHeadcountReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	stats, err := h.svc.Headcount(c, req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, stats)
+}
+
+func (h *OrganizationHandler) export(c *gin.Context) {
+	var req types2.OrganizationExportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.l.Error(fmt.Sprintf("数据绑定失败: %s", err))
+		response.FailedParam(c, err)
+		return
+	}
+	resp, err := h.svc.Export(c, req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	// 边生成边写入响应，开始写入后出错只能中断连接
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", resp.FileName))
+	c.Header("Content-Type", resp.ContentType)
+	c.Status(http.StatusOK)
+	if err := resp.Write(c.Writer); err != nil {
+		h.l.Error(fmt.Sprintf("导出机构失败: %s", err))
+	}
+}
+
 func (h *OrganizationHandler) Name() string {
 	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppOrganization)
 }
@@ -35,6 +35,7 @@ func (h *PositionHandler) AuthRegistry(r gin.IRouter) {
 		group.POST("/", h.create)
 		group.PUT("/:id", h.put)
 		group.DELETE("/:id", h.delete)
+		group.POST("/:id/reassign", h.reassign)
 	}
 }
 
@@ -106,6 +107,27 @@ func (h *PositionHandler) delete(c *gin.Context) {
 	response.SuccessMap(c, nil)
 }
 
+func (h *PositionHandler) reassign(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	var req types2.PositionReassignReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	resp, err := h.svc.Reassign(c, id, &req)
+	if err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, resp)
+}
+
 func (h *PositionHandler) Name() string {
 	return fmt.Sprintf("%s.%s", users.AppName, users.AppPosition)
 }
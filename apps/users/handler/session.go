@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/logic"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/middleware"
+	"github.com/yanshicheng/ikube-gin-xjob/common/response"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"go.uber.org/zap"
+)
+
+var _ router.GinService = (*SessionHandler)(nil)
+var sessionHandler = &SessionHandler{}
+
+type SessionHandler struct {
+	l   *zap.Logger
+	svc *logic.SessionLogic
+}
+
+func (h *SessionHandler) PublicRegistry(gin.IRouter) {
+
+}
+
+// AuthRegistry 注册认证接口
+func (h *SessionHandler) AuthRegistry(r gin.IRouter) {
+	// 分组路由
+	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppSession))
+	{
+		// 当前账号的会话
+		group.GET("/", h.list)
+		group.DELETE("/:id", h.revoke)
+		// 管理员查看、踢出数据权限范围内账号的会话
+		group.GET("/account/:accountId", middleware.RequireAdmin(), h.accountList)
+		group.DELETE("/account/:accountId/:id", middleware.RequireAdmin(), h.accountRevoke)
+	}
+}
+
+func (h *SessionHandler) list(c *gin.Context) {
+	if list, err := h.svc.List(c); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessSlice(c, list)
+	}
+}
+
+func (h *SessionHandler) revoke(c *gin.Context) {
+	var req types2.SessionSearchReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("吊销会话: %s", req.Id))
+	if err := h.svc.Revoke(c, req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *SessionHandler) accountList(c *gin.Context) {
+	var req types2.SessionAccountReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if list, err := h.svc.AccountList(c, req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessSlice(c, list)
+	}
+}
+
+func (h *SessionHandler) accountRevoke(c *gin.Context) {
+	var req types2.SessionAccountRevokeReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("管理员吊销会话: %+v", req))
+	if err := h.svc.AccountRevoke(c, req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *SessionHandler) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppSession)
+}
+
+// Config 配置函数，在这里注入依赖，并且初始化实例，供其他函数使用。
+func (h *SessionHandler) Config() {
+	h.l = global.L.Named(apps.AppName).Named(apps.AppSession).Named("handler")
+	h.svc = router.GetLogic(h.Name()).(*logic.SessionLogic)
+}
+
+func init() {
+	router.RegistryGinRouter(sessionHandler)
+}
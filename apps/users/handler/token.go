@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/logic"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/middleware"
+	"github.com/yanshicheng/ikube-gin-xjob/common/response"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"go.uber.org/zap"
+)
+
+var _ router.GinService = (*ApiTokenHandler)(nil)
+var apiTokenHandler = &ApiTokenHandler{}
+
+type ApiTokenHandler struct {
+	l   *zap.Logger
+	svc *logic.ApiTokenLogic
+}
+
+func (h *ApiTokenHandler) PublicRegistry(gin.IRouter) {
+
+}
+
+// AuthRegistry 注册认证接口
+func (h *ApiTokenHandler) AuthRegistry(r gin.IRouter) {
+	// 分组路由
+	group := r.Group(fmt.Sprintf("%s/%s", apps.AppName, apps.AppToken))
+	{
+		// 当前账号自助管理
+		group.GET("/", h.list)
+		group.POST("/", h.create)
+		group.DELETE("/:id", h.delete)
+		// 管理员管理数据权限范围内账号的令牌
+		group.GET("/account/:accountId", middleware.RequireAdmin(), h.accountList)
+		group.DELETE("/admin/:id", middleware.RequireAdmin(), h.accountDelete)
+	}
+}
+
+func (h *ApiTokenHandler) list(c *gin.Context) {
+	if list, err := h.svc.List(c); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessSlice(c, list)
+	}
+}
+
+func (h *ApiTokenHandler) create(c *gin.Context) {
+	var req types2.ApiTokenCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if token, err := h.svc.Create(c, &req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessMap(c, token)
+	}
+}
+
+func (h *ApiTokenHandler) delete(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("吊销令牌id: %d", id))
+	if err := h.svc.Delete(c, id); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *ApiTokenHandler) accountList(c *gin.Context) {
+	var req types2.ApiTokenAccountReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	if list, err := h.svc.AccountList(c, req); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	} else {
+		response.SuccessSlice(c, list)
+	}
+}
+
+func (h *ApiTokenHandler) accountDelete(c *gin.Context) {
+	var id types.SearchId
+	if err := c.ShouldBindUri(&id); err != nil {
+		global.LSys.Error(fmt.Sprintf("参数绑定失败: %s", err.Error()))
+		response.FailedParam(c, err)
+		return
+	}
+	h.l.Debug(fmt.Sprintf("管理员吊销令牌id: %d", id))
+	if err := h.svc.AccountDelete(c, id); err != nil {
+		response.FailedStr(c, err.Error())
+		return
+	}
+	response.SuccessMap(c, nil)
+}
+
+func (h *ApiTokenHandler) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppToken)
+}
+
+// Config 配置函数，在这里注入依赖，并且初始化实例，供其他函数使用。
+func (h *ApiTokenHandler) Config() {
+	h.l = global.L.Named(apps.AppName).Named(apps.AppToken).Named("handler")
+	h.svc = router.GetLogic(h.Name()).(*logic.ApiTokenLogic)
+}
+
+func init() {
+	router.RegistryGinRouter(apiTokenHandler)
+}
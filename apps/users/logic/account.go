@@ -1,309 +1,646 @@
-package logic
-
-import (
-	"fmt"
-	"github.com/gin-gonic/gin"
-	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
-	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
-	"github.com/yanshicheng/ikube-gin-xjob/apps/users/service"
-	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
-	"github.com/yanshicheng/ikube-gin-xjob/common/errorx"
-	"github.com/yanshicheng/ikube-gin-xjob/common/sql"
-	"github.com/yanshicheng/ikube-gin-xjob/common/types"
-	"github.com/yanshicheng/ikube-gin-xjob/global"
-	"github.com/yanshicheng/ikube-gin-xjob/router"
-	"github.com/yanshicheng/ikube-gin-xjob/utils"
-	"go.uber.org/zap"
-	"gorm.io/gorm"
-	"time"
-)
-
-var _ service.AccountService = (*AccountLogic)(nil)
-
-var accountLogic = &AccountLogic{}
-
-type AccountLogic struct {
-	l  *zap.Logger
-	db *gorm.DB
-}
-
-func (l *AccountLogic) Get(c *gin.Context, search types.SearchId) (*model.Account, error) {
-	var account *model.Account
-	// 查询账号详情
-	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", search.Id).First(&account).Error; err != nil {
-		l.l.Error(fmt.Sprintf("查询账号详情失败: %s", err.Error()))
-		return nil, fmt.Errorf("查询账号详情失败")
-	}
-	var position *model.Position
-	if err := l.db.WithContext(c).Model(&model.Position{}).Where("id = ?", account.PositionId).First(&position).Error; err != nil {
-		l.l.Error(fmt.Sprintf("查询职位详情失败: %s", err.Error()))
-		return nil, fmt.Errorf("查询职位详情失败")
-	}
-	account.PositionName = position.Name
-	// 查找部门信息
-	var organization *model.Organization
-	if err := l.db.WithContext(c).Model(&model.Organization{}).Where("id = ?", account.OrganizationId).First(&organization).Error; err != nil {
-		l.l.Error(fmt.Sprintf("查询部门详情失败: %s", err.Error()))
-		return nil, fmt.Errorf("查询部门详情失败")
-	}
-	account.OrganizationName = organization.Name
-	OrganizationTreeName, err := organization.GetFullHierarchy(l.db)
-	if err != nil {
-		l.l.Error(fmt.Sprintf("查询部门详情失败: %s", err.Error()))
-		return nil, fmt.Errorf("查询部门详情失败")
-	}
-	account.OrganizationTreeName = OrganizationTreeName
-	// 补充职位信息，
-	return account, nil
-}
-func (l *AccountLogic) List(c *gin.Context, query types2.AccountQueryReq) (*types.QueryResponse, error) {
-	var list []*model.Account
-	// 设置排序
-	db := l.db.WithContext(c).Model(&model.Account{})
-	db = db.Order(fmt.Sprintf("%s %s", "ID", query.Sort))
-	// 模糊查询
-	if query.Account != "" {
-		db = db.Where("title like ?", "%d"+query.Account+"%")
-	}
-	if query.UserName != "" {
-		db = db.Where("title like ?", "%d"+query.UserName+"%")
-	}
-	if query.Mobile != "" {
-		db = db.Where("title like ?", "%d"+query.Mobile+"%")
-	}
-	if query.WorkNumber != "" {
-		db = db.Where("title like ?", "%d"+query.WorkNumber+"%")
-	}
-	if query.Email != "" {
-		db = db.Where("title like ?", "%d"+query.Email+"%")
-	}
-	if !query.IsDisabled {
-		db = db.Where("is_disabled = ?", query.IsDisabled)
-	}
-	if !query.IsFrozen {
-		db = db.Where("is_frozen = ?", query.IsFrozen)
-	}
-	if !query.IsLeave {
-		db = db.Where("is_leave = ?", query.IsLeave)
-	}
-	if query.PositionId != nil {
-		db = db.Where("position_id = ?", query.PositionId)
-	}
-	if query.OrganizationId != nil {
-		db = db.Where("organization_id = ?", query.OrganizationId)
-	}
-	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
-	if err != nil {
-		l.l.Error(fmt.Sprintf("查询数据失败: %s", err.Error()))
-		return nil, fmt.Errorf("查询数据失败")
-	}
-	return queryRes, nil
-}
-
-func (l *AccountLogic) Create(c *gin.Context, data *types2.AccountCreateReq) (*model.Account, error) {
-	// 创建账号，先查询机构是否存在
-	account := model.Account{
-		Account:        data.Account,
-		UserName:       data.UserName,
-		Email:          data.Email,
-		HireDate:       data.HireDate,
-		WorkNumber:     data.WorkNumber,
-		Mobile:         data.Mobile,
-		OrganizationId: data.OrganizationId,
-		PositionId:     data.PositionId,
-	}
-	// 判断组织是否存在
-	if err := l.db.WithContext(c).Model(&model.Organization{}).Where("id = ?", data.OrganizationId).First(&model.Organization{}).Error; err != nil {
-		l.l.Error(fmt.Sprintf("查询部门详情失败: %s", err.Error()))
-		return nil, fmt.Errorf("查询部门详情失败")
-	}
-	// 判断职位是否存在
-	if err := l.db.WithContext(c).Model(&model.Position{}).Where("id = ?", data.PositionId).First(&model.Position{}).Error; err != nil {
-		l.l.Error(fmt.Sprintf("查询职位详情失败: %s", err.Error()))
-		return nil, fmt.Errorf("查询职位详情失败")
-	}
-	// 创建账号， 进行密码加密
-	err := account.SetPassword(utils.GeneratePassword())
-	if err != nil {
-		return nil, err
-	}
-	// 设置默认头像
-	account.Icon = utils.GenerateIcon()
-	// 设置必须重置密码
-	account.IsChangePassword = true
-	// 创建账号
-	if err := l.db.WithContext(c).Create(&account).Error; err != nil {
-		l.l.Error(fmt.Sprintf("创建账号失败: %s", err.Error()))
-		return nil, fmt.Errorf("创建账号失败")
-	}
-	return &account, nil
-}
-func (l *AccountLogic) Put(c *gin.Context, search types.SearchId, new *types2.AccountCreateReq) (*model.Account, error) {
-	// 只允许修改名称
-	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", search.Id).Updates(new).Error; err != nil {
-		l.l.Error(fmt.Sprintf("更新账号失败: %s", err.Error()))
-		return nil, fmt.Errorf("更新账号失败: %d", search.Id)
-	}
-
-	// 重新获取更新后的账号信息
-	var updatedPosition model.Account
-	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", search.Id).First(&updatedPosition).Error; err != nil {
-		l.l.Error(fmt.Sprintf("获取更新后的账号信息失败: %s", err.Error()))
-		return nil, fmt.Errorf("获取更新后的账号信息失败")
-	}
-	return &updatedPosition, nil
-}
-
-func (l *AccountLogic) Delete(c *gin.Context, id types.SearchId) error {
-	// 执行删除操作，并获取结果
-	result := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", id.Id).Delete(&model.Account{})
-
-	// 检查是否有错误发生
-	if err := result.Error; err != nil {
-		l.l.Error(fmt.Sprintf("删除账号失败: %s", err.Error()))
-		return fmt.Errorf("删除账号失败: %s", err.Error())
-	}
-
-	// 检查是否实际上删除了记录
-	if result.RowsAffected == 0 {
-		l.l.Error("删除账号失败: 未找到指定的账号")
-		return fmt.Errorf("删除账号失败: 未找到指定的账号")
-	}
-	return nil
-}
-
-func (l *AccountLogic) ChangePassword(c *gin.Context, req *types2.AccountChangePasswordReq) error {
-	var account model.Account
-	if err := l.db.WithContext(c).Model(&model.Account{}).Where("account = ?", req.Account).First(&account).Error; err != nil {
-		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
-		return fmt.Errorf("查询账号失败")
-	}
-	// 对原始密码解密
-	oldPassword, err := utils.DecodeBase64Password(req.Password)
-	if err != nil {
-		l.l.Error(fmt.Sprintf("解密密码失败: %s", err.Error()))
-		return fmt.Errorf("解密密码失败")
-	}
-	// 验证密码
-	if !account.CheckPassword(oldPassword) {
-		l.l.Error(fmt.Sprintf("用户:%s  密码错误: %s", req.Account, oldPassword))
-		return fmt.Errorf("用户名或密码错误")
-	}
-	// 判断用户是否离职 或者 是否为禁用，
-	if account.IsLeave || account.IsDisabled {
-		l.l.Error(fmt.Sprintf("用户:%s  已被禁用", req.Account))
-		return fmt.Errorf("用户已被禁用，请联系管理员")
-	}
-	// 解析新密码
-	newPassword, err := utils.DecodeBase64Password(req.NewPassword)
-	if err != nil {
-		l.l.Error(fmt.Sprintf("解密密码失败: %s", err.Error()))
-		return fmt.Errorf("解密密码失败")
-	}
-	// 验证密码复杂度要求
-	if !utils.CheckPasswordComplexity(newPassword) {
-		l.l.Error(fmt.Sprintf("密码复杂度要求:密码: %s", newPassword))
-		return fmt.Errorf("不满足密码复杂度要求，要求: 至少 12 位包含数字、字母、特殊字符")
-	}
-	// 判断新密码是否与旧密码相同
-	if oldPassword == newPassword {
-		l.l.Error(fmt.Sprintf("新密码不能与旧密码相同: %s", newPassword))
-		return fmt.Errorf("新密码不能与旧密码相同")
-	}
-	// 验证密码
-	if !account.CheckPassword(newPassword) {
-		l.l.Error(fmt.Sprintf("用户名或者密码错误: %s", newPassword))
-		return fmt.Errorf("用户名或者密码错误")
-	}
-	if err := account.SetPassword(newPassword); err != nil {
-		l.l.Error(fmt.Sprintf("设置密码失败: %s", err.Error()))
-		return fmt.Errorf("设置密码失败")
-	}
-	// 设置密码重置标识
-	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", account.ID).Update("is_reset_password", 0).Error; err != nil {
-		l.l.Error(fmt.Sprintf("设置密码重置标识失败: %s", err.Error()))
-		return fmt.Errorf("设置密码重置标识失败")
-	}
-	// 设置新密码
-	return nil
-
-}
-
-func (l *AccountLogic) RestPassword(c *gin.Context, req *types2.AccountRestPasswordReq) error {
-	var account model.Account
-	if err := l.db.WithContext(c).Model(&model.Account{}).Where("account = ?", req.Account).First(&account).Error; err != nil {
-		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
-		return fmt.Errorf("查询账号失败")
-	}
-	// 创建账号， 进行密码加密
-	err := account.SetPassword(utils.GeneratePassword())
-	// 设置必须修改密码
-	account.IsChangePassword = true
-	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", account.ID).Updates(account).Error; err != nil {
-		l.l.Error(fmt.Sprintf("更新账号失败: %s", err.Error()))
-		return fmt.Errorf("更新账号失败")
-	}
-	if err != nil {
-		return err
-	}
-	return nil
-}
-func (l *AccountLogic) Login(c *gin.Context, req *types2.AccountLoginReq) (*utils.JWTResponse, errorx.ErrorCode, error) {
-	var account model.Account
-	if err := l.db.WithContext(c).Model(&model.Account{}).Where("account = ?", req.Account).First(&account).Error; err != nil {
-		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
-		return nil, errorx.ErrGeneric, fmt.Errorf("用户名或密码错误")
-	}
-	// 密码解密
-	password, err := utils.DecodeBase64Password(req.Password)
-	if err != nil {
-		l.l.Error(fmt.Sprintf("解密密码失败: %s", err.Error()))
-		return nil, errorx.ErrGeneric, fmt.Errorf("解密密码失败")
-	}
-	if !account.CheckPassword(password) {
-		l.l.Error(fmt.Sprintf("用户:%s  密码错误: %s", req.Account, password))
-		return nil, errorx.ErrGeneric, fmt.Errorf("用户名或密码错误")
-	}
-	if account.IsDisabled {
-		l.l.Error(fmt.Sprintf("用户:%s  已被禁用", req.Account))
-		return nil, errorx.ErrGeneric, fmt.Errorf("用户已被禁用，请联系管理员")
-	}
-	if account.IsLeave {
-		l.l.Error(fmt.Sprintf("用户:%s  已被离职", req.Account))
-		return nil, errorx.ErrGeneric, fmt.Errorf("用户已被离职，请联系管理员")
-	}
-	if account.IsChangePassword {
-		l.l.Error(fmt.Sprintf("用户:%s  需要重置密码", req.Account))
-		return nil, errorx.ErrNeedResetPassword, fmt.Errorf("用户需要重置密码，请联系管理员")
-	}
-	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", account.ID).Update("last_login_time", time.Now()).Error; err != nil {
-		l.l.Error(fmt.Sprintf("更新账号登录时间失败: %s", err.Error()))
-		return nil, errorx.ErrGeneric, fmt.Errorf("更新账号登录时间失败")
-	}
-
-	// var
-	var applicationRole utils.ApplicationRole
-	fmt.Println(applicationRole)
-	return nil, errorx.ErrGeneric, nil
-}
-func (l *AccountLogic) Logout(*gin.Context) error {
-	return nil
-}
-func (l *AccountLogic) ChangeIcon(*gin.Context) (types2.AccountIconResp, error) {
-	return types2.AccountIconResp{}, nil
-}
-
-// Config 只需要保证 全局对象Config和全局Logger已经加载完成
-func (l *AccountLogic) Config() {
-	l.l = global.L.Named(apps.AppName).Named(apps.AppAccount).Named("logic")
-	l.db = global.DB.GetDb()
-}
-
-func (l *AccountLogic) Name() string {
-	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppAccount)
-}
-
-func init() {
-	// 注册
-	router.RegistryLogic(accountLogic)
-}
+package logic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/service"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/errorx"
+	"github.com/yanshicheng/ikube-gin-xjob/common/sql"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/version"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var _ service.AccountService = (*AccountLogic)(nil)
+
+var accountLogic = &AccountLogic{}
+
+type AccountLogic struct {
+	l  *zap.Logger
+	db *gorm.DB
+}
+
+const accountTable = "ikubexjob_user_account"
+
+// 允许排序的字段，key 为请求参数，value 为数据库列
+var accountOrderColumns = map[string]string{
+	"id":               accountTable + ".id",
+	"userName":         accountTable + ".user_name",
+	"account":          accountTable + ".account",
+	"workNumber":       accountTable + ".work_number",
+	"hireDate":         accountTable + ".hire_date",
+	"lastLoginTime":    accountTable + ".last_login_time",
+	"createdAt":        accountTable + ".created_at",
+	"organizationName": "o.name",
+	"positionName":     "p.name",
+}
+
+// accountWithNames 关联机构和职位表，一次查询带出机构名称和职位名称
+func accountWithNames(db *gorm.DB) *gorm.DB {
+	return db.Model(&model.Account{}).
+		Select(accountTable + ".*, o.name AS organization_name, p.name AS position_name").
+		Joins("LEFT JOIN ikubexjob_user_organization o ON o.id = " + accountTable + ".organization_id").
+		Joins("LEFT JOIN ikubexjob_user_position p ON p.id = " + accountTable + ".position_id")
+}
+
+func (l *AccountLogic) Get(c *gin.Context, search types.SearchId) (*model.Account, error) {
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	var account *model.Account
+	// 查询账号详情
+	if err := accountWithNames(l.db.WithContext(c)).Scopes(scope.Accounts).Where(accountTable+".id = ?", search.Id).First(&account).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询账号详情失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询账号详情失败")
+	}
+	// 部门层级信息从机构缓存中取
+	orgs, err := loadOrganizationPaths(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询部门详情失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询部门详情失败")
+	}
+	OrganizationTreeName, ok := orgs.paths[account.OrganizationId]
+	if !ok {
+		l.l.Error(fmt.Sprintf("查询部门详情失败: 机构 %d 不存在", account.OrganizationId))
+		return nil, fmt.Errorf("查询部门详情失败")
+	}
+	account.OrganizationTreeName = OrganizationTreeName
+	return account, nil
+}
+func (l *AccountLogic) List(c *gin.Context, query types2.AccountQueryReq) (*types.QueryResponse, error) {
+	var list []*model.Account
+	db, err := l.accountFilter(c, accountWithNames(l.db.WithContext(c)), query)
+	if err != nil {
+		return nil, err
+	}
+	// 设置排序，只允许白名单字段
+	column, ok := accountOrderColumns[query.OrderBy]
+	if !ok {
+		column = accountOrderColumns["id"]
+	}
+	db = db.Order(fmt.Sprintf("%s %s", column, query.Sort))
+	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据失败")
+	}
+	// 填充部门层级信息
+	if accounts, ok := queryRes.Data.([]*model.Account); ok && len(accounts) > 0 {
+		orgs, err := loadOrganizationPaths(c, l.db)
+		if err != nil {
+			l.l.Error(fmt.Sprintf("查询机构失败: %s", err.Error()))
+			return nil, fmt.Errorf("查询机构失败")
+		}
+		for _, account := range accounts {
+			account.OrganizationTreeName = orgs.paths[account.OrganizationId]
+		}
+	}
+	return queryRes, nil
+}
+
+// accountFilter 账号列表和导出共用的过滤条件，包含数据权限
+func (l *AccountLogic) accountFilter(c *gin.Context, db *gorm.DB, query types2.AccountQueryReq) (*gorm.DB, error) {
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	db = db.Scopes(scope.Accounts)
+	// 文本字段按前缀或包含匹配
+	for column, value := range map[string]string{
+		"user_name":   query.UserName,
+		"account":     query.Account,
+		"mobile":      query.Mobile,
+		"email":       query.Email,
+		"work_number": query.WorkNumber,
+	} {
+		if value == "" {
+			continue
+		}
+		pattern := escapeLike(value) + "%"
+		if query.MatchMode != "prefix" {
+			pattern = "%" + pattern
+		}
+		db = db.Where(fmt.Sprintf("%s.%s LIKE ?", accountTable, column), pattern)
+	}
+	// 状态字段为 false 时不过滤
+	if query.IsDisabled {
+		db = db.Where(accountTable+".is_disabled = ?", true)
+	}
+	if query.IsLeave {
+		db = db.Where(accountTable+".is_leave = ?", true)
+	}
+	if query.PositionId != nil {
+		db = db.Where(accountTable+".position_id = ?", *query.PositionId)
+	}
+	if query.OrganizationId != nil {
+		if query.IncludeSubOrganizations {
+			ids, err := organizationDescendantIds(c, l.db, *query.OrganizationId)
+			if err != nil {
+				l.l.Error(fmt.Sprintf("查询下级机构失败: %s", err.Error()))
+				return nil, fmt.Errorf("查询下级机构失败")
+			}
+			db = db.Where(accountTable+".organization_id IN ?", ids)
+		} else {
+			db = db.Where(accountTable+".organization_id = ?", *query.OrganizationId)
+		}
+	}
+	return db, nil
+}
+
+// escapeLike 转义 LIKE 中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+func (l *AccountLogic) Create(c *gin.Context, data *types2.AccountCreateReq) (*model.Account, error) {
+	// 创建账号，先查询机构是否存在
+	account := model.Account{
+		Account:        data.Account,
+		UserName:       data.UserName,
+		Email:          data.Email,
+		HireDate:       data.HireDate,
+		WorkNumber:     data.WorkNumber,
+		Mobile:         data.Mobile,
+		OrganizationId: data.OrganizationId,
+		PositionId:     data.PositionId,
+	}
+	// 只能在数据权限范围内的机构创建账号
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	if err := scope.checkOrganization(data.OrganizationId); err != nil {
+		return nil, err
+	}
+	// 机构类型必须允许账号归属，职位必须属于该机构
+	if err := checkAssignmentTarget(l.db.WithContext(c), data.OrganizationId, data.PositionId); err != nil {
+		return nil, err
+	}
+	// 创建账号， 进行密码加密
+	if err := account.SetPassword(utils.GeneratePassword()); err != nil {
+		return nil, err
+	}
+	// 设置默认头像
+	account.Icon = utils.GenerateIcon()
+	// 设置必须重置密码
+	account.IsChangePassword = true
+	// 创建账号，职位设置了编制时在同一事务内校验在职人数
+	err = l.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := checkPositionQuota(tx, data.PositionId, 1); err != nil {
+			return err
+		}
+		return tx.Create(&account).Error
+	})
+	if err != nil {
+		l.l.Error(fmt.Sprintf("创建账号失败: %s", err.Error()))
+		if errors.Is(err, errPositionQuota) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("创建账号失败")
+	}
+	account.Icon = utils.StorageURL(account.Icon)
+	return &account, nil
+}
+func (l *AccountLogic) Put(c *gin.Context, search types.SearchId, new *types2.AccountCreateReq) (*model.Account, error) {
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	// 只能修改数据权限写范围内的账号，本人不能通过该接口修改自己的机构和职位，调整机构时新机构也必须在范围内
+	var old model.Account
+	if err := l.db.WithContext(c).Scopes(scope.WritableAccounts).Where(accountTable+".id = ?", search.Id).First(&old).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+		return nil, fmt.Errorf("账号不存在或超出数据权限范围")
+	}
+	organizationId, positionId := old.OrganizationId, old.PositionId
+	if new.OrganizationId != 0 {
+		organizationId = new.OrganizationId
+	}
+	if new.PositionId != 0 {
+		positionId = new.PositionId
+	}
+	if organizationId != old.OrganizationId || positionId != old.PositionId {
+		if err := scope.checkOrganization(organizationId); err != nil {
+			return nil, err
+		}
+		// 职位必须属于修改后的机构，只修改机构时原职位同样需要属于新机构
+		if err := checkAssignmentTarget(l.db.WithContext(c), organizationId, positionId); err != nil {
+			return nil, err
+		}
+	}
+	// 机构或职位变化时从当天开始新的任职记录，需要指定生效日期时使用调动接口
+	err = l.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if positionId != old.PositionId && !old.IsLeave {
+			if err := checkPositionQuota(tx, positionId, 1); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&model.Account{}).Where("id = ?", search.Id).Updates(new).Error; err != nil {
+			return err
+		}
+		if organizationId == old.OrganizationId && positionId == old.PositionId {
+			return nil
+		}
+		// 尚未入职的账号从入职日期开始
+		startDate := today()
+		if compareDate(old.HireDate, startDate) > 0 {
+			startDate = old.HireDate
+		}
+		return recordAssignment(tx, &model.AccountAssignment{
+			AccountId:      old.ID,
+			OrganizationId: organizationId,
+			PositionId:     positionId,
+			StartDate:      startDate,
+			Reason:         "修改账号",
+		})
+	})
+	if err != nil {
+		l.l.Error(fmt.Sprintf("更新账号失败: %s", err.Error()))
+		if errors.Is(err, errPositionQuota) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("更新账号失败: %d", search.Id)
+	}
+
+	// 重新获取更新后的账号信息
+	var updatedPosition model.Account
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", search.Id).First(&updatedPosition).Error; err != nil {
+		l.l.Error(fmt.Sprintf("获取更新后的账号信息失败: %s", err.Error()))
+		return nil, fmt.Errorf("获取更新后的账号信息失败")
+	}
+	return &updatedPosition, nil
+}
+
+func (l *AccountLogic) Delete(c *gin.Context, id types.SearchId) error {
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return fmt.Errorf("查询数据权限失败")
+	}
+	// 仍是机构负责人的账号需要先转交，超出数据权限写范围的账号按未找到处理，本人不能删除自己。
+	// 在同一事务内锁定账号和它的负责人记录后再检查和删除，避免检查后被并发设置为负责人
+	return l.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var account model.Account
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope.WritableAccounts).
+			Where(accountTable+".id = ?", id.Id).First(&account).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				l.l.Error("删除账号失败: 未找到指定的账号")
+				return fmt.Errorf("删除账号失败: 未找到指定的账号")
+			}
+			l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+			return fmt.Errorf("删除账号失败: %s", err.Error())
+		}
+		if err := l.checkNotManager(c, tx, account.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", account.ID).Delete(&model.Account{}).Error; err != nil {
+			l.l.Error(fmt.Sprintf("删除账号失败: %s", err.Error()))
+			return fmt.Errorf("删除账号失败: %s", err.Error())
+		}
+		return nil
+	})
+}
+
+func (l *AccountLogic) ChangePassword(c *gin.Context, req *types2.AccountChangePasswordReq) error {
+	var account model.Account
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("account = ?", req.Account).First(&account).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+		securityEventLogic.Record(c, 0, req.Account, model.EventPasswordChange, false, "账号不存在")
+		return fmt.Errorf("查询账号失败")
+	}
+	// 对原始密码解密
+	oldPassword, err := utils.DecodeBase64Password(req.Password)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("解密密码失败: %s", err.Error()))
+		return fmt.Errorf("解密密码失败")
+	}
+	// 验证密码
+	if !account.CheckPassword(oldPassword) {
+		l.l.Error(fmt.Sprintf("用户:%s  原密码错误", req.Account))
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventPasswordChange, false, "原密码错误")
+		return fmt.Errorf("用户名或密码错误")
+	}
+	// 判断用户是否离职 或者 是否为禁用，
+	if account.IsLeave || account.IsDisabled {
+		l.l.Error(fmt.Sprintf("用户:%s  已被禁用", req.Account))
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventPasswordChange, false, "账号已禁用或离职")
+		return fmt.Errorf("用户已被禁用，请联系管理员")
+	}
+	// 解析新密码
+	newPassword, err := utils.DecodeBase64Password(req.NewPassword)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("解密密码失败: %s", err.Error()))
+		return fmt.Errorf("解密密码失败")
+	}
+	// 验证密码复杂度要求
+	if !utils.CheckPasswordComplexity(newPassword) {
+		l.l.Error(fmt.Sprintf("用户:%s  新密码不满足复杂度要求", req.Account))
+		return fmt.Errorf("不满足密码复杂度要求，要求: 至少 12 位包含数字、字母、特殊字符")
+	}
+	// 判断新密码是否与旧密码相同
+	if oldPassword == newPassword {
+		l.l.Error(fmt.Sprintf("用户:%s  新密码不能与旧密码相同", req.Account))
+		return fmt.Errorf("新密码不能与旧密码相同")
+	}
+	if err := account.SetPassword(newPassword); err != nil {
+		l.l.Error(fmt.Sprintf("设置密码失败: %s", err.Error()))
+		return fmt.Errorf("设置密码失败")
+	}
+	// 保存新密码并清除密码重置标识
+	updates := map[string]interface{}{"password": account.Password, "is_change_password": false}
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", account.ID).Updates(updates).Error; err != nil {
+		l.l.Error(fmt.Sprintf("设置新密码失败: %s", err.Error()))
+		return fmt.Errorf("设置新密码失败")
+	}
+	securityEventLogic.Record(c, account.ID, account.Account, model.EventPasswordChange, true, "")
+	return nil
+
+}
+
+func (l *AccountLogic) RestPassword(c *gin.Context, req *types2.AccountRestPasswordReq) error {
+	var account model.Account
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("account = ?", req.Account).First(&account).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+		return fmt.Errorf("查询账号失败")
+	}
+	// 创建账号， 进行密码加密
+	if err := account.SetPassword(utils.GeneratePassword()); err != nil {
+		l.l.Error(fmt.Sprintf("设置密码失败: %s", err.Error()))
+		return fmt.Errorf("设置密码失败")
+	}
+	// 设置必须修改密码
+	updates := map[string]interface{}{"password": account.Password, "is_change_password": true}
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", account.ID).Updates(updates).Error; err != nil {
+		l.l.Error(fmt.Sprintf("更新账号失败: %s", err.Error()))
+		return fmt.Errorf("更新账号失败")
+	}
+	reason := "管理员重置"
+	if claims, err := utils.GetClaims(c); err == nil {
+		reason = fmt.Sprintf("管理员 %s 重置", claims.Account)
+	}
+	securityEventLogic.Record(c, account.ID, account.Account, model.EventPasswordReset, true, reason)
+	return nil
+}
+func (l *AccountLogic) Login(c *gin.Context, req *types2.AccountLoginReq) (*utils.JWTResponse, errorx.ErrorCode, error) {
+	var account model.Account
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("account = ?", req.Account).First(&account).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+		securityEventLogic.Record(c, 0, req.Account, model.EventLoginFailure, false, "账号不存在")
+		return nil, errorx.ErrGeneric, fmt.Errorf("用户名或密码错误")
+	}
+	// 密码解密
+	password, err := utils.DecodeBase64Password(req.Password)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("解密密码失败: %s", err.Error()))
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventLoginFailure, false, "密码解析失败")
+		return nil, errorx.ErrGeneric, fmt.Errorf("解密密码失败")
+	}
+	if !account.CheckPassword(password) {
+		l.l.Error(fmt.Sprintf("用户:%s  密码错误", req.Account))
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventLoginFailure, false, "密码错误")
+		return nil, errorx.ErrGeneric, fmt.Errorf("用户名或密码错误")
+	}
+	if account.IsDisabled {
+		l.l.Error(fmt.Sprintf("用户:%s  已被禁用", req.Account))
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventLoginFailure, false, "账号已禁用")
+		return nil, errorx.ErrGeneric, fmt.Errorf("用户已被禁用，请联系管理员")
+	}
+	if account.IsLeave {
+		l.l.Error(fmt.Sprintf("用户:%s  已被离职", req.Account))
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventLoginFailure, false, "账号已离职")
+		return nil, errorx.ErrGeneric, fmt.Errorf("用户已被离职，请联系管理员")
+	}
+	if account.IsChangePassword {
+		l.l.Error(fmt.Sprintf("用户:%s  需要重置密码", req.Account))
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventLoginFailure, false, "需要重置密码")
+		return nil, errorx.ErrNeedResetPassword, fmt.Errorf("用户需要重置密码，请联系管理员")
+	}
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", account.ID).Update("last_login_time", time.Now()).Error; err != nil {
+		l.l.Error(fmt.Sprintf("更新账号登录时间失败: %s", err.Error()))
+		return nil, errorx.ErrGeneric, fmt.Errorf("更新账号登录时间失败")
+	}
+
+	// 签发令牌，角色信息写入声明
+	roles, err := accountRoleNames(c, l.db, account.ID)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询账号角色失败: %s", err.Error()))
+		return nil, errorx.ErrGeneric, fmt.Errorf("查询账号角色失败")
+	}
+	token, err := utils.GenerateToken(account.ID, account.Account, utils.ApplicationRole{
+		Application: version.IkubeopsProjectName,
+		Role:        roles,
+	})
+	if err != nil {
+		l.l.Error(fmt.Sprintf("签发令牌失败: %s", err.Error()))
+		return nil, errorx.ErrGeneric, fmt.Errorf("签发令牌失败")
+	}
+	// 记录登录会话
+	if err := sessionLogic.Create(c, account.ID, account.Account, token.SessionId, token.RefreshId); err != nil {
+		l.l.Error(fmt.Sprintf("创建登录会话失败: %s", err.Error()))
+		return nil, errorx.ErrGeneric, fmt.Errorf("创建登录会话失败")
+	}
+	securityEventLogic.Record(c, account.ID, account.Account, model.EventLoginSuccess, true, "")
+	return token, errorx.ErrNormal, nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌，沿用原会话，刷新令牌只能使用一次
+func (l *AccountLogic) Refresh(c *gin.Context, req *types2.AccountRefreshReq) (*utils.JWTResponse, errorx.ErrorCode, error) {
+	claims, err := utils.ParseJWT("Bearer " + req.RefreshToken)
+	if err != nil || claims.Type != types.TokenTypeRefresh {
+		return nil, errorx.ErrTokenRefresh, fmt.Errorf("刷新令牌无效")
+	}
+	var account model.Account
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", claims.AccountId).First(&account).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+		return nil, errorx.ErrTokenRefresh, fmt.Errorf("刷新令牌无效")
+	}
+	if account.IsDisabled || account.IsLeave {
+		return nil, errorx.ErrTokenRefresh, fmt.Errorf("用户已被禁用，请联系管理员")
+	}
+	roles, err := accountRoleNames(c, l.db, account.ID)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询账号角色失败: %s", err.Error()))
+		return nil, errorx.ErrTokenRefresh, fmt.Errorf("查询账号角色失败")
+	}
+	token, err := utils.GenerateTokenWithId(claims.Id, account.ID, account.Account, utils.ApplicationRole{
+		Application: version.IkubeopsProjectName,
+		Role:        roles,
+	})
+	if err != nil {
+		l.l.Error(fmt.Sprintf("签发令牌失败: %s", err.Error()))
+		return nil, errorx.ErrTokenRefresh, fmt.Errorf("签发令牌失败")
+	}
+	// 会话中的刷新令牌编号更换为新令牌的编号，原刷新令牌随之失效
+	if err := sessionLogic.Refresh(c, account.ID, claims.Id, claims.RefreshId, token.RefreshId); err != nil {
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventTokenRefresh, false, err.Error())
+		return nil, errorx.ErrLoginExpired, err
+	}
+	securityEventLogic.Record(c, account.ID, account.Account, model.EventTokenRefresh, true, "")
+	return token, errorx.ErrNormal, nil
+}
+
+// Logout 退出登录，吊销当前会话
+func (l *AccountLogic) Logout(c *gin.Context) error {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return err
+	}
+	if claims.Type != types.TokenTypeAccess {
+		return nil
+	}
+	if sessionLogic.rdb != nil {
+		if err := sessionLogic.remove(c, claims.AccountId, claims.Id); err != nil {
+			l.l.Error(fmt.Sprintf("吊销会话失败: %s", err.Error()))
+			return fmt.Errorf("退出登录失败")
+		}
+	}
+	securityEventLogic.Record(c, claims.AccountId, claims.Account, model.EventLogout, true, "")
+	return nil
+}
+
+// 头像允许的图片类型
+var avatarContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// 未配置或配置为 0 时使用的头像大小上限，单位 kb，以及图片宽高上限、缩放后的边长，单位 px
+const (
+	defaultAvatarMaxSize      = 2048
+	defaultAvatarMaxDimension = 4096
+	defaultAvatarDimension    = 256
+)
+
+// ChangeIcon 上传当前账号的头像，校验大小、类型和宽高后缩放为 PNG 写入对象存储
+func (l *AccountLogic) ChangeIcon(c *gin.Context) (types2.AccountIconResp, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return types2.AccountIconResp{}, err
+	}
+	if global.Storage == nil {
+		return types2.AccountIconResp{}, fmt.Errorf("对象存储未初始化")
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		l.l.Error(fmt.Sprintf("读取上传文件失败: %s", err.Error()))
+		return types2.AccountIconResp{}, fmt.Errorf("请上传头像文件")
+	}
+	maxSize := global.C.Storage.AvatarMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultAvatarMaxSize
+	}
+	if fileHeader.Size > int64(maxSize)*1024 {
+		return types2.AccountIconResp{}, fmt.Errorf("头像文件不能超过 %d KB", maxSize)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		l.l.Error(fmt.Sprintf("打开上传文件失败: %s", err.Error()))
+		return types2.AccountIconResp{}, fmt.Errorf("读取头像文件失败")
+	}
+	defer file.Close()
+	// 按文件内容判断类型，不信任客户端传入的 Content-Type
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	if !avatarContentTypes[http.DetectContentType(head[:n])] {
+		return types2.AccountIconResp{}, fmt.Errorf("头像只支持 png、jpeg、gif 格式")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return types2.AccountIconResp{}, fmt.Errorf("读取头像文件失败")
+	}
+	// 先只读取图片头部的宽高，体积很小但声明了超大宽高的图片完整解码时会耗尽内存
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("解析头像图片失败: %s", err.Error()))
+		return types2.AccountIconResp{}, fmt.Errorf("解析头像图片失败")
+	}
+	maxDimension := global.C.Storage.AvatarMaxDimension
+	if maxDimension <= 0 {
+		maxDimension = defaultAvatarMaxDimension
+	}
+	if cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return types2.AccountIconResp{}, fmt.Errorf("头像图片的宽高不能超过 %d px", maxDimension)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return types2.AccountIconResp{}, fmt.Errorf("读取头像文件失败")
+	}
+	img, _, err := image.Decode(file)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("解析头像图片失败: %s", err.Error()))
+		return types2.AccountIconResp{}, fmt.Errorf("解析头像图片失败")
+	}
+	dimension := global.C.Storage.AvatarDimension
+	if dimension <= 0 {
+		dimension = defaultAvatarDimension
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, utils.ResizeSquare(img, dimension)); err != nil {
+		l.l.Error(fmt.Sprintf("生成头像失败: %s", err.Error()))
+		return types2.AccountIconResp{}, fmt.Errorf("生成头像失败")
+	}
+	// 读取原始头像 key，跳过 AfterFind 避免拿到拼接后的访问地址
+	var account model.Account
+	if err := l.db.WithContext(c).Session(&gorm.Session{SkipHooks: true}).Where("id = ?", claims.AccountId).First(&account).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+		return types2.AccountIconResp{}, fmt.Errorf("查询账号失败")
+	}
+	id, err := utils.GenerateRandomID()
+	if err != nil {
+		return types2.AccountIconResp{}, err
+	}
+	key := fmt.Sprintf("account/%d/%s.png", account.ID, id)
+	if err := global.Storage.Put(c, key, &buf, int64(buf.Len()), "image/png"); err != nil {
+		l.l.Error(fmt.Sprintf("上传头像失败: %s", err.Error()))
+		return types2.AccountIconResp{}, fmt.Errorf("上传头像失败")
+	}
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", account.ID).Update("icon", key).Error; err != nil {
+		l.l.Error(fmt.Sprintf("更新头像失败: %s", err.Error()))
+		_ = global.Storage.Delete(c, key)
+		return types2.AccountIconResp{}, fmt.Errorf("更新头像失败")
+	}
+	// 只清理该账号自己上传的旧头像，默认头像保留
+	if strings.HasPrefix(account.Icon, fmt.Sprintf("account/%d/", account.ID)) {
+		if err := global.Storage.Delete(c, account.Icon); err != nil {
+			l.l.Warn(fmt.Sprintf("删除旧头像失败: %s", err.Error()))
+		}
+	}
+	return types2.AccountIconResp{IconPath: global.Storage.URL(key)}, nil
+}
+
+// Config 只需要保证 全局对象Config和全局Logger已经加载完成
+func (l *AccountLogic) Config() {
+	l.l = global.L.Named(apps.AppName).Named(apps.AppAccount).Named("logic")
+	l.db = global.DB.GetDb()
+}
+
+func (l *AccountLogic) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppAccount)
+}
+
+func init() {
+	// 注册
+	router.RegistryLogic(accountLogic)
+}
@@ -0,0 +1,468 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	validator2 "github.com/go-playground/validator/v10"
+	"github.com/xuri/excelize/v2"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	model2 "github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
+	"github.com/yanshicheng/ikube-gin-xjob/common/validator"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"gorm.io/gorm"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	accountImportMaxSize = 5 << 20 // 导入文件大小上限 5M
+	accountImportMaxRows = 2000    // 单次导入的最大行数
+	accountImportSheet   = "Sheet1"
+	hireDateLayout       = "2006-01-02"
+)
+
+// 导入文件的列名，与 AccountImportRow 的 json 标签一致
+var accountImportColumns = importColumns(reflect.TypeOf(types2.AccountImportRow{}))
+
+// 导出在导入列的基础上追加的只读列
+var accountExportExtraColumns = []string{"isDisabled", "isLeave", "lastLoginTime"}
+
+func importColumns(t reflect.Type) []string {
+	columns := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		columns = append(columns, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return columns
+}
+
+// Import 从 CSV/XLSX 批量导入账号，任意一行校验失败则整体不写入
+func (l *AccountLogic) Import(c *gin.Context, req types2.AccountImportReq) (*types2.AccountImportResp, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		l.l.Error(fmt.Sprintf("读取上传文件失败: %s", err.Error()))
+		return nil, fmt.Errorf("请上传导入文件")
+	}
+	if fileHeader.Size > accountImportMaxSize {
+		return nil, fmt.Errorf("导入文件不能超过 %dM", accountImportMaxSize>>20)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		l.l.Error(fmt.Sprintf("打开上传文件失败: %s", err.Error()))
+		return nil, fmt.Errorf("读取导入文件失败")
+	}
+	defer file.Close()
+	records, err := readImportRecords(fileHeader.Filename, file)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("解析导入文件失败: %s", err.Error()))
+		return nil, fmt.Errorf("解析导入文件失败: %s", err.Error())
+	}
+	rows, err := parseImportRows(records)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("导入文件没有数据")
+	}
+	if len(rows) > accountImportMaxRows {
+		return nil, fmt.Errorf("单次最多导入 %d 行", accountImportMaxRows)
+	}
+
+	resp := &types2.AccountImportResp{DryRun: req.DryRun, Total: len(rows)}
+	accounts, importErrors, err := l.validateImportRows(c, rows)
+	if err != nil {
+		return nil, err
+	}
+	resp.Errors = importErrors
+	if len(importErrors) > 0 || req.DryRun {
+		return resp, nil
+	}
+
+	// 导入账号使用相同的初始密码，只需要加密一次
+	var tmp model.Account
+	if err := tmp.SetPassword(utils.GeneratePassword()); err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		account.Password = tmp.Password
+		account.Icon = utils.GenerateIcon()
+		account.IsChangePassword = true
+	}
+	// 按职位汇总新增人数，在同一事务内校验编制
+	adding := make(map[uint]int64)
+	for _, account := range accounts {
+		adding[account.PositionId]++
+	}
+	if err := l.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		for positionId, count := range adding {
+			if err := checkPositionQuota(tx, positionId, count); err != nil {
+				return err
+			}
+		}
+		return tx.CreateInBatches(accounts, 100).Error
+	}); err != nil {
+		l.l.Error(fmt.Sprintf("导入账号失败: %s", err.Error()))
+		if errors.Is(err, errPositionQuota) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("导入账号失败")
+	}
+	resp.Created = len(accounts)
+	l.l.Info(fmt.Sprintf("导入账号 %d 个", resp.Created))
+	return resp, nil
+}
+
+// validateImportRows 校验导入行并转换为账号，返回按行汇总的错误
+func (l *AccountLogic) validateImportRows(c *gin.Context, rows []*importRow) ([]*model.Account, []*types2.AccountImportError, error) {
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, nil, fmt.Errorf("查询数据权限失败")
+	}
+	snapshot, err := orgCache.get(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询机构失败: %s", err.Error()))
+		return nil, nil, fmt.Errorf("查询机构失败")
+	}
+	orgs := snapshot.paths
+	var positions []*model.Position
+	if err := l.db.WithContext(c).Find(&positions).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询职位失败: %s", err.Error()))
+		return nil, nil, fmt.Errorf("查询职位失败")
+	}
+	positionIds := make(map[uint]map[string]uint)
+	for _, p := range positions {
+		if positionIds[p.OrganizationId] == nil {
+			positionIds[p.OrganizationId] = make(map[string]uint)
+		}
+		positionIds[p.OrganizationId][p.Name] = p.ID
+	}
+	existing, err := l.existingAccountKeys(c, rows)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询已有账号失败: %s", err.Error()))
+		return nil, nil, fmt.Errorf("查询已有账号失败")
+	}
+
+	seen := map[string]map[string]int{"account": {}, "mobile": {}, "email": {}, "workNumber": {}}
+	var accounts []*model.Account
+	var importErrors []*types2.AccountImportError
+	for _, row := range rows {
+		errs := map[string]string{}
+		if err := binding.Validator.ValidateStruct(&row.AccountImportRow); err != nil {
+			var validationErrs validator2.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				for field, msg := range validator.RemoveTopStruct(validationErrs) {
+					errs[field] = msg
+				}
+			} else {
+				errs["row"] = err.Error()
+			}
+		}
+		// 唯一字段：文件内不能重复，也不能与已有账号冲突
+		for field, value := range map[string]string{
+			"account":    row.Account,
+			"mobile":     row.Mobile,
+			"email":      row.Email,
+			"workNumber": row.WorkNumber,
+		} {
+			if value == "" {
+				continue
+			}
+			if line, ok := seen[field][value]; ok {
+				errs[field] = fmt.Sprintf("与第 %d 行重复", line)
+				continue
+			}
+			seen[field][value] = row.line
+			if existing[field][value] {
+				errs[field] = fmt.Sprintf("%s 已存在", value)
+			}
+		}
+		account := &model.Account{
+			UserName:   row.UserName,
+			Account:    row.Account,
+			Mobile:     row.Mobile,
+			Email:      row.Email,
+			WorkNumber: row.WorkNumber,
+		}
+		if hireDate, err := time.ParseInLocation(hireDateLayout, row.HireDate, time.Local); err == nil {
+			account.HireDate = model2.DateTime{Time: hireDate}
+		}
+		if row.Organization != "" {
+			if orgId, ok := orgs.ids[normalizeOrganizationPath(row.Organization)]; ok {
+				account.OrganizationId = orgId
+				if !scope.hasOrganization(orgId) {
+					errs["organization"] = fmt.Sprintf("机构 %s 超出数据权限范围", row.Organization)
+				} else if err := snapshot.byId[orgId].CheckAccounts(); err != nil {
+					errs["organization"] = err.Error()
+				}
+				if positionId, ok := orgs.findPosition(orgId, row.Position, positionIds); ok {
+					account.PositionId = positionId
+				} else if row.Position != "" {
+					errs["position"] = fmt.Sprintf("机构 %s 下不存在职位 %s", row.Organization, row.Position)
+				}
+			} else {
+				errs["organization"] = fmt.Sprintf("机构不存在: %s", row.Organization)
+			}
+		}
+		if len(errs) > 0 {
+			importErrors = append(importErrors, &types2.AccountImportError{Row: row.line, Errors: errs})
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, importErrors, nil
+}
+
+// existingAccountKeys 查询与导入行唯一字段冲突的已有账号，已删除的账号不占用唯一字段
+func (l *AccountLogic) existingAccountKeys(c *gin.Context, rows []*importRow) (map[string]map[string]bool, error) {
+	var accountNames, mobiles, emails, workNumbers []string
+	for _, row := range rows {
+		accountNames = append(accountNames, row.Account)
+		mobiles = append(mobiles, row.Mobile)
+		emails = append(emails, row.Email)
+		workNumbers = append(workNumbers, row.WorkNumber)
+	}
+	var list []*model.Account
+	if err := l.db.WithContext(c).Select("account", "mobile", "email", "work_number").
+		Where("account IN ? OR mobile IN ? OR email IN ? OR work_number IN ?", accountNames, mobiles, emails, workNumbers).
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	existing := map[string]map[string]bool{"account": {}, "mobile": {}, "email": {}, "workNumber": {}}
+	for _, a := range list {
+		existing["account"][a.Account] = true
+		existing["mobile"][a.Mobile] = true
+		existing["email"][a.Email] = true
+		existing["workNumber"][a.WorkNumber] = true
+	}
+	return existing, nil
+}
+
+// Export 按列表的过滤条件导出账号，列格式与导入一致
+func (l *AccountLogic) Export(c *gin.Context, req types2.AccountExportReq) (*types2.AccountExportResp, error) {
+	var list []*model.Account
+	db, err := l.accountFilter(c, accountWithNames(l.db.WithContext(c)), req.AccountQueryReq)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Order(accountTable + ".id ASC").Find(&list).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询账号失败")
+	}
+	orgs, err := loadOrganizationPaths(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询机构失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询机构失败")
+	}
+
+	records := [][]string{append(append([]string{}, accountImportColumns...), accountExportExtraColumns...)}
+	for _, a := range list {
+		lastLoginTime := ""
+		if a.LastLoginTime != nil {
+			lastLoginTime = a.LastLoginTime.Format("2006-01-02 15:04:05")
+		}
+		records = append(records, []string{
+			a.UserName,
+			a.Account,
+			a.Mobile,
+			a.Email,
+			a.WorkNumber,
+			a.HireDate.Format(hireDateLayout),
+			orgs.paths[a.OrganizationId],
+			a.PositionName,
+			strconv.FormatBool(a.IsDisabled),
+			strconv.FormatBool(a.IsLeave),
+			lastLoginTime,
+		})
+	}
+
+	resp := &types2.AccountExportResp{}
+	var buf bytes.Buffer
+	switch req.Format {
+	case "csv":
+		// 写入 BOM，避免 Excel 打开中文乱码
+		buf.WriteString("\xEF\xBB\xBF")
+		w := csv.NewWriter(&buf)
+		if err := w.WriteAll(records); err != nil {
+			l.l.Error(fmt.Sprintf("生成导出文件失败: %s", err.Error()))
+			return nil, fmt.Errorf("生成导出文件失败")
+		}
+		resp.ContentType = "text/csv; charset=utf-8"
+	default:
+		req.Format = "xlsx"
+		if err := writeXlsx(&buf, records); err != nil {
+			l.l.Error(fmt.Sprintf("生成导出文件失败: %s", err.Error()))
+			return nil, fmt.Errorf("生成导出文件失败")
+		}
+		resp.ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	resp.FileName = fmt.Sprintf("accounts-%s.%s", time.Now().Format("20060102150405"), req.Format)
+	resp.Data = buf.Bytes()
+	return resp, nil
+}
+
+func writeXlsx(w io.Writer, records [][]string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	sw, err := f.NewStreamWriter(accountImportSheet)
+	if err != nil {
+		return err
+	}
+	for i, record := range records {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		values := make([]interface{}, len(record))
+		for j, v := range record {
+			values[j] = v
+		}
+		if err := sw.SetRow(cell, values); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	_, err = f.WriteTo(w)
+	return err
+}
+
+// readImportRecords 按扩展名读取 CSV 或 XLSX 的全部行
+func readImportRecords(filename string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > 0 && len(records[0]) > 0 {
+			records[0][0] = strings.TrimPrefix(records[0][0], "\xEF\xBB\xBF")
+		}
+		return records, nil
+	case ".xlsx":
+		f, err := excelize.OpenReader(r, excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return f.GetRows(f.GetSheetName(0))
+	default:
+		return nil, fmt.Errorf("只支持 csv、xlsx 格式")
+	}
+}
+
+type importRow struct {
+	types2.AccountImportRow
+	line int
+}
+
+// parseImportRows 按表头列名把记录映射为导入行，忽略未知列和空行
+func parseImportRows(records [][]string) ([]*importRow, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	index := make(map[string]int)
+	for i, name := range records[0] {
+		index[strings.TrimSpace(name)] = i
+	}
+	for _, column := range accountImportColumns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("导入文件缺少列: %s", column)
+		}
+	}
+	var rows []*importRow
+	for i, record := range records[1:] {
+		get := func(column string) string {
+			if idx := index[column]; idx < len(record) {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		row := &importRow{line: i + 2}
+		row.UserName = get("userName")
+		row.Account = get("account")
+		row.Mobile = get("mobile")
+		row.Email = get("email")
+		row.WorkNumber = get("workNumber")
+		row.HireDate = get("hireDate")
+		row.Organization = get("organization")
+		row.Position = get("position")
+		// XLSX 中设置为日期格式的单元格读取到的是序列号
+		if serial, err := strconv.ParseFloat(row.HireDate, 64); err == nil {
+			if t, err := excelize.ExcelDateToTime(serial, false); err == nil {
+				row.HireDate = t.Format(hireDateLayout)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// organizationPaths 机构 ID 与名称路径的双向映射
+type organizationPaths struct {
+	ids     map[string]uint
+	paths   map[uint]string
+	parents map[uint]uint
+}
+
+// loadOrganizationPaths 从机构缓存中取出名称路径
+func loadOrganizationPaths(ctx context.Context, db *gorm.DB) (*organizationPaths, error) {
+	snapshot, err := orgCache.get(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.paths, nil
+}
+
+// newOrganizationPaths 按机构列表建立名称路径，会修改机构的子节点
+func newOrganizationPaths(orgs []*model.Organization) *organizationPaths {
+	t := tree.Build(orgs, nil)
+	result := &organizationPaths{
+		ids:     make(map[string]uint, len(orgs)),
+		paths:   make(map[uint]string, len(orgs)),
+		parents: make(map[uint]uint, len(orgs)),
+	}
+	for _, o := range orgs {
+		path := organizationPath(t, o.ID)
+		result.ids[path] = o.ID
+		result.paths[o.ID] = path
+		result.parents[o.ID] = o.ParentId
+	}
+	return result
+}
+
+// findPosition 在机构及其上级机构中按名称查找职位，离机构最近的优先
+func (p *organizationPaths) findPosition(orgId uint, name string, positions map[uint]map[string]uint) (uint, bool) {
+	for depth := 0; orgId != 0 && depth <= len(p.parents); depth++ {
+		if id, ok := positions[orgId][name]; ok {
+			return id, true
+		}
+		orgId = p.parents[orgId]
+	}
+	return 0, false
+}
+
+func normalizeOrganizationPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,229 @@
+package logic
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	upmsModel "github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"math/big"
+	"time"
+)
+
+const (
+	emailChangeKeyPrefix   = "ikubexjob:email_change:" // 邮箱变更验证码 ikubexjob:email_change:<accountId>
+	emailChangeTTL         = 15 * time.Minute
+	emailChangeMaxAttempts = 5
+)
+
+// emailChange 待验证的邮箱变更，只保存验证码的摘要
+type emailChange struct {
+	Email    string `json:"email"`
+	CodeHash string `json:"codeHash"`
+	Attempts int    `json:"attempts"`
+}
+
+func emailChangeKey(accountId uint) string {
+	return fmt.Sprintf("%s%d", emailChangeKeyPrefix, accountId)
+}
+
+// Me 查询当前登录账号的资料，包含机构路径和职位
+func (l *AccountLogic) Me(c *gin.Context) (*model.Account, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	return l.profile(c, claims.AccountId)
+}
+
+// UpdateMe 修改当前账号允许自助修改的字段
+func (l *AccountLogic) UpdateMe(c *gin.Context, req *types2.AccountProfileUpdateReq) (*model.Account, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("mobile = ? AND id <> ?", req.Mobile, claims.AccountId).Count(&count).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询手机号失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询手机号失败")
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("手机号已被使用")
+	}
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", claims.AccountId).Update("mobile", req.Mobile).Error; err != nil {
+		l.l.Error(fmt.Sprintf("更新账号失败: %s", err.Error()))
+		return nil, fmt.Errorf("更新账号失败")
+	}
+	return l.profile(c, claims.AccountId)
+}
+
+// profile 查询账号资料，清除密码摘要后返回给本人
+func (l *AccountLogic) profile(c *gin.Context, accountId uint) (*model.Account, error) {
+	account, err := l.Get(c, types.SearchId{Id: accountId})
+	if err != nil {
+		return nil, err
+	}
+	account.Password = ""
+	return account, nil
+}
+
+// RequestEmailChange 向新邮箱发送验证码，验证通过后才会修改邮箱
+func (l *AccountLogic) RequestEmailChange(c *gin.Context, req *types2.AccountEmailChangeReq) error {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return err
+	}
+	if global.RDB == nil || global.Mailer == nil {
+		return fmt.Errorf("邮箱验证未启用，请联系管理员")
+	}
+	if err := l.checkEmailAvailable(c, claims.AccountId, req.Email); err != nil {
+		return err
+	}
+	code, err := verificationCode()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(&emailChange{Email: req.Email, CodeHash: utils.HashToken(code)})
+	if err != nil {
+		return err
+	}
+	if err := global.RDB.GetClient().Set(c, emailChangeKey(claims.AccountId), data, emailChangeTTL).Err(); err != nil {
+		l.l.Error(fmt.Sprintf("保存邮箱验证码失败: %s", err.Error()))
+		return fmt.Errorf("发送验证码失败")
+	}
+	body := fmt.Sprintf("您好 %s：\n\n您正在修改登录邮箱，验证码为 %s，%d 分钟内有效。\n如果不是您本人操作，请忽略本邮件。",
+		claims.Account, code, int(emailChangeTTL.Minutes()))
+	if err := global.Mailer.Send(c, []string{req.Email}, "邮箱变更验证码", body); err != nil {
+		l.l.Error(fmt.Sprintf("发送邮箱验证码失败: %s", err.Error()))
+		return fmt.Errorf("发送验证码失败")
+	}
+	return nil
+}
+
+// VerifyEmailChange 校验验证码并修改邮箱
+func (l *AccountLogic) VerifyEmailChange(c *gin.Context, req *types2.AccountEmailVerifyReq) error {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return err
+	}
+	if global.RDB == nil {
+		return fmt.Errorf("邮箱验证未启用，请联系管理员")
+	}
+	rdb := global.RDB.GetClient()
+	key := emailChangeKey(claims.AccountId)
+	data, err := rdb.Get(c, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return fmt.Errorf("验证码已过期，请重新获取")
+		}
+		l.l.Error(fmt.Sprintf("查询邮箱验证码失败: %s", err.Error()))
+		return fmt.Errorf("查询验证码失败")
+	}
+	var pending emailChange
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return fmt.Errorf("验证码已过期，请重新获取")
+	}
+	if utils.HashToken(req.Code) != pending.CodeHash {
+		// 超过最大尝试次数后作废验证码
+		pending.Attempts++
+		if pending.Attempts >= emailChangeMaxAttempts {
+			rdb.Del(c, key)
+			return fmt.Errorf("验证码错误次数过多，请重新获取")
+		}
+		if data, err := json.Marshal(&pending); err == nil {
+			rdb.Set(c, key, data, redis.KeepTTL)
+		}
+		return fmt.Errorf("验证码错误")
+	}
+	rdb.Del(c, key)
+	if err := l.checkEmailAvailable(c, claims.AccountId, pending.Email); err != nil {
+		return err
+	}
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", claims.AccountId).Update("email", pending.Email).Error; err != nil {
+		l.l.Error(fmt.Sprintf("更新邮箱失败: %s", err.Error()))
+		return fmt.Errorf("更新邮箱失败")
+	}
+	securityEventLogic.Record(c, claims.AccountId, claims.Account, model.EventEmailChange, true, pending.Email)
+	return nil
+}
+
+func (l *AccountLogic) checkEmailAvailable(c *gin.Context, accountId uint, email string) error {
+	var count int64
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("email = ? AND id <> ?", email, accountId).Count(&count).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询邮箱失败: %s", err.Error()))
+		return fmt.Errorf("查询邮箱失败")
+	}
+	if count > 0 {
+		return fmt.Errorf("邮箱已被使用")
+	}
+	return nil
+}
+
+// verificationCode 生成 6 位数字验证码
+func verificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// ChangeMyPassword 修改当前账号的密码
+func (l *AccountLogic) ChangeMyPassword(c *gin.Context, req *types2.AccountMePasswordReq) error {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return err
+	}
+	return l.ChangePassword(c, &types2.AccountChangePasswordReq{
+		Account:       claims.Account,
+		Password:      req.Password,
+		NewPassword:   req.NewPassword,
+		ReNewPassword: req.ReNewPassword,
+	})
+}
+
+// MyRoles 查询当前账号生效的角色，包含所在用户组绑定的角色
+func (l *AccountLogic) MyRoles(c *gin.Context) ([]*upmsModel.Role, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := accountRoles(c, l.db, claims.AccountId)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询账号角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询账号角色失败")
+	}
+	return roles, nil
+}
+
+// MyMenus 查询当前账号角色可见的菜单树和授权的按钮权限标识
+func (l *AccountLogic) MyMenus(c *gin.Context) (*types2.AccountMenusResp, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	menus, permissions, err := accountMenus(c, l.db, claims.AccountId)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询账号菜单失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询账号菜单失败")
+	}
+	return &types2.AccountMenusResp{Menus: menus, Permissions: permissions}, nil
+}
+
+// MyEvents 查询当前账号的安全事件
+func (l *AccountLogic) MyEvents(c *gin.Context, query types2.SecurityEventQueryReq) (*types.QueryResponse, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	query.AccountId = claims.AccountId
+	query.Account = ""
+	return securityEventLogic.List(c, query)
+}
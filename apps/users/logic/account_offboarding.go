@@ -0,0 +1,244 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	commonModel "github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const (
+	anonymizeBatchSize  = 100 // 后台任务每次查询的待匿名化账号数量
+	anonymizeErrorLimit = 256
+)
+
+// Offboard 办理离职：在一个事务内标记离职日期、解除角色、退出用户组、把负责的机构转交给交接人、删除 API 令牌、结束任职记录、取消待生效的调动，
+// 提交后吊销全部登录会话，保留期满后由后台任务匿名化个人信息
+func (l *AccountLogic) Offboard(c *gin.Context, id types.SearchId, req *types2.AccountOffboardReq) (*model.AccountOffboarding, error) {
+	if req.LeaveDate.IsZero() {
+		return nil, fmt.Errorf("离职日期不能为空")
+	}
+	// 离职立即生效，不支持预约未来的离职日期
+	if compareDate(req.LeaveDate, today()) > 0 {
+		return nil, fmt.Errorf("离职日期不能晚于今天")
+	}
+	account, err := l.writableAccount(c, id.Id)
+	if err != nil {
+		return nil, err
+	}
+	if account.IsLeave {
+		return nil, fmt.Errorf("账号已离职")
+	}
+	claims, err := utils.GetClaims(c)
+	if err == nil && claims.AccountId == account.ID {
+		return nil, fmt.Errorf("不能为自己办理离职")
+	}
+	// 负责机构的账号必须指定交接人
+	if req.SuccessorId == 0 {
+		names, err := managedOrganizationNames(c, l.db, account.ID)
+		if err != nil {
+			l.l.Error(fmt.Sprintf("查询账号负责的机构失败: %s", err.Error()))
+			return nil, fmt.Errorf("查询账号负责的机构失败")
+		}
+		if len(names) > 0 {
+			return nil, fmt.Errorf("账号是机构 %s 的负责人，请指定交接人", strings.Join(names, "、"))
+		}
+	} else {
+		if req.SuccessorId == account.ID {
+			return nil, fmt.Errorf("交接人不能是离职账号自己")
+		}
+		successor, err := l.visibleAccount(c, req.SuccessorId)
+		if err != nil {
+			return nil, fmt.Errorf("交接人不存在或超出数据权限范围")
+		}
+		if successor.IsLeave {
+			return nil, fmt.Errorf("交接人已离职")
+		}
+	}
+	roles, err := accountRoleNames(c, l.db, account.ID)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询账号角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询账号角色失败")
+	}
+	leaveDate := req.LeaveDate.Format(dateLayout)
+	offboarding := &model.AccountOffboarding{
+		AccountId:   account.ID,
+		LeaveDate:   req.LeaveDate,
+		SuccessorId: req.SuccessorId,
+		Reason:      req.Reason,
+		Roles:       roles,
+	}
+	if days := global.C.Offboarding.RetentionDays; days > 0 {
+		offboarding.AnonymizeAfter = commonModel.DateTime{Time: req.LeaveDate.AddDate(0, 0, days)}
+	}
+	if claims != nil {
+		offboarding.OperatorId = claims.AccountId
+		offboarding.Operator = claims.Account
+	}
+	err = l.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// 已经被其他请求办理离职时不重复处理
+		result := tx.Model(&model.Account{}).Where("id = ? AND is_leave = ?", account.ID, false).
+			Updates(map[string]interface{}{"is_leave": true, "leave_date": leaveDate})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("账号已离职")
+		}
+		if err := tx.Where("account_id = ?", account.ID).Delete(&model.AccountRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("account_id = ?", account.ID).Delete(&model.GroupAccount{}).Error; err != nil {
+			return err
+		}
+		if req.SuccessorId != 0 {
+			orgIds, err := handoverManagers(tx, account.ID, req.SuccessorId)
+			if err != nil {
+				return err
+			}
+			offboarding.Organizations = orgIds
+		}
+		if err := tx.Where("account_id = ?", account.ID).Delete(&model.ApiToken{}).Error; err != nil {
+			return err
+		}
+		// 离职日期当天起不再属于所在机构
+		var current model.AccountAssignment
+		if err := tx.Where("account_id = ? AND end_date IS NULL", account.ID).Order("id DESC").First(&current).Error; err == nil {
+			if compareDate(req.LeaveDate, current.StartDate) < 0 {
+				return fmt.Errorf("离职日期不能早于当前任职的开始日期 %s", current.StartDate.Format(dateLayout))
+			}
+			if err := tx.Model(&current).Update("end_date", leaveDate).Error; err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := tx.Model(&model.AccountTransfer{}).Where("account_id = ? AND status = ?", account.ID, model.TransferStatusPending).
+			Update("status", model.TransferStatusCanceled).Error; err != nil {
+			return err
+		}
+		return tx.Create(offboarding).Error
+	})
+	if err != nil {
+		l.l.Error(fmt.Sprintf("办理离职失败, id: %d, error: %s", account.ID, err.Error()))
+		return nil, fmt.Errorf("办理离职失败: %s", err.Error())
+	}
+	// 会话存储在 redis 中，不能与数据库在同一事务内提交，失败时只记录日志，离职账号无法再刷新令牌
+	if err := sessionLogic.RevokeAll(c, account.ID); err != nil {
+		l.l.Error(fmt.Sprintf("吊销账号 %d 的会话失败: %s", account.ID, err.Error()))
+	}
+	securityEventLogic.Record(c, account.ID, account.Account, model.EventOffboard, true, req.Reason)
+	return offboarding, nil
+}
+
+// Offboarding 查询账号的离职记录
+func (l *AccountLogic) Offboarding(c *gin.Context, id types.SearchId) (*model.AccountOffboarding, error) {
+	if _, err := l.visibleAccount(c, id.Id); err != nil {
+		return nil, err
+	}
+	var offboarding model.AccountOffboarding
+	if err := l.db.WithContext(c).Where("account_id = ?", id.Id).Order("id DESC").First(&offboarding).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("账号没有离职记录")
+		}
+		l.l.Error(fmt.Sprintf("查询离职记录失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询离职记录失败")
+	}
+	return &offboarding, nil
+}
+
+// Anonymize 匿名化保留期已满的离职账号：姓名、账号、手机号、邮箱、工号替换为按账号 ID 生成的占位值，头像恢复为默认头像，
+// 安全事件中的账号同步替换。按 ID 分批向后处理，失败的账号记录原因后跳过，下次执行时重试，不会阻塞后面的账号
+func (l *AccountLogic) Anonymize(ctx context.Context) error {
+	for lastId := uint(0); ; {
+		var list []*model.AccountOffboarding
+		if err := l.db.WithContext(ctx).
+			Where("anonymized_at IS NULL AND anonymize_after IS NOT NULL AND anonymize_after <= ? AND id > ?", today().Format(dateLayout), lastId).
+			Order("id").Limit(anonymizeBatchSize).Find(&list).Error; err != nil {
+			return fmt.Errorf("查询待匿名化账号失败: %w", err)
+		}
+		for _, offboarding := range list {
+			lastId = offboarding.ID
+			if err := l.anonymize(ctx, offboarding); err != nil {
+				l.l.Error(fmt.Sprintf("匿名化账号 %d 失败: %s", offboarding.AccountId, err.Error()))
+				if err := l.db.WithContext(ctx).Model(offboarding).
+					Update("anonymize_error", truncateRunes(err.Error(), anonymizeErrorLimit)).Error; err != nil {
+					l.l.Error(fmt.Sprintf("记录账号 %d 匿名化失败原因失败: %s", offboarding.AccountId, err.Error()))
+				}
+				continue
+			}
+			l.l.Info(fmt.Sprintf("账号 %d 离职保留期已满，个人信息已匿名化", offboarding.AccountId))
+		}
+		if len(list) < anonymizeBatchSize {
+			return nil
+		}
+	}
+}
+
+// anonymize 在事务中匿名化一个离职账号，提交后删除账号自己上传的头像
+func (l *AccountLogic) anonymize(ctx context.Context, offboarding *model.AccountOffboarding) error {
+	placeholder := fmt.Sprintf("anonymized-%d", offboarding.AccountId)
+	var icon string
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 离职后被删除的账号同样需要匿名化
+		if err := tx.Unscoped().Model(&model.Account{}).Where("id = ?", offboarding.AccountId).
+			Pluck("icon", &icon).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.Account{}).Where("id = ?", offboarding.AccountId).Updates(map[string]interface{}{
+			"user_name":   "已匿名",
+			"account":     placeholder,
+			"mobile":      fmt.Sprintf("0%010d", offboarding.AccountId), // 手机号以 1 开头，0 开头的占位值不会与真实手机号冲突
+			"email":       placeholder + "@invalid",
+			"work_number": placeholder,
+			"icon":        utils.DefaultIcon,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.SecurityEvent{}).Where("account_id = ?", offboarding.AccountId).
+			Update("account", placeholder).Error; err != nil {
+			return err
+		}
+		return tx.Model(offboarding).Updates(map[string]interface{}{"anonymized_at": time.Now(), "anonymize_error": ""}).Error
+	})
+	if err != nil {
+		return err
+	}
+	// 只清理该账号自己上传的头像，默认头像保留
+	if global.Storage != nil && strings.HasPrefix(icon, fmt.Sprintf("account/%d/", offboarding.AccountId)) {
+		if err := global.Storage.Delete(ctx, icon); err != nil {
+			l.l.Warn(fmt.Sprintf("删除账号 %d 的头像失败: %s", offboarding.AccountId, err.Error()))
+		}
+	}
+	return nil
+}
+
+// accountAnonymizeJob 离职账号个人信息匿名化任务
+type accountAnonymizeJob struct{}
+
+func (j *accountAnonymizeJob) Name() string {
+	return fmt.Sprintf("%s.%s.anonymize", apps.AppName, apps.AppAccount)
+}
+
+func (j *accountAnonymizeJob) Interval() time.Duration {
+	return time.Hour
+}
+
+func (j *accountAnonymizeJob) Run(ctx context.Context) error {
+	return accountLogic.Anonymize(ctx)
+}
+
+func init() {
+	router.RegistryJob(&accountAnonymizeJob{})
+}
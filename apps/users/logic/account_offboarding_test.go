@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	commonModel "github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"go.uber.org/zap"
+)
+
+func TestAnonymize(t *testing.T) {
+	f := newRestructureFixture(t, 0)
+	l := &AccountLogic{l: zap.NewNop(), db: f.db}
+	// 姓名为 "无法匿名化" 的账号在更新时报错，模拟始终失败的账号
+	require.NoError(t, f.db.Exec(fmt.Sprintf(`CREATE TRIGGER anonymize_fail BEFORE UPDATE ON %s
+		WHEN OLD.user_name = '无法匿名化' BEGIN SELECT RAISE(ABORT, '账号被锁定'); END`, accountTable)).Error)
+
+	offboard := func(name string, anonymizeAfter commonModel.DateTime) *model.AccountOffboarding {
+		account := &model.Account{
+			UserName: name, Account: name, Mobile: name, Email: name + "@example.com", WorkNumber: name,
+			HireDate: daysFromToday(-800), OrganizationId: f.rd.ID, PositionId: f.rdEng.ID, IsLeave: true,
+		}
+		require.NoError(t, f.db.Create(account).Error)
+		offboarding := &model.AccountOffboarding{
+			AccountId: account.ID, LeaveDate: daysFromToday(-400), AnonymizeAfter: anonymizeAfter,
+			Roles: []string{}, Organizations: []uint{},
+		}
+		require.NoError(t, f.db.Create(offboarding).Error)
+		return offboarding
+	}
+	// 失败的账号超过一批，排在后面的账号仍然需要匿名化
+	var failed []*model.AccountOffboarding
+	for i := 0; i < anonymizeBatchSize+1; i++ {
+		o := offboard(fmt.Sprintf("fail-%d", i), daysFromToday(-1))
+		require.NoError(t, f.db.Model(&model.Account{}).Where("id = ?", o.AccountId).Update("user_name", "无法匿名化").Error)
+		failed = append(failed, o)
+	}
+	due := offboard("due", daysFromToday(0))
+	require.NoError(t, f.db.Create(&model.SecurityEvent{AccountId: due.AccountId, Account: "due", Type: model.EventLoginSuccess}).Error)
+	future := offboard("future", daysFromToday(1))
+	never := offboard("never", commonModel.DateTime{}) // retention_days 为 0 时不生成匿名化日期
+
+	require.NoError(t, l.Anonymize(context.Background()))
+
+	reload := func(o *model.AccountOffboarding) (*model.AccountOffboarding, *model.Account) {
+		var offboarding model.AccountOffboarding
+		require.NoError(t, f.db.Where("id = ?", o.ID).First(&offboarding).Error)
+		var account model.Account
+		require.NoError(t, f.db.Where("id = ?", o.AccountId).First(&account).Error)
+		return &offboarding, &account
+	}
+	offboarding, account := reload(due)
+	assert.NotNil(t, offboarding.AnonymizedAt, "排在失败账号之后的账号同样匿名化")
+	assert.Empty(t, offboarding.AnonymizeError)
+	assert.Equal(t, "已匿名", account.UserName)
+	assert.Equal(t, fmt.Sprintf("anonymized-%d", due.AccountId), account.Account)
+	var event model.SecurityEvent
+	require.NoError(t, f.db.Where("account_id = ?", due.AccountId).First(&event).Error)
+	assert.Equal(t, account.Account, event.Account, "安全事件中的账号同步匿名化")
+
+	for _, o := range []*model.AccountOffboarding{failed[0], failed[len(failed)-1]} {
+		offboarding, account := reload(o)
+		assert.Nil(t, offboarding.AnonymizedAt, "失败的账号等待下次重试")
+		assert.Contains(t, offboarding.AnonymizeError, "账号被锁定", "记录失败原因")
+		assert.Equal(t, "无法匿名化", account.UserName, "失败时回滚")
+	}
+	for _, o := range []*model.AccountOffboarding{future, never} {
+		offboarding, account := reload(o)
+		assert.Nil(t, offboarding.AnonymizedAt, "未到匿名化日期或不匿名化的账号保持不变")
+		assert.NotEqual(t, "已匿名", account.UserName)
+	}
+
+	// 失败原因消除后下次执行时重试成功，并清空失败原因
+	require.NoError(t, f.db.Exec("DROP TRIGGER anonymize_fail").Error)
+	require.NoError(t, l.Anonymize(context.Background()))
+	offboarding, _ = reload(failed[0])
+	assert.NotNil(t, offboarding.AnonymizedAt)
+	assert.Empty(t, offboarding.AnonymizeError)
+}
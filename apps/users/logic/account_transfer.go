@@ -0,0 +1,326 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	commonModel "github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/sql"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout         = "2006-01-02"
+	transferBatchSize  = 100 // 后台任务每次处理的调动数量
+	assignmentTable    = "ikubexjob_user_account_assignment"
+	transferErrorLimit = 256
+)
+
+// today 当天日期，任职记录和调动都按天生效
+func today() commonModel.DateTime {
+	now := time.Now()
+	return commonModel.DateTime{Time: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)}
+}
+
+// compareDate 按日期比较，请求和数据库中的日期时区可能不同，不能直接比较时间
+func compareDate(a, b commonModel.DateTime) int {
+	return strings.Compare(a.Format(dateLayout), b.Format(dateLayout))
+}
+
+// checkAssignmentTarget 校验目标机构允许账号归属，职位存在且属于目标机构
+func checkAssignmentTarget(tx *gorm.DB, organizationId, positionId uint) error {
+	var org model.Organization
+	if err := tx.Where("id = ?", organizationId).First(&org).Error; err != nil {
+		return fmt.Errorf("机构 %d 不存在或已删除", organizationId)
+	}
+	if err := org.CheckAccounts(); err != nil {
+		return err
+	}
+	var position model.Position
+	if err := tx.Where("id = ?", positionId).First(&position).Error; err != nil {
+		return fmt.Errorf("职位 %d 不存在或已删除", positionId)
+	}
+	if position.OrganizationId != organizationId {
+		return fmt.Errorf("职位 %s 不属于机构 %s", position.Name, org.Name)
+	}
+	return nil
+}
+
+// recordAssignment 结束账号当前的任职记录并从 date 开始新的任职记录
+func recordAssignment(tx *gorm.DB, assignment *model.AccountAssignment) error {
+	var current model.AccountAssignment
+	err := tx.Where("account_id = ? AND end_date IS NULL", assignment.AccountId).Order("id DESC").First(&current).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil {
+		// 不允许早于当前任职的开始日期，否则历史记录会重叠
+		if compareDate(assignment.StartDate, current.StartDate) < 0 {
+			return fmt.Errorf("生效日期不能早于当前任职的开始日期 %s", current.StartDate.Format(dateLayout))
+		}
+		// 同一天内多次调整只保留最后一次
+		if compareDate(assignment.StartDate, current.StartDate) == 0 {
+			return tx.Model(&current).Select("organization_id", "position_id", "transfer_id", "reason").Updates(assignment).Error
+		}
+		if err := tx.Model(&model.AccountAssignment{}).Where("account_id = ? AND end_date IS NULL", assignment.AccountId).
+			Update("end_date", assignment.StartDate.Format(dateLayout)).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(assignment).Error
+}
+
+// applyTransfer 在事务中执行调动：更新账号的机构和职位，记录任职历史，标记调动已生效
+func applyTransfer(tx *gorm.DB, transfer *model.AccountTransfer) error {
+	var account model.Account
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transfer.AccountId).First(&account).Error; err != nil {
+		return fmt.Errorf("账号 %d 不存在或已删除", transfer.AccountId)
+	}
+	if err := checkAssignmentTarget(tx, transfer.ToOrganizationId, transfer.ToPositionId); err != nil {
+		return err
+	}
+	if account.PositionId != transfer.ToPositionId && !account.IsLeave {
+		if err := checkPositionQuota(tx, transfer.ToPositionId, 1); err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&model.Account{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+		"organization_id": transfer.ToOrganizationId,
+		"position_id":     transfer.ToPositionId,
+	}).Error; err != nil {
+		return err
+	}
+	if err := recordAssignment(tx, &model.AccountAssignment{
+		AccountId:      account.ID,
+		OrganizationId: transfer.ToOrganizationId,
+		PositionId:     transfer.ToPositionId,
+		StartDate:      transfer.EffectiveDate,
+		TransferId:     transfer.ID,
+		Reason:         transfer.Reason,
+	}); err != nil {
+		return err
+	}
+	now := time.Now()
+	transfer.FromOrganizationId = account.OrganizationId
+	transfer.FromPositionId = account.PositionId
+	transfer.Status = model.TransferStatusApplied
+	transfer.AppliedAt = &now
+	return tx.Model(transfer).Select("from_organization_id", "from_position_id", "status", "applied_at").Updates(transfer).Error
+}
+
+// Transfer 调动账号，生效日期不晚于当天时立即生效，否则等待后台任务在生效日期生效
+func (l *AccountLogic) Transfer(c *gin.Context, id types.SearchId, req *types2.AccountTransferReq) (*model.AccountTransfer, error) {
+	if req.EffectiveDate.IsZero() {
+		return nil, fmt.Errorf("生效日期不能为空")
+	}
+	account, err := l.writableAccount(c, id.Id)
+	if err != nil {
+		return nil, err
+	}
+	if account.IsLeave {
+		return nil, fmt.Errorf("账号已离职，不能调动")
+	}
+	if account.OrganizationId == req.OrganizationId && account.PositionId == req.PositionId {
+		return nil, fmt.Errorf("目标机构和职位与当前相同")
+	}
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	if err := scope.checkOrganization(req.OrganizationId); err != nil {
+		return nil, err
+	}
+	if err := checkAssignmentTarget(l.db.WithContext(c), req.OrganizationId, req.PositionId); err != nil {
+		return nil, err
+	}
+	transfer := &model.AccountTransfer{
+		AccountId:        account.ID,
+		ToOrganizationId: req.OrganizationId,
+		ToPositionId:     req.PositionId,
+		EffectiveDate:    req.EffectiveDate,
+		Status:           model.TransferStatusPending,
+		Reason:           req.Reason,
+	}
+	if claims, err := utils.GetClaims(c); err == nil {
+		transfer.OperatorId = claims.AccountId
+		transfer.Operator = claims.Account
+	}
+	err = l.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+		if compareDate(transfer.EffectiveDate, today()) > 0 {
+			return nil
+		}
+		return applyTransfer(tx, transfer)
+	})
+	if err != nil {
+		l.l.Error(fmt.Sprintf("调动账号失败: %s", err.Error()))
+		return nil, fmt.Errorf("调动账号失败: %s", err.Error())
+	}
+	return transfer, nil
+}
+
+// Transfers 查询账号的调动记录
+func (l *AccountLogic) Transfers(c *gin.Context, id types.SearchId, query types2.AccountTransferQueryReq) (*types.QueryResponse, error) {
+	if _, err := l.visibleAccount(c, id.Id); err != nil {
+		return nil, err
+	}
+	var list []*model.AccountTransfer
+	db := l.db.WithContext(c).Model(&model.AccountTransfer{}).Where("account_id = ?", id.Id)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	db = db.Order(fmt.Sprintf("effective_date %s, id %s", query.Sort, query.Sort))
+	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询调动记录失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询调动记录失败")
+	}
+	return queryRes, nil
+}
+
+// CancelTransfer 取消尚未生效的调动
+func (l *AccountLogic) CancelTransfer(c *gin.Context, req types2.AccountTransferCancelReq) error {
+	if _, err := l.writableAccount(c, req.Id); err != nil {
+		return err
+	}
+	result := l.db.WithContext(c).Model(&model.AccountTransfer{}).
+		Where("id = ? AND account_id = ? AND status = ?", req.TransferId, req.Id, model.TransferStatusPending).
+		Update("status", model.TransferStatusCanceled)
+	if result.Error != nil {
+		l.l.Error(fmt.Sprintf("取消调动失败: %s", result.Error.Error()))
+		return fmt.Errorf("取消调动失败")
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("调动不存在或已生效")
+	}
+	return nil
+}
+
+// Assignments 查询账号的任职历史，按开始日期倒序
+func (l *AccountLogic) Assignments(c *gin.Context, id types.SearchId) ([]*model.AccountAssignment, error) {
+	if _, err := l.visibleAccount(c, id.Id); err != nil {
+		return nil, err
+	}
+	var list []*model.AccountAssignment
+	if err := l.db.WithContext(c).Model(&model.AccountAssignment{}).
+		Select(assignmentTable+".*, p.name AS position_name").
+		Joins("LEFT JOIN ikubexjob_user_position p ON p.id = "+assignmentTable+".position_id").
+		Where(assignmentTable+".account_id = ?", id.Id).
+		Order(assignmentTable + ".start_date DESC, " + assignmentTable + ".id DESC").
+		Find(&list).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询任职记录失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询任职记录失败")
+	}
+	orgs, err := loadOrganizationPaths(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询机构失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询机构失败")
+	}
+	for _, a := range list {
+		a.TreeName = orgs.paths[a.OrganizationId]
+	}
+	return list, nil
+}
+
+// ApplyDueTransfers 执行生效日期已到的调动，单个调动失败时标记为失败并继续处理其他调动
+func (l *AccountLogic) ApplyDueTransfers(ctx context.Context) error {
+	var ids []uint
+	if err := l.db.WithContext(ctx).Model(&model.AccountTransfer{}).
+		Where("status = ? AND effective_date <= ?", model.TransferStatusPending, today().Format(dateLayout)).
+		Order("effective_date, id").Limit(transferBatchSize).Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("查询待生效调动失败: %w", err)
+	}
+	for _, id := range ids {
+		err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// 多个实例同时执行时只有一个实例能锁定待生效的调动
+			var transfer model.AccountTransfer
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND status = ?", id, model.TransferStatusPending).First(&transfer).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			return applyTransfer(tx, &transfer)
+		})
+		if err == nil {
+			continue
+		}
+		l.l.Error(fmt.Sprintf("调动 %d 生效失败: %s", id, err.Error()))
+		msg := []rune(err.Error())
+		if len(msg) > transferErrorLimit {
+			msg = msg[:transferErrorLimit]
+		}
+		if err := l.db.WithContext(ctx).Model(&model.AccountTransfer{}).
+			Where("id = ? AND status = ?", id, model.TransferStatusPending).
+			Updates(map[string]interface{}{"status": model.TransferStatusFailed, "error": string(msg)}).Error; err != nil {
+			l.l.Error(fmt.Sprintf("更新调动 %d 状态失败: %s", id, err.Error()))
+		}
+	}
+	return nil
+}
+
+// Members 查询指定日期在机构任职的账号，日期为空时查询当天
+func (o *OrganizationLogic) Members(c *gin.Context, id types.SearchId, query types2.OrganizationMembersQueryReq) (*types.QueryResponse, error) {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.checkOrganization(id.Id); err != nil {
+		return nil, err
+	}
+	date := query.Date
+	if date == "" {
+		date = today().Format(dateLayout)
+	}
+	orgIds := []uint{id.Id}
+	if query.IncludeSubOrganizations {
+		if orgIds, err = organizationDescendantIds(c, o.db, id.Id); err != nil {
+			o.l.Error(fmt.Sprintf("查询下级机构失败: %s", err.Error()))
+			return nil, fmt.Errorf("查询下级机构失败")
+		}
+	}
+	members := o.db.Model(&model.AccountAssignment{}).Select("account_id").
+		Where("organization_id IN ? AND start_date <= ? AND (end_date IS NULL OR end_date > ?)", orgIds, date, date)
+	var list []*model.Account
+	db := accountWithNames(o.db.WithContext(c)).Where(accountTable+".id IN (?)", members).
+		Order(fmt.Sprintf("%s.id %s", accountTable, query.Sort))
+	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
+	if err != nil {
+		o.l.Error(fmt.Sprintf("查询机构成员失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询机构成员失败")
+	}
+	return queryRes, nil
+}
+
+// accountTransferJob 执行生效日期已到的调动
+type accountTransferJob struct{}
+
+func (j *accountTransferJob) Name() string {
+	return fmt.Sprintf("%s.%s.transfer", apps.AppName, apps.AppAccount)
+}
+
+func (j *accountTransferJob) Interval() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *accountTransferJob) Run(ctx context.Context) error {
+	return accountLogic.ApplyDueTransfers(ctx)
+}
+
+func init() {
+	router.RegistryJob(&accountTransferJob{})
+}
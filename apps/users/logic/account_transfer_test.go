@@ -0,0 +1,199 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	commonModel "github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// transferStep 依次执行的调动，days 为生效日期距今天的天数
+type transferStep struct {
+	days     int
+	position string
+	wantErr  string
+}
+
+// wantAssignment 期望的任职记录，days 为距今天的天数，endDays 为 nil 表示当前任职
+type wantAssignment struct {
+	position  string
+	startDays int
+	endDays   *int
+}
+
+func daysFromToday(days int) commonModel.DateTime {
+	return commonModel.DateTime{Time: today().AddDate(0, 0, days)}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func (f *restructureFixture) positionsByName() map[string]*model.Position {
+	return map[string]*model.Position{"rdEng": f.rdEng, "rdPm": f.rdPm, "prodEng": f.prodEng}
+}
+
+func (f *restructureFixture) account(t *testing.T, name string) *model.Account {
+	var account model.Account
+	require.NoError(t, f.db.Where("account = ?", name).First(&account).Error)
+	return &account
+}
+
+// newTransfer 创建调动并在同一事务内生效，失败时整体回滚
+func newTransfer(db *gorm.DB, account *model.Account, position *model.Position, date commonModel.DateTime) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		transfer := &model.AccountTransfer{
+			AccountId:        account.ID,
+			ToOrganizationId: position.OrganizationId,
+			ToPositionId:     position.ID,
+			EffectiveDate:    date,
+			Status:           model.TransferStatusPending,
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+		return applyTransfer(tx, transfer)
+	})
+}
+
+func TestApplyTransfer(t *testing.T) {
+	tests := []struct {
+		name        string
+		quota       int
+		leave       bool
+		steps       []transferStep
+		wantPos     string
+		assignments []wantAssignment
+	}{
+		{
+			name:    "结束当前任职并开始新的任职",
+			steps:   []transferStep{{days: 0, position: "prodEng"}},
+			wantPos: "prodEng",
+			assignments: []wantAssignment{
+				{position: "rdEng", startDays: -365, endDays: intPtr(0)},
+				{position: "prodEng", startDays: 0},
+			},
+		},
+		{
+			name:    "同一天多次调动只保留最后一次",
+			steps:   []transferStep{{days: 0, position: "prodEng"}, {days: 0, position: "rdPm"}},
+			wantPos: "rdPm",
+			assignments: []wantAssignment{
+				{position: "rdEng", startDays: -365, endDays: intPtr(0)},
+				{position: "rdPm", startDays: 0},
+			},
+		},
+		{
+			name: "生效日期早于当前任职的开始日期",
+			steps: []transferStep{
+				{days: 0, position: "prodEng"},
+				{days: -1, position: "rdPm", wantErr: "生效日期不能早于当前任职的开始日期"},
+			},
+			wantPos: "prodEng",
+			assignments: []wantAssignment{
+				{position: "rdEng", startDays: -365, endDays: intPtr(0)},
+				{position: "prodEng", startDays: 0},
+			},
+		},
+		{
+			name:    "目标职位编制已满",
+			quota:   1,
+			steps:   []transferStep{{days: 0, position: "prodEng", wantErr: errPositionQuota.Error()}},
+			wantPos: "rdEng",
+			assignments: []wantAssignment{
+				{position: "rdEng", startDays: -365},
+			},
+		},
+		{
+			name:    "离职账号不占用编制",
+			quota:   1,
+			leave:   true,
+			steps:   []transferStep{{days: 0, position: "prodEng"}},
+			wantPos: "prodEng",
+			assignments: []wantAssignment{
+				{position: "rdEng", startDays: -365, endDays: intPtr(0)},
+				{position: "prodEng", startDays: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newRestructureFixture(t, tt.quota)
+			positions := f.positionsByName()
+			account := f.account(t, "rd-eng-1")
+			// 入职日期为一年前，按天对齐
+			require.NoError(t, f.db.Model(&model.AccountAssignment{}).Where("account_id = ?", account.ID).
+				Update("start_date", daysFromToday(-365).Format(dateLayout)).Error)
+			if tt.leave {
+				require.NoError(t, f.db.Model(account).Update("is_leave", true).Error)
+			}
+			for i, step := range tt.steps {
+				err := newTransfer(f.db, account, positions[step.position], daysFromToday(step.days))
+				if step.wantErr != "" {
+					require.Error(t, err, "第 %d 次调动", i+1)
+					assert.Contains(t, err.Error(), step.wantErr)
+					continue
+				}
+				require.NoError(t, err, "第 %d 次调动", i+1)
+			}
+
+			current := f.account(t, "rd-eng-1")
+			want := positions[tt.wantPos]
+			assert.Equal(t, [2]uint{want.OrganizationId, want.ID}, [2]uint{current.OrganizationId, current.PositionId}, "账号当前的机构和职位")
+
+			var assignments []*model.AccountAssignment
+			require.NoError(t, f.db.Where("account_id = ?", account.ID).Order("id").Find(&assignments).Error)
+			require.Len(t, assignments, len(tt.assignments), "任职记录数量")
+			for i, w := range tt.assignments {
+				got := assignments[i]
+				p := positions[w.position]
+				assert.Equal(t, [2]uint{p.OrganizationId, p.ID}, [2]uint{got.OrganizationId, got.PositionId}, "第 %d 条任职记录的机构和职位", i+1)
+				assert.Zero(t, compareDate(daysFromToday(w.startDays), got.StartDate), "第 %d 条任职记录的开始日期", i+1)
+				if w.endDays == nil {
+					assert.True(t, got.EndDate.IsZero(), "第 %d 条任职记录应为当前任职", i+1)
+				} else {
+					assert.Zero(t, compareDate(daysFromToday(*w.endDays), got.EndDate), "第 %d 条任职记录的结束日期", i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyDueTransfers(t *testing.T) {
+	f := newRestructureFixture(t, 1)
+	l := &AccountLogic{l: zap.NewNop(), db: f.db}
+	pending := func(name string, position *model.Position, days int) *model.AccountTransfer {
+		transfer := &model.AccountTransfer{
+			AccountId:        f.account(t, name).ID,
+			ToOrganizationId: position.OrganizationId,
+			ToPositionId:     position.ID,
+			EffectiveDate:    daysFromToday(days),
+			Status:           model.TransferStatusPending,
+		}
+		require.NoError(t, f.db.Create(transfer).Error)
+		return transfer
+	}
+	due := pending("rd-pm", f.rdEng, -1)
+	full := pending("rd-eng-1", f.prodEng, 0) // 产品部工程师编制 1 人已满
+	future := pending("rd-eng-2", f.prodEng, 1)
+
+	require.NoError(t, l.ApplyDueTransfers(context.Background()))
+
+	status := func(transfer *model.AccountTransfer) *model.AccountTransfer {
+		var current model.AccountTransfer
+		require.NoError(t, f.db.Where("id = ?", transfer.ID).First(&current).Error)
+		return &current
+	}
+	assert.Equal(t, model.TransferStatusApplied, status(due).Status, "生效日期已到的调动生效")
+	assert.Equal(t, f.rdEng.ID, f.account(t, "rd-pm").PositionId)
+	failed := status(full)
+	assert.Equal(t, model.TransferStatusFailed, failed.Status, "编制已满的调动标记为失败")
+	assert.Contains(t, failed.Error, errPositionQuota.Error(), "记录失败原因")
+	assert.Equal(t, f.rdEng.ID, f.account(t, "rd-eng-1").PositionId, "失败的调动不修改账号")
+	assert.Equal(t, model.TransferStatusPending, status(future).Status, "未到生效日期的调动保持待生效")
+}
@@ -0,0 +1,191 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	upmsLogic "github.com/yanshicheng/ikube-gin-xjob/apps/upms/logic"
+	upmsModel "github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"gorm.io/gorm"
+)
+
+// dataScopeKey 当前请求的数据权限缓存在 gin.Context 中的键
+const dataScopeKey = "dataScope"
+
+var errOutOfDataScope = fmt.Errorf("无权操作该数据，超出数据权限范围")
+
+// dataScope 当前账号可见的数据范围，由令牌中全部角色的数据权限取并集得到
+// 账号本人始终可见，保证个人中心等接口不受数据权限影响
+type dataScope struct {
+	all             bool
+	accountId       uint
+	positionId      uint
+	organizationId  uint
+	organizationIds []uint // 可见的机构，不包含仅因本人可见的机构
+	organization    bool   // 拥有本机构数据权限的角色
+	children        bool   // 拥有本机构及下级机构数据权限的角色
+}
+
+// loadDataScope 按令牌中的角色计算数据权限，同一请求内只计算一次
+func loadDataScope(c *gin.Context, db *gorm.DB) (*dataScope, error) {
+	if value, ok := c.Get(dataScopeKey); ok {
+		if scope, ok := value.(*dataScope); ok {
+			return scope, nil
+		}
+	}
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	var account model.Account
+	if err := db.WithContext(c).Session(&gorm.Session{SkipHooks: true}).Select("id", "organization_id", "position_id").
+		Where("id = ?", claims.AccountId).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("查询当前账号失败: %w", err)
+	}
+	scope := &dataScope{accountId: account.ID, positionId: account.PositionId, organizationId: account.OrganizationId}
+	var roles []*upmsModel.Role
+	if len(claims.Application.Role) > 0 {
+		if err := db.WithContext(c).Where("name IN ?", claims.Application.Role).Find(&roles).Error; err != nil {
+			return nil, fmt.Errorf("查询角色数据权限失败: %w", err)
+		}
+	}
+	organizations := map[uint]bool{}
+	addOrganizations := func(ids ...uint) {
+		for _, id := range ids {
+			organizations[id] = true
+		}
+	}
+	for _, role := range roles {
+		switch role.DataScope {
+		case upmsModel.DataScopeAll:
+			scope.all = true
+		case upmsModel.DataScopeOrganization:
+			scope.organization = true
+			addOrganizations(account.OrganizationId)
+		case upmsModel.DataScopeOrganizationAndChildren:
+			scope.children = true
+			ids, err := organizationDescendantIds(c, db, account.OrganizationId)
+			if err != nil {
+				return nil, fmt.Errorf("查询下级机构失败: %w", err)
+			}
+			addOrganizations(ids...)
+		case upmsModel.DataScopeCustom:
+			addOrganizations(role.OrganizationIds...)
+		}
+	}
+	for id := range organizations {
+		scope.organizationIds = append(scope.organizationIds, id)
+	}
+	c.Set(dataScopeKey, scope)
+	return scope, nil
+}
+
+// hasOrganization 判断机构是否在数据权限范围内
+func (s *dataScope) hasOrganization(id uint) bool {
+	if s.all {
+		return true
+	}
+	for _, orgId := range s.organizationIds {
+		if orgId == id {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleOrganization 判断机构是否可见，与 Organizations 的查询范围一致
+func (s *dataScope) visibleOrganization(id uint) bool {
+	return id == s.organizationId || s.hasOrganization(id)
+}
+
+// Accounts 限制账号读操作范围，可见机构下的账号以及本人
+func (s *dataScope) Accounts(db *gorm.DB) *gorm.DB {
+	if s.all {
+		return db
+	}
+	if len(s.organizationIds) == 0 {
+		return db.Where(accountTable+".id = ?", s.accountId)
+	}
+	return db.Where(fmt.Sprintf("(%s.organization_id IN ? OR %s.id = ?)", accountTable, accountTable), s.organizationIds, s.accountId)
+}
+
+// WritableAccounts 限制账号写操作范围，只包含可见机构下的账号，本人不因本人可见而可写，修改本人信息使用个人中心
+func (s *dataScope) WritableAccounts(db *gorm.DB) *gorm.DB {
+	if s.all {
+		return db
+	}
+	if len(s.organizationIds) == 0 {
+		return db.Where("1 = 0")
+	}
+	return db.Where(accountTable+".organization_id IN ?", s.organizationIds)
+}
+
+// Positions 限制职位查询范围，可见机构下的职位以及本人的职位
+func (s *dataScope) Positions(db *gorm.DB) *gorm.DB {
+	if s.all {
+		return db
+	}
+	table := (&model.Position{}).TableName()
+	if len(s.organizationIds) == 0 {
+		return db.Where(table+".id = ?", s.positionId)
+	}
+	return db.Where(fmt.Sprintf("(%s.organization_id IN ? OR %s.id = ?)", table, table), s.organizationIds, s.positionId)
+}
+
+// Organizations 限制机构查询范围，可见机构以及本人所在机构
+func (s *dataScope) Organizations(db *gorm.DB) *gorm.DB {
+	if s.all {
+		return db
+	}
+	table := (&model.Organization{}).TableName()
+	return db.Where(table+".id IN ?", append([]uint{s.organizationId}, s.organizationIds...))
+}
+
+// coversRole 判断角色的数据权限是否不超过当前账号，授予他人角色时使用，避免借助角色扩大数据权限
+// 本机构、本机构及下级机构按成员自身的机构计算，当前账号需要拥有同等或更大的相对权限
+func (s *dataScope) coversRole(role *upmsModel.Role) bool {
+	if s.all {
+		return true
+	}
+	switch role.DataScope {
+	case upmsModel.DataScopeSelf:
+		return true
+	case upmsModel.DataScopeOrganization:
+		return s.organization || s.children
+	case upmsModel.DataScopeOrganizationAndChildren:
+		return s.children
+	case upmsModel.DataScopeCustom:
+		for _, id := range role.OrganizationIds {
+			if !s.hasOrganization(id) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// checkOrganization 写操作的目标机构必须在数据权限范围内，本人所在机构不因本人可见而可写
+func (s *dataScope) checkOrganization(id uint) error {
+	if !s.hasOrganization(id) {
+		return errOutOfDataScope
+	}
+	return nil
+}
+
+// checkRoleScope 创建、修改角色时角色的数据权限不能超过当前账号
+func checkRoleScope(c *gin.Context, db *gorm.DB, role *upmsModel.Role) error {
+	scope, err := loadDataScope(c, db)
+	if err != nil {
+		return err
+	}
+	if !scope.coversRole(role) {
+		return fmt.Errorf("角色 %s 的数据权限超出当前账号的数据权限范围", role.Name)
+	}
+	return nil
+}
+
+func init() {
+	upmsLogic.RegistryRoleScopeChecker(checkRoleScope)
+}
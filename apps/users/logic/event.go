@@ -0,0 +1,155 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/service"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/sql"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"time"
+)
+
+var _ service.SecurityEventService = (*SecurityEventLogic)(nil)
+
+var securityEventLogic = &SecurityEventLogic{}
+
+// 每次清理删除的最大行数，避免长时间锁表
+const securityEventCleanupBatch = 1000
+
+type SecurityEventLogic struct {
+	l  *zap.Logger
+	db *gorm.DB
+}
+
+// Record 记录安全事件，写入失败只记录日志，不影响业务流程
+func (l *SecurityEventLogic) Record(c *gin.Context, accountId uint, account, eventType string, success bool, reason string) {
+	event := &model.SecurityEvent{
+		AccountId: accountId,
+		Account:   account,
+		Type:      eventType,
+		Success:   success,
+		Reason:    reason,
+		Ip:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	// 登录失败时账号来自用户输入，按字段长度截断，按字符截断避免切开多字节字符
+	event.Account = truncateRunes(event.Account, 32)
+	event.UserAgent = truncateRunes(event.UserAgent, 255)
+	if err := l.db.WithContext(c).Create(event).Error; err != nil {
+		l.l.Error(fmt.Sprintf("记录安全事件失败: %s, 事件: %+v", err.Error(), event))
+	}
+}
+
+// List 查询数据权限范围内账号的安全事件，不属于任何账号的事件只有全部数据权限可见
+func (l *SecurityEventLogic) List(c *gin.Context, query types2.SecurityEventQueryReq) (*types.QueryResponse, error) {
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	var list []*model.SecurityEvent
+	db := l.db.WithContext(c).Model(&model.SecurityEvent{})
+	if !scope.all {
+		db = db.Where("account_id IN (?)", scope.Accounts(l.db.Model(&model.Account{}).Select(accountTable+".id")))
+	}
+	// 设置排序
+	db = db.Order(fmt.Sprintf("%s %s", "ID", query.Sort))
+	if query.AccountId != 0 {
+		db = db.Where("account_id = ?", query.AccountId)
+	}
+	if query.Account != "" {
+		db = db.Where("account = ?", query.Account)
+	}
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.Ip != "" {
+		db = db.Where("ip = ?", query.Ip)
+	}
+	if query.Success != nil {
+		db = db.Where("success = ?", *query.Success)
+	}
+	if query.StartTime != nil {
+		db = db.Where("created_at >= ?", query.StartTime)
+	}
+	if query.EndTime != nil {
+		db = db.Where("created_at <= ?", query.EndTime)
+	}
+	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询安全事件失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询安全事件失败")
+	}
+	return queryRes, nil
+}
+
+// Cleanup 按保留天数物理删除过期的安全事件
+func (l *SecurityEventLogic) Cleanup(ctx context.Context) error {
+	days := global.C.Audit.RetentionDays
+	if days <= 0 {
+		return nil
+	}
+	before := time.Now().AddDate(0, 0, -days)
+	var total int64
+	for {
+		result := l.db.WithContext(ctx).Unscoped().Where("created_at < ?", before).Limit(securityEventCleanupBatch).Delete(&model.SecurityEvent{})
+		if result.Error != nil {
+			return result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < securityEventCleanupBatch {
+			break
+		}
+	}
+	if total > 0 {
+		l.l.Info(fmt.Sprintf("清理 %d 天前的安全事件 %d 条", days, total))
+	}
+	return nil
+}
+
+// Config 只需要保证 全局对象Config和全局Logger已经加载完成
+func (l *SecurityEventLogic) Config() {
+	l.l = global.L.Named(apps.AppName).Named(apps.AppEvent).Named("logic")
+	l.db = global.DB.GetDb()
+}
+
+func (l *SecurityEventLogic) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppEvent)
+}
+
+// securityEventCleanupJob 安全事件保留期清理任务
+type securityEventCleanupJob struct{}
+
+func (j *securityEventCleanupJob) Name() string {
+	return fmt.Sprintf("%s.%s.cleanup", apps.AppName, apps.AppEvent)
+}
+
+func (j *securityEventCleanupJob) Interval() time.Duration {
+	return time.Hour
+}
+
+func (j *securityEventCleanupJob) Run(ctx context.Context) error {
+	return securityEventLogic.Cleanup(ctx)
+}
+
+func init() {
+	// 注册
+	router.RegistryLogic(securityEventLogic)
+	router.RegistryJob(&securityEventCleanupJob{})
+}
+
+// truncateRunes 截取前 n 个字符
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,406 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	upmsModel "github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/service"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/sql"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ service.GroupService = (*GroupLogic)(nil)
+
+var groupLogic = &GroupLogic{}
+
+// GroupLogic 用户组，成员的角色在下次登录或刷新令牌时生效
+type GroupLogic struct {
+	l  *zap.Logger
+	db *gorm.DB
+}
+
+func (g *GroupLogic) List(c *gin.Context, query types2.GroupQueryReq) (*types.QueryResponse, error) {
+	var list []*model.Group
+	db := g.db.WithContext(c).Model(&model.Group{}).Order(fmt.Sprintf("id %s", query.Sort))
+	if query.Name != "" {
+		db = db.Where("name LIKE ?", "%"+escapeLike(query.Name)+"%")
+	}
+	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
+	if err != nil {
+		g.l.Error(fmt.Sprintf("查询用户组失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询用户组失败")
+	}
+	return queryRes, nil
+}
+
+func (g *GroupLogic) Create(c *gin.Context, req *model.Group) error {
+	if err := g.db.WithContext(c).Create(req).Error; err != nil {
+		g.l.Error(fmt.Sprintf("创建用户组失败: %s", err.Error()))
+		return fmt.Errorf("创建用户组失败")
+	}
+	return nil
+}
+
+func (g *GroupLogic) Put(c *gin.Context, id types.SearchId, req *model.Group) (*model.Group, error) {
+	group, err := g.group(c, id.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.db.WithContext(c).Model(group).Updates(map[string]interface{}{"name": req.Name, "desc": req.Desc}).Error; err != nil {
+		g.l.Error(fmt.Sprintf("修改用户组失败: %s", err.Error()))
+		return nil, fmt.Errorf("修改用户组失败")
+	}
+	return g.group(c, id.Id)
+}
+
+// Delete 删除用户组，成员、下级用户组和角色保留，从回收站恢复后重新生效
+func (g *GroupLogic) Delete(c *gin.Context, id types.SearchId) error {
+	result := g.db.WithContext(c).Where("id = ?", id.Id).Delete(&model.Group{})
+	if result.Error != nil {
+		g.l.Error(fmt.Sprintf("删除用户组失败: %s", result.Error.Error()))
+		return fmt.Errorf("删除用户组失败")
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("删除用户组失败: 用户组不存在")
+	}
+	return nil
+}
+
+// Accounts 分页查询用户组在数据权限范围内的成员，indirect 为 true 时包含全部下级用户组的成员
+func (g *GroupLogic) Accounts(c *gin.Context, id types.SearchId, query types2.GroupAccountQueryReq) (*types.QueryResponse, error) {
+	if _, err := g.group(c, id.Id); err != nil {
+		return nil, err
+	}
+	scope, err := loadDataScope(c, g.db)
+	if err != nil {
+		g.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	groupIds := []uint{id.Id}
+	if query.Indirect {
+		if groupIds, err = groupDescendantIds(c, g.db, id.Id); err != nil {
+			g.l.Error(fmt.Sprintf("查询下级用户组失败: %s", err.Error()))
+			return nil, fmt.Errorf("查询下级用户组失败")
+		}
+	}
+	members := g.db.Model(&model.GroupAccount{}).Select("account_id").Where("group_id IN ?", groupIds)
+	var list []*model.Account
+	db := accountWithNames(g.db.WithContext(c)).Scopes(scope.Accounts).
+		Where(accountTable+".id IN (?)", members).Order(fmt.Sprintf("%s.id %s", accountTable, query.Sort))
+	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
+	if err != nil {
+		g.l.Error(fmt.Sprintf("查询用户组成员失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询用户组成员失败")
+	}
+	return queryRes, nil
+}
+
+// AddAccounts 把数据权限范围内的账号加入用户组，已经是成员的账号忽略
+func (g *GroupLogic) AddAccounts(c *gin.Context, id types.SearchId, req *types2.GroupAccountReq) error {
+	if _, err := g.group(c, id.Id); err != nil {
+		return err
+	}
+	ids, err := g.writableAccountIds(c, req.AccountIds)
+	if err != nil {
+		return err
+	}
+	err = g.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		if err := tx.Model(&model.GroupAccount{}).Where("group_id = ?", id.Id).Pluck("account_id", &existing).Error; err != nil {
+			return err
+		}
+		for _, accountId := range ids {
+			if containsId(existing, accountId) {
+				continue
+			}
+			if err := tx.Create(&model.GroupAccount{GroupId: id.Id, AccountId: accountId}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		g.l.Error(fmt.Sprintf("添加用户组成员失败, id: %d, error: %s", id.Id, err.Error()))
+		return fmt.Errorf("添加用户组成员失败")
+	}
+	return nil
+}
+
+// RemoveAccounts 从用户组移除数据权限范围内的账号
+func (g *GroupLogic) RemoveAccounts(c *gin.Context, id types.SearchId, req *types2.GroupAccountReq) error {
+	if _, err := g.group(c, id.Id); err != nil {
+		return err
+	}
+	ids, err := g.writableAccountIds(c, req.AccountIds)
+	if err != nil {
+		return err
+	}
+	if err := g.db.WithContext(c).Where("group_id = ? AND account_id IN ?", id.Id, ids).Delete(&model.GroupAccount{}).Error; err != nil {
+		g.l.Error(fmt.Sprintf("移除用户组成员失败, id: %d, error: %s", id.Id, err.Error()))
+		return fmt.Errorf("移除用户组成员失败")
+	}
+	return nil
+}
+
+// Children 查询用户组的直接下级用户组
+func (g *GroupLogic) Children(c *gin.Context, id types.SearchId) ([]*model.Group, error) {
+	if _, err := g.group(c, id.Id); err != nil {
+		return nil, err
+	}
+	list := []*model.Group{}
+	childIds := g.db.Model(&model.GroupChild{}).Select("child_id").Where("group_id = ?", id.Id)
+	if err := g.db.WithContext(c).Where("id IN (?)", childIds).Order("id").Find(&list).Error; err != nil {
+		g.l.Error(fmt.Sprintf("查询下级用户组失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询下级用户组失败")
+	}
+	return list, nil
+}
+
+// SetChildren 设置用户组的下级用户组，覆盖原有的下级用户组，不能包含自身或者任意上级用户组
+func (g *GroupLogic) SetChildren(c *gin.Context, id types.SearchId, req *types2.GroupChildReq) ([]*model.Group, error) {
+	ids := make([]uint, 0, len(req.GroupIds))
+	for _, childId := range req.GroupIds {
+		if childId == id.Id {
+			return nil, fmt.Errorf("用户组不能包含自身")
+		}
+		if !containsId(ids, childId) {
+			ids = append(ids, childId)
+		}
+	}
+	err := g.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// 锁定用户组表，避免并发设置形成环
+		var groups []*model.Group
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&groups).Error; err != nil {
+			return fmt.Errorf("查询用户组失败")
+		}
+		names := make(map[uint]string, len(groups))
+		for _, group := range groups {
+			names[group.ID] = group.Name
+		}
+		if _, ok := names[id.Id]; !ok {
+			return fmt.Errorf("用户组不存在")
+		}
+		for _, childId := range ids {
+			if _, ok := names[childId]; !ok {
+				return fmt.Errorf("下级用户组 %d 不存在", childId)
+			}
+		}
+		// 已删除用户组的关系同样参与环检测，否则从回收站恢复后可能形成环
+		var edges []*model.GroupChild
+		if err := tx.Where("group_id <> ?", id.Id).Find(&edges).Error; err != nil {
+			return fmt.Errorf("查询下级用户组失败")
+		}
+		children := make(map[uint][]uint)
+		for _, edge := range edges {
+			children[edge.GroupId] = append(children[edge.GroupId], edge.ChildId)
+		}
+		for _, childId := range ids {
+			if reachable(children, childId, id.Id) {
+				return fmt.Errorf("用户组 %s 已经包含 %s，不能形成环", names[childId], names[id.Id])
+			}
+		}
+		remove := tx.Where("group_id = ?", id.Id)
+		if len(ids) > 0 {
+			remove = remove.Where("child_id NOT IN ?", ids)
+		}
+		if err := remove.Delete(&model.GroupChild{}).Error; err != nil {
+			return err
+		}
+		var existing []uint
+		if err := tx.Model(&model.GroupChild{}).Where("group_id = ?", id.Id).Pluck("child_id", &existing).Error; err != nil {
+			return err
+		}
+		for _, childId := range ids {
+			if containsId(existing, childId) {
+				continue
+			}
+			if err := tx.Create(&model.GroupChild{GroupId: id.Id, ChildId: childId}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		g.l.Error(fmt.Sprintf("设置下级用户组失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("设置下级用户组失败: %w", err)
+	}
+	return g.Children(c, id)
+}
+
+// Roles 查询用户组绑定的角色
+func (g *GroupLogic) Roles(c *gin.Context, id types.SearchId) ([]*upmsModel.Role, error) {
+	if _, err := g.group(c, id.Id); err != nil {
+		return nil, err
+	}
+	list := []*upmsModel.Role{}
+	roleIds := g.db.Model(&model.GroupRole{}).Select("role_id").Where("group_id = ?", id.Id)
+	if err := g.db.WithContext(c).Where("id IN (?)", roleIds).Order("id").Find(&list).Error; err != nil {
+		g.l.Error(fmt.Sprintf("查询用户组角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询用户组角色失败")
+	}
+	return list, nil
+}
+
+// SetRoles 设置用户组绑定的角色，覆盖原有的角色
+func (g *GroupLogic) SetRoles(c *gin.Context, id types.SearchId, req *types2.GroupRoleReq) ([]*upmsModel.Role, error) {
+	if _, err := g.group(c, id.Id); err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(req.RoleIds))
+	for _, roleId := range req.RoleIds {
+		if !containsId(ids, roleId) {
+			ids = append(ids, roleId)
+		}
+	}
+	if len(ids) > 0 {
+		var roles []*upmsModel.Role
+		if err := g.db.WithContext(c).Where("id IN ?", ids).Find(&roles).Error; err != nil {
+			g.l.Error(fmt.Sprintf("查询角色失败: %s", err.Error()))
+			return nil, fmt.Errorf("查询角色失败")
+		}
+		if len(roles) != len(ids) {
+			return nil, fmt.Errorf("角色不存在或已删除")
+		}
+		// 用户组的成员获得绑定的角色，不能授予数据权限超过当前账号的角色
+		scope, err := loadDataScope(c, g.db)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			if !scope.coversRole(role) {
+				return nil, fmt.Errorf("角色 %s 的数据权限超出当前账号的数据权限范围", role.Name)
+			}
+		}
+	}
+	err := g.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		remove := tx.Where("group_id = ?", id.Id)
+		if len(ids) > 0 {
+			remove = remove.Where("role_id NOT IN ?", ids)
+		}
+		if err := remove.Delete(&model.GroupRole{}).Error; err != nil {
+			return err
+		}
+		var existing []uint
+		if err := tx.Model(&model.GroupRole{}).Where("group_id = ?", id.Id).Pluck("role_id", &existing).Error; err != nil {
+			return err
+		}
+		for _, roleId := range ids {
+			if containsId(existing, roleId) {
+				continue
+			}
+			if err := tx.Create(&model.GroupRole{GroupId: id.Id, RoleId: roleId}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		g.l.Error(fmt.Sprintf("设置用户组角色失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("设置用户组角色失败")
+	}
+	g.l.Info(fmt.Sprintf("设置用户组角色, id: %d, roleIds: %v", id.Id, ids))
+	return g.Roles(c, id)
+}
+
+func (g *GroupLogic) group(c *gin.Context, id uint) (*model.Group, error) {
+	var group model.Group
+	if err := g.db.WithContext(c).Where("id = ?", id).First(&group).Error; err != nil {
+		g.l.Error(fmt.Sprintf("查询用户组失败, id: %d, error: %s", id, err.Error()))
+		return nil, fmt.Errorf("用户组不存在")
+	}
+	return &group, nil
+}
+
+// writableAccountIds 去重后校验账号全部在数据权限的写范围内，本人不能把自己加入或移出用户组
+func (g *GroupLogic) writableAccountIds(c *gin.Context, accountIds []uint) ([]uint, error) {
+	scope, err := loadDataScope(c, g.db)
+	if err != nil {
+		g.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	ids := make([]uint, 0, len(accountIds))
+	for _, accountId := range accountIds {
+		if !containsId(ids, accountId) {
+			ids = append(ids, accountId)
+		}
+	}
+	var count int64
+	if err := g.db.WithContext(c).Model(&model.Account{}).Scopes(scope.WritableAccounts).
+		Where(accountTable+".id IN ?", ids).Count(&count).Error; err != nil {
+		g.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询账号失败")
+	}
+	if int(count) != len(ids) {
+		return nil, fmt.Errorf("账号不存在或超出数据权限范围")
+	}
+	return ids, nil
+}
+
+// reachable 判断沿下级关系能否从 from 到达 to
+func reachable(children map[uint][]uint, from, to uint) bool {
+	visited := map[uint]bool{from: true}
+	for stack := []uint{from}; len(stack) > 0; {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == to {
+			return true
+		}
+		for _, child := range children[id] {
+			if !visited[child] {
+				visited[child] = true
+				stack = append(stack, child)
+			}
+		}
+	}
+	return false
+}
+
+// groupDescendantIds 查询用户组自身及其全部下级用户组，已删除的用户组不展开
+func groupDescendantIds(ctx context.Context, db *gorm.DB, id uint) ([]uint, error) {
+	groupTable := (&model.Group{}).TableName()
+	groupChildTable := (&model.GroupChild{}).TableName()
+	ids := []uint{id}
+	visited := map[uint]bool{id: true}
+	for frontier := ids; len(frontier) > 0; {
+		var children []uint
+		if err := db.WithContext(ctx).Model(&model.GroupChild{}).
+			Joins(fmt.Sprintf("JOIN %s g ON g.id = %s.child_id AND g.deleted_at = 0", groupTable, groupChildTable)).
+			Where(groupChildTable+".group_id IN ?", frontier).
+			Pluck(groupChildTable+".child_id", &children).Error; err != nil {
+			return nil, err
+		}
+		frontier = nil
+		for _, child := range children {
+			if !visited[child] {
+				visited[child] = true
+				ids = append(ids, child)
+				frontier = append(frontier, child)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Config 只需要保证 全局对象Config和全局Logger已经加载完成
+func (g *GroupLogic) Config() {
+	g.l = global.L.Named(apps.AppName).Named(apps.AppGroup).Named("logic")
+	g.db = global.DB.GetDb()
+}
+
+func (g *GroupLogic) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppGroup)
+}
+
+func init() {
+	// 注册
+	router.RegistryLogic(groupLogic)
+}
@@ -1,16 +1,20 @@
 package logic
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
 	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
 	"github.com/yanshicheng/ikube-gin-xjob/apps/users/service"
 	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
 	"github.com/yanshicheng/ikube-gin-xjob/common/types"
 	"github.com/yanshicheng/ikube-gin-xjob/global"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"sort"
+	"strings"
 )
 
 // 接口检查
@@ -25,154 +29,98 @@ type OrganizationLogic struct {
 
 func (o *OrganizationLogic) Get(c *gin.Context, search types2.OrganizationGetSearchReq) ([]*model.Organization, error) {
 	o.l.Info(fmt.Sprintf("查询机构信息, name: %s", search.Name))
-	var orsSlice []model.Organization
-	if err := o.db.WithContext(c).Where("name like ?", search.Name+"%").Find(&orsSlice).Error; err != nil {
-		o.l.Error(fmt.Sprintf("查询机构信息失败, id: %s, error: %s", search.Name, err.Error()))
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := o.snapshot(c)
+	if err != nil {
 		return nil, err
 	}
+	// 按名称前缀匹配，每个匹配的机构返回以顶层机构为根、只包含当前分支的树
+	prefix := strings.ToLower(search.Name)
 	resultOrgSlice := make([]*model.Organization, 0)
-	for _, org := range orsSlice {
-		resultOrg, err := o.buildSingleParent(&org)
-		if err != nil {
-			o.l.Error(fmt.Sprintf("构建父级层级结构失败, id: %d, error: %s", org.ID, err.Error()))
-			return nil, err
+	for _, org := range snapshot.Orgs {
+		if scope.visibleOrganization(org.ID) && strings.HasPrefix(strings.ToLower(org.Name), prefix) {
+			resultOrgSlice = append(resultOrgSlice, snapshot.branch(org.ID))
 		}
-		resultOrgSlice = append(resultOrgSlice, resultOrg)
 	}
 	return resultOrgSlice, nil
 }
 
-// 递归获某个节点的所有父节点
-func (o *OrganizationLogic) buildSingleParent(org *model.Organization) (*model.Organization, error) {
-	if org.ParentId == 0 {
-		return org, nil // 如果没有父级，返回当前组织
-	}
-
-	var parent model.Organization
-	if err := o.db.Where("id = ?", org.ParentId).First(&parent).Error; err != nil {
-		return nil, err
-	}
-	// 将当前组织添加到父级的 Children 中
-	parent.Children = append(parent.Children, org)
-	// 判断是不是根节点
-	if parent.ParentId == 0 {
-		return &parent, nil
-	}
-	// 递归构建父级结构
-	parentOrg, err := o.buildSingleParent(&parent)
+// organizationDescendantIds 从机构缓存中按祖先路径取出机构自身及其全部下级机构的 ID
+func organizationDescendantIds(ctx context.Context, db *gorm.DB, id uint) ([]uint, error) {
+	snapshot, err := orgCache.get(ctx, db)
 	if err != nil {
 		return nil, err
 	}
-
-	return parentOrg, nil
+	return snapshot.descendantIds(id), nil
 }
 
-// 递归获某个节点的所有父节点
-func (o *OrganizationLogic) buildParentHierarchy(org *model.Organization, orgSlice []*model.Organization) ([]*model.Organization, error) {
-	// 先判断是否存在列表中
-	args, ok := o.recursiveAdd(org, orgSlice)
-	if ok {
-		return args, nil
+// List 返回数据权限范围内的机构树和机构树的版本号
+func (o *OrganizationLogic) List(c *gin.Context, search types2.OrganizationGetSearchReq) ([]*model.Organization, int64, error) {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, 0, err
 	}
-
-	var parent model.Organization
-	if err := o.db.Where("id = ?", org.ParentId).First(&parent).Error; err != nil {
-		return nil, err
+	snapshot, err := o.snapshot(c)
+	if err != nil {
+		return nil, 0, err
 	}
-	// 将当前组织添加到父级的 Children 中
-	parent.Children = append(parent.Children, org)
-	args, ok = o.recursiveAdd(&parent, orgSlice)
-	if ok {
-		return args, nil
-	} else {
-		orgSlice, err := o.buildParentHierarchy(&parent, orgSlice)
-		if err != nil {
-			return nil, err
+	orgs := snapshot.clone(func(org *model.Organization) bool { return scope.visibleOrganization(org.ID) })
+	// 上级机构不在数据权限范围内的机构作为根节点
+	if search.Name == "" {
+		t := tree.Build(orgs, nil)
+		if ids := t.CycleIds(); len(ids) > 0 {
+			o.l.Warn(fmt.Sprintf("机构的上级关系存在环，在机构 %v 处断开后作为根节点返回", ids))
 		}
-		return orgSlice, nil
+		return t.Forest(), snapshot.Version, nil
 	}
+	// 按名称前缀匹配，同时保留匹配机构的上级机构
+	prefix := strings.ToLower(search.Name)
+	return tree.Search(orgs, func(org *model.Organization) bool {
+		return strings.HasPrefix(strings.ToLower(org.Name), prefix)
+	}, nil), snapshot.Version, nil
 }
 
-// 递归添加节点
-func (o *OrganizationLogic) recursiveAdd(org *model.Organization, orgSlice []*model.Organization) ([]*model.Organization, bool) {
-	// 判断当前是否为主节点
-	if org.ParentId == 0 {
-		for _, og := range orgSlice {
-			if og.ID == org.ID {
-				// 如果已经存在，则跳过
-				return orgSlice, true
-			}
-		}
-		// 如果不存在，则添加
-		orgSlice = append(orgSlice, org)
-		return orgSlice, true
-	}
-	// 当前节点为子节点
-	for _, og := range orgSlice {
-		if og.ID == org.ParentId {
-			// 如果已经存在，则跳过
-			og.Children = append(og.Children, org)
-			return orgSlice, true
-		}
-		// 如果当前节点存在子节点，则递归添加
-		if og.Children != nil {
-			o.recursiveAdd(org, og.Children)
-		}
+// Version 返回机构树的版本号，版本号未变化时客户端不需要重新加载机构树
+func (o *OrganizationLogic) Version(c *gin.Context) (*types2.OrganizationVersionResp, error) {
+	snapshot, err := o.snapshot(c)
+	if err != nil {
+		return nil, err
 	}
-	// 如果都不满足，则返回false
-	return orgSlice, false
+	return &types2.OrganizationVersionResp{Version: snapshot.Version}, nil
 }
-func (o *OrganizationLogic) List(c *gin.Context, search types2.OrganizationGetSearchReq) ([]*model.Organization, error) {
-	if search.Name == "" {
-		var allOrgSlice []model.Organization
-		if err := o.db.WithContext(c).Find(&allOrgSlice).Error; err != nil {
-			o.l.Error(fmt.Sprintf("查询机构信息失败, error: %s", err.Error()))
-			return nil, fmt.Errorf("查询机构信息失败")
-		}
-		orgMap := make(map[uint]*model.Organization, len(allOrgSlice))
-		for i := range allOrgSlice {
-			orgMap[allOrgSlice[i].ID] = &allOrgSlice[i]
-		}
-		// 构建树形结构
-		var orgTree []*model.Organization
-		for i := range allOrgSlice {
-			org := &allOrgSlice[i]
-			if org.ParentId != 0 {
-				if parent, ok := orgMap[org.ParentId]; ok {
-					if parent.Children == nil {
-						parent.Children = make([]*model.Organization, 0)
-					}
-					parent.Children = append(parent.Children, org)
-				}
-			} else {
-				orgTree = append(orgTree, org)
-			}
-		}
-		return orgTree, nil
-	} else {
-		var orgSlice []model.Organization
-		if err := o.db.WithContext(c).Where("name like ?", search.Name+"%").Find(&orgSlice).Error; err != nil {
-			o.l.Error(fmt.Sprintf("查询机构信息失败, id: %s, error: %s", search.Name, err.Error()))
-			return nil, err
-		}
-		o.l.Debug(fmt.Sprintf("查询机构信息: %+v, %d", orgSlice, len(orgSlice)))
-		var resultOrgSlice []*model.Organization
-		for _, org := range orgSlice {
-			o.l.Debug(fmt.Sprintf("查询机构信息org: %+v", org))
-			resOrgSlice, err := o.buildParentHierarchy(&org, resultOrgSlice)
-			if err != nil {
-				o.l.Error(fmt.Sprintf("构建父级层级结构失败, id: %d, error: %s", org.ID, err.Error()))
-				return nil, err
-			}
-			resultOrgSlice = make([]*model.Organization, len(resOrgSlice))
-			copy(resultOrgSlice, resOrgSlice)
-		}
-		o.l.Debug(fmt.Sprintf("查询机构信息resultOrg: %+v", resultOrgSlice))
-		return resultOrgSlice, nil
+
+// Types 返回配置的机构类型规则，按类型排序
+func (o *OrganizationLogic) Types(c *gin.Context) []*types2.OrganizationTypeResp {
+	list := make([]*types2.OrganizationTypeResp, 0, len(global.C.Organization.Types))
+	for name := range global.C.Organization.Types {
+		org := &model.Organization{Type: name}
+		rule, _ := org.TypeRule()
+		list = append(list, &types2.OrganizationTypeResp{
+			Type:      name,
+			Title:     rule.Title,
+			Root:      rule.Root,
+			Parents:   rule.Parents,
+			MaxLevel:  org.MaxLevel(),
+			Positions: rule.Positions,
+			Accounts:  rule.Accounts,
+		})
 	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Type < list[j].Type })
+	return list
 }
 
 func (o *OrganizationLogic) Put(c *gin.Context, id types.SearchId, org *model.Organization) (*model.Organization, error) {
+	// 机构和调整后的上级机构都必须在数据权限范围内
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.checkOrganization(id.Id); err != nil {
+		return nil, err
+	}
 	// 先查询出来
 	var oldOrg model.Organization
 	if err := o.db.WithContext(c).Where("id = ?", id.Id).First(&oldOrg).Error; err != nil {
@@ -185,41 +133,115 @@ func (o *OrganizationLogic) Put(c *gin.Context, id types.SearchId, org *model.Or
 			o.l.Error(fmt.Sprintf("主节点不允许修改 ParentId, id: %d", id.Id))
 			return nil, fmt.Errorf("主节点不允许修改 ParentId")
 		}
+	} else if org.ParentId == 0 {
+		return nil, fmt.Errorf("不允许将机构修改为主节点")
 	}
-	// 判断是否存在子节点
-	var childOrgSlice []model.Organization
-	if err := o.db.WithContext(c).Where("parent_id = ?", id.Id).Find(&childOrgSlice).Error; err != nil {
-		o.l.Error(fmt.Sprintf("查询子节点信息失败, id: %d, error: %s", id.Id, err.Error()))
-		return nil, fmt.Errorf("查询子节点信息失败")
-	}
-	if len(childOrgSlice) > 0 {
-		// 如果存在子节点，则不允许修改 ParentId
-		if org.ParentId != oldOrg.ParentId {
-			o.l.Error(fmt.Sprintf("存在子节点不允许修改 ParentId, id: %d", id.Id))
-			return nil, fmt.Errorf("存在子节点不允许修改 ParentId")
+	moved := org.ParentId != oldOrg.ParentId
+	if moved {
+		if err := scope.checkOrganization(org.ParentId); err != nil {
+			return nil, err
 		}
 	}
-	// 修改操作
-	oldOrg.Desc = org.Desc
-	oldOrg.Name = org.Name
-	oldOrg.ParentId = org.ParentId
-	// 保存
-	if err := o.db.WithContext(c).Save(&oldOrg).Error; err != nil {
+	// 先修改类型再移动，移动时按新的类型校验上级机构和层级
+	// 修改上级机构时整棵子树一起移动
+	err = o.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if org.Type != "" && org.Type != oldOrg.Type {
+			if err := o.changeType(tx, &oldOrg, org.Type, !moved); err != nil {
+				return err
+			}
+		}
+		if moved {
+			if _, err := o.move(c, tx, id.Id, org.ParentId); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.Organization{}).Where("id = ?", id.Id).
+			Updates(map[string]interface{}{"name": org.Name, "desc": org.Desc}).Error
+	})
+	if err != nil {
 		o.l.Error(fmt.Sprintf("更新机构信息失败, id: %d, error: %s", id.Id, err.Error()))
-		return nil, fmt.Errorf("更新机构信息失败")
+		return nil, fmt.Errorf("更新机构信息失败: %w", err)
+	}
+	orgCache.invalidate(c)
+	var updated model.Organization
+	if err := o.db.WithContext(c).Where("id = ?", id.Id).First(&updated).Error; err != nil {
+		o.l.Error(fmt.Sprintf("查询机构信息失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("查询机构信息失败")
 	}
-	return &oldOrg, nil
+	return &updated, nil
+}
+
+// changeType 修改机构类型，新类型必须允许当前的上级机构、下级机构、层级以及机构下已有的职位和账号
+// 同时修改上级机构时由移动校验上级机构和层级
+func (o *OrganizationLogic) changeType(tx *gorm.DB, org *model.Organization, typ string, checkParent bool) error {
+	changed := *org
+	changed.Type = typ
+	if checkParent {
+		var parent *model.Organization
+		if org.ParentId != 0 {
+			parent = &model.Organization{}
+			if err := tx.Where("id = ?", org.ParentId).First(parent).Error; err != nil {
+				return fmt.Errorf("查询上级机构失败")
+			}
+		}
+		if err := changed.CheckParent(parent); err != nil {
+			return err
+		}
+		if changed.Level > changed.MaxLevel() {
+			return fmt.Errorf("层级不能超过 %d 级", changed.MaxLevel())
+		}
+	}
+	var children []*model.Organization
+	if err := tx.Where("parent_id = ?", org.ID).Find(&children).Error; err != nil {
+		return fmt.Errorf("查询下级机构失败")
+	}
+	for _, child := range children {
+		if err := child.CheckParent(&changed); err != nil {
+			return err
+		}
+	}
+	// 新类型不允许职位或账号时，机构下不能已有职位或账号
+	var positions, accounts int64
+	if err := tx.Model(&model.Position{}).Where("organization_id = ?", org.ID).Count(&positions).Error; err != nil {
+		return fmt.Errorf("查询机构职位失败")
+	}
+	if err := changed.CheckPositions(); err != nil && positions > 0 {
+		return fmt.Errorf("机构下存在职位，%w", err)
+	}
+	if err := tx.Model(&model.Account{}).Where("organization_id = ?", org.ID).Count(&accounts).Error; err != nil {
+		return fmt.Errorf("查询机构账号失败")
+	}
+	if err := changed.CheckAccounts(); err != nil && accounts > 0 {
+		return fmt.Errorf("机构下存在账号，%w", err)
+	}
+	return tx.Model(&model.Organization{}).Where("id = ?", org.ID).Update("type", typ).Error
 }
 
 func (o *OrganizationLogic) Create(c *gin.Context, req *model.Organization) error {
+	// 只能在数据权限范围内的机构下创建，创建主体机构需要全部数据权限
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return err
+	}
+	if err := scope.checkOrganization(req.ParentId); err != nil {
+		return err
+	}
 	if err := o.db.WithContext(c).Create(req).Error; err != nil {
 		o.l.Error(fmt.Sprintf("创建机构信息失败, error: %s", err.Error()))
 		return err
 	}
+	orgCache.invalidate(c)
 	return nil
 }
 
 func (o *OrganizationLogic) Delete(c *gin.Context, id types.SearchId) error {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return err
+	}
+	if err := scope.checkOrganization(id.Id); err != nil {
+		return err
+	}
 	// 删除机构首先查询出来
 	var org model.Organization
 	if err := o.db.WithContext(c).Where("id = ?", id.Id).First(&org).Error; err != nil {
@@ -256,9 +278,65 @@ func (o *OrganizationLogic) Delete(c *gin.Context, id types.SearchId) error {
 		o.l.Error(fmt.Sprintf("删除机构信息失败, id: %d, error: %s", id.Id, result.Error.Error()))
 		return fmt.Errorf("删除机构信息失败,职位不存在")
 	}
+	orgCache.invalidate(c)
 	return nil
 }
 
+// Stats 统计机构的下级机构数量和账号数量，子树按祖先路径前缀匹配
+func (o *OrganizationLogic) Stats(c *gin.Context, id types.SearchId) (*types2.OrganizationStatsResp, error) {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.checkOrganization(id.Id); err != nil {
+		return nil, err
+	}
+	var org model.Organization
+	if err := o.db.WithContext(c).Where("id = ?", id.Id).First(&org).Error; err != nil {
+		o.l.Error(fmt.Sprintf("查询机构信息失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("查询机构信息失败")
+	}
+	snapshot, err := o.snapshot(c)
+	if err != nil {
+		return nil, err
+	}
+	resp := &types2.OrganizationStatsResp{OrganizationId: org.ID, TreeName: snapshot.paths.paths[org.ID]}
+	if err := o.db.WithContext(c).Model(&model.Organization{}).Where("path LIKE ?", org.SubtreePath()+"%").
+		Count(&resp.DescendantCount).Error; err != nil {
+		o.l.Error(fmt.Sprintf("统计下级机构失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("统计下级机构失败")
+	}
+	orgTable := (&model.Organization{}).TableName()
+	err = o.db.WithContext(c).Model(&model.Account{}).
+		Select(fmt.Sprintf("COUNT(*) AS subtree_account_count, COALESCE(SUM(%s.organization_id = ?), 0) AS account_count", accountTable), org.ID).
+		Joins(fmt.Sprintf("JOIN %s o ON o.id = %s.organization_id AND o.deleted_at = 0", orgTable, accountTable)).
+		Where("o.id = ? OR o.path LIKE ?", org.ID, org.SubtreePath()+"%").
+		Scan(resp).Error
+	if err != nil {
+		o.l.Error(fmt.Sprintf("统计机构账号失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("统计机构账号失败")
+	}
+	return resp, nil
+}
+
+func (o *OrganizationLogic) snapshot(c *gin.Context) (*organizationSnapshot, error) {
+	snapshot, err := orgCache.get(c, o.db)
+	if err != nil {
+		o.l.Error(fmt.Sprintf("查询机构信息失败, error: %s", err.Error()))
+		return nil, fmt.Errorf("查询机构信息失败")
+	}
+	return snapshot, nil
+}
+
+func (o *OrganizationLogic) dataScope(c *gin.Context) (*dataScope, error) {
+	scope, err := loadDataScope(c, o.db)
+	if err != nil {
+		o.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	return scope, nil
+}
+
 // Config 只需要保证 全局对象Config和全局Logger已经加载完成
 func (o *OrganizationLogic) Config() {
 	o.l = global.L.Named(apps.AppName).Named(apps.AppOrganization).Named("logic")
@@ -0,0 +1,311 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	commonModel "github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 机构树缓存分为进程内和 redis 两层，机构变更后递增 redis 中的版本号并删除快照，
+// 再通过发布订阅通知全部实例丢弃进程内的快照，未启用 redis 时只有进程内缓存
+const (
+	organizationCacheKey   = "ikubexjob:organization:tree"    // 机构快照
+	organizationVersionKey = "ikubexjob:organization:version" // 机构版本号，每次变更加一
+	organizationChannel    = "ikubexjob:organization:changed" // 变更通知，消息为新的版本号
+)
+
+// organizationSnapshot 某一版本的全部机构，快照中的机构只读，组装树形结构前需要复制
+type organizationSnapshot struct {
+	Version int64                 `json:"version"`
+	Orgs    []*model.Organization `json:"orgs"` // 按层级和 ID 排序
+	byId    map[uint]*model.Organization
+	paths   *organizationPaths
+}
+
+func newOrganizationSnapshot(version int64, orgs []*model.Organization) *organizationSnapshot {
+	s := &organizationSnapshot{Version: version, Orgs: orgs}
+	s.index()
+	return s
+}
+
+// index 建立 ID 索引和名称路径，从 redis 反序列化后也需要调用
+func (s *organizationSnapshot) index() {
+	s.byId = make(map[uint]*model.Organization, len(s.Orgs))
+	for _, org := range s.Orgs {
+		s.byId[org.ID] = org
+	}
+	s.paths = newOrganizationPaths(s.clone(nil))
+}
+
+// clone 复制满足条件的机构，match 为空时复制全部机构
+func (s *organizationSnapshot) clone(match func(*model.Organization) bool) []*model.Organization {
+	orgs := make([]*model.Organization, 0, len(s.Orgs))
+	for _, org := range s.Orgs {
+		if match == nil || match(org) {
+			copied := *org
+			copied.Children = nil
+			orgs = append(orgs, &copied)
+		}
+	}
+	return orgs
+}
+
+// branch 返回以顶层机构为根、只包含机构自身及其上级机构的树
+func (s *organizationSnapshot) branch(id uint) *model.Organization {
+	ids := map[uint]bool{id: true}
+	for _, ancestorId := range s.byId[id].AncestorIds() {
+		ids[ancestorId] = true
+	}
+	return tree.Build(s.clone(func(org *model.Organization) bool { return ids[org.ID] }), nil).Path(id)[0]
+}
+
+// descendantIds 机构自身及其全部下级机构的 ID
+func (s *organizationSnapshot) descendantIds(id uint) []uint {
+	ids := []uint{id}
+	org, ok := s.byId[id]
+	if !ok {
+		return ids
+	}
+	prefix := org.SubtreePath()
+	for _, o := range s.Orgs {
+		if strings.HasPrefix(o.Path, prefix) {
+			ids = append(ids, o.ID)
+		}
+	}
+	return ids
+}
+
+type organizationCache struct {
+	mu         sync.RWMutex
+	snapshot   *organizationSnapshot
+	generation uint64 // 每次丢弃快照时加一，加载期间发生变更时不保存加载结果
+	version    int64  // 未启用 redis 时的版本号
+	listen     sync.Once
+	group      singleflight.Group
+}
+
+var orgCache = &organizationCache{}
+
+func (c *organizationCache) logger() *zap.Logger {
+	return global.L.Named(apps.AppName).Named(apps.AppOrganization).Named("cache")
+}
+
+func (c *organizationCache) rdb() *redis.Client {
+	if global.RDB == nil {
+		return nil
+	}
+	return global.RDB.GetClient()
+}
+
+// get 返回当前的机构快照，未启用缓存时每次从数据库加载
+func (c *organizationCache) get(ctx context.Context, db *gorm.DB) (*organizationSnapshot, error) {
+	if !global.C.OrgCache.Enable {
+		return c.load(ctx, db)
+	}
+	c.listen.Do(c.subscribe)
+	c.mu.RLock()
+	s, generation := c.snapshot, c.generation
+	c.mu.RUnlock()
+	if s != nil {
+		return s, nil
+	}
+	// 同一时刻只加载一次，加载不受发起请求的取消影响
+	value, err, _ := c.group.Do("snapshot", func() (interface{}, error) {
+		s, err := c.load(context.WithoutCancel(ctx), db)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		if c.generation == generation {
+			c.snapshot = s
+		}
+		c.mu.Unlock()
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*organizationSnapshot), nil
+}
+
+// load 优先读取 redis 中的快照，不存在时从数据库加载并写入 redis
+func (c *organizationCache) load(ctx context.Context, db *gorm.DB) (*organizationSnapshot, error) {
+	rdb := c.rdb()
+	if rdb != nil && global.C.OrgCache.Enable {
+		data, err := rdb.Get(ctx, organizationCacheKey).Bytes()
+		if err == nil {
+			var s organizationSnapshot
+			if err := json.Unmarshal(data, &s); err == nil {
+				s.index()
+				return &s, nil
+			}
+		} else if !errors.Is(err, redis.Nil) {
+			c.logger().Warn(fmt.Sprintf("读取机构缓存失败，从数据库加载: %s", err.Error()))
+		}
+	}
+	// 先读取版本号再查询数据库，查询期间发生变更时版本号不一致，不会写入旧数据
+	version, err := c.currentVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询机构版本号失败: %w", err)
+	}
+	var orgs []*model.Organization
+	if err := db.WithContext(ctx).Order("level, id").Find(&orgs).Error; err != nil {
+		return nil, err
+	}
+	s := newOrganizationSnapshot(version, orgs)
+	if rdb != nil && global.C.OrgCache.Enable {
+		if err := c.save(ctx, rdb, s); err != nil {
+			c.logger().Warn(fmt.Sprintf("写入机构缓存失败: %s", err.Error()))
+		}
+	}
+	return s, nil
+}
+
+// save 版本号未变化时写入快照
+func (c *organizationCache) save(ctx context.Context, rdb *redis.Client, s *organizationSnapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	ttl := time.Duration(global.C.OrgCache.TTL) * time.Minute
+	err = rdb.Watch(ctx, func(tx *redis.Tx) error {
+		version, err := tx.Get(ctx, organizationVersionKey).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if version != s.Version {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return pipe.Set(ctx, organizationCacheKey, data, ttl).Err()
+		})
+		return err
+	}, organizationVersionKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return nil
+	}
+	return err
+}
+
+// currentVersion 查询最新的版本号
+func (c *organizationCache) currentVersion(ctx context.Context) (int64, error) {
+	rdb := c.rdb()
+	if rdb == nil {
+		return atomic.LoadInt64(&c.version), nil
+	}
+	version, err := rdb.Get(ctx, organizationVersionKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return version, err
+}
+
+// invalidate 机构变更后调用，递增版本号并通知全部实例丢弃快照，失败时只记录日志，快照最迟在有效期后更新
+func (c *organizationCache) invalidate(ctx context.Context) {
+	c.drop()
+	rdb := c.rdb()
+	if rdb == nil {
+		atomic.AddInt64(&c.version, 1)
+		return
+	}
+	version, err := rdb.Incr(ctx, organizationVersionKey).Result()
+	if err != nil {
+		c.logger().Error(fmt.Sprintf("更新机构版本号失败: %s", err.Error()))
+		return
+	}
+	if err := rdb.Del(ctx, organizationCacheKey).Err(); err != nil {
+		c.logger().Error(fmt.Sprintf("删除机构缓存失败: %s", err.Error()))
+	}
+	if err := rdb.Publish(ctx, organizationChannel, version).Err(); err != nil {
+		c.logger().Error(fmt.Sprintf("发布机构变更通知失败: %s", err.Error()))
+	}
+	// 递增版本号之前本实例可能已经加载了新数据但记录的是旧版本号
+	c.expire(version)
+}
+
+// drop 丢弃进程内的快照
+func (c *organizationCache) drop() {
+	c.mu.Lock()
+	c.snapshot = nil
+	c.generation++
+	c.mu.Unlock()
+}
+
+// expire 进程内快照的版本号与最新版本号不一致时丢弃
+func (c *organizationCache) expire(version int64) {
+	c.mu.RLock()
+	stale := c.snapshot != nil && c.snapshot.Version != version
+	c.mu.RUnlock()
+	if stale {
+		c.drop()
+	}
+}
+
+// subscribe 订阅其他实例的变更通知，连接断开时由客户端自动重连，期间丢失的通知由定时任务补偿
+func (c *organizationCache) subscribe() {
+	rdb := c.rdb()
+	if rdb == nil {
+		return
+	}
+	pubsub := rdb.Subscribe(context.Background(), organizationChannel)
+	go func() {
+		for msg := range pubsub.Channel() {
+			version, err := strconv.ParseInt(msg.Payload, 10, 64)
+			if err != nil {
+				c.logger().Warn(fmt.Sprintf("机构变更通知格式错误: %s", msg.Payload))
+				continue
+			}
+			c.expire(version)
+		}
+	}()
+}
+
+// organizationCacheSyncJob 定时核对版本号，补偿订阅断开期间丢失的变更通知
+type organizationCacheSyncJob struct{}
+
+func (j *organizationCacheSyncJob) Name() string {
+	return fmt.Sprintf("%s.%s.cache", apps.AppName, apps.AppOrganization)
+}
+
+func (j *organizationCacheSyncJob) Interval() time.Duration {
+	if global.C.OrgCache.SyncInterval <= 0 {
+		return time.Minute
+	}
+	return time.Duration(global.C.OrgCache.SyncInterval) * time.Second
+}
+
+func (j *organizationCacheSyncJob) Run(ctx context.Context) error {
+	if !global.C.OrgCache.Enable || global.RDB == nil {
+		return nil
+	}
+	version, err := orgCache.currentVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("查询机构版本号失败: %w", err)
+	}
+	orgCache.expire(version)
+	return nil
+}
+
+func init() {
+	router.RegistryJob(&organizationCacheSyncJob{})
+	// 回收站恢复机构后同样需要刷新缓存
+	if r, ok := commonModel.GetRecyclable("organization"); ok {
+		r.Restored = orgCache.invalidate
+	}
+}
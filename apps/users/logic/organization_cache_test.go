@@ -0,0 +1,114 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	ikubeRedis "github.com/yanshicheng/ikube-gin-xjob/pkg/redis"
+)
+
+// useOrganizationCache 开启机构缓存并替换为新的缓存实例，withRedis 为 true 时使用 miniredis
+func useOrganizationCache(t *testing.T, withRedis bool) {
+	enable, rdb, cache := global.C.OrgCache.Enable, global.RDB, orgCache
+	t.Cleanup(func() {
+		global.C.OrgCache.Enable, global.RDB, orgCache = enable, rdb, cache
+	})
+	global.C.OrgCache.Enable = true
+	global.RDB = nil
+	orgCache = &organizationCache{}
+	if withRedis {
+		mr := miniredis.RunT(t)
+		client, err := ikubeRedis.InitIkubeRedis(mr.Addr(), "", 0, 4)
+		require.NoError(t, err)
+		t.Cleanup(func() { client.GetClient().Close() })
+		global.RDB = client
+	}
+}
+
+func cachedPath(t *testing.T, cache *organizationCache, f *restructureFixture, id uint) string {
+	s, err := cache.get(context.Background(), f.db)
+	require.NoError(t, err)
+	return s.paths.paths[id]
+}
+
+func TestOrganizationCacheInvalidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, f *restructureFixture)
+		check func(t *testing.T, f *restructureFixture, s *organizationSnapshot)
+	}{
+		{
+			name: "移动",
+			write: func(t *testing.T, f *restructureFixture) {
+				_, err := f.logic.Move(newTestContext(), types.SearchId{Id: f.backend.ID}, &types2.OrganizationMoveReq{ParentId: f.product.ID})
+				require.NoError(t, err)
+			},
+			check: func(t *testing.T, f *restructureFixture, s *organizationSnapshot) {
+				assert.Equal(t, "公司/产品部/后端组", s.paths.paths[f.backend.ID])
+				assert.Equal(t, f.product.ID, s.byId[f.backend.ID].ParentId)
+			},
+		},
+		{
+			name: "合并",
+			write: func(t *testing.T, f *restructureFixture) {
+				_, err := f.logic.Merge(newTestContext(), types.SearchId{Id: f.rd.ID}, &types2.OrganizationRestructureReq{TargetId: f.product.ID})
+				require.NoError(t, err)
+			},
+			check: func(t *testing.T, f *restructureFixture, s *organizationSnapshot) {
+				assert.Equal(t, "公司/产品部/后端组", s.paths.paths[f.backend.ID])
+				assert.NotContains(t, s.byId, f.rd.ID, "被合并的机构不再出现在缓存中")
+			},
+		},
+		{
+			name: "修改名称和类型",
+			write: func(t *testing.T, f *restructureFixture) {
+				_, err := f.logic.Put(newTestContext(), types.SearchId{Id: f.backend.ID}, &model.Organization{
+					Name: "平台组", Type: model.OrganizationTypeTeam, ParentId: f.rd.ID,
+				})
+				require.NoError(t, err)
+			},
+			check: func(t *testing.T, f *restructureFixture, s *organizationSnapshot) {
+				assert.Equal(t, "公司/研发部/平台组", s.paths.paths[f.backend.ID])
+				assert.Equal(t, model.OrganizationTypeTeam, s.byId[f.backend.ID].Type)
+			},
+		},
+	}
+	for _, mode := range []struct {
+		name      string
+		withRedis bool
+	}{{"进程内缓存", false}, {"redis 缓存", true}} {
+		for _, tt := range tests {
+			t.Run(mode.name+"/"+tt.name, func(t *testing.T) {
+				useOrganizationCache(t, mode.withRedis)
+				f := newRestructureFixture(t, 0)
+				// 预热缓存，redis 模式下另一个实例同样缓存了旧的快照
+				other := &organizationCache{}
+				assert.Equal(t, "公司/研发部/后端组", cachedPath(t, orgCache, f, f.backend.ID))
+				if mode.withRedis {
+					assert.Equal(t, "公司/研发部/后端组", cachedPath(t, other, f, f.backend.ID))
+				}
+
+				tt.write(t, f)
+
+				s, err := orgCache.get(context.Background(), f.db)
+				require.NoError(t, err)
+				tt.check(t, f, s)
+				if mode.withRedis {
+					// 其他实例通过变更通知丢弃进程内的快照
+					want := s.paths.paths[f.backend.ID]
+					assert.Eventually(t, func() bool {
+						return cachedPath(t, other, f, f.backend.ID) == want
+					}, time.Second, 10*time.Millisecond, "其他实例收到变更通知后重新加载")
+				}
+			})
+		}
+	}
+}
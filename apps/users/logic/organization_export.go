@@ -0,0 +1,297 @@
+package logic
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// orgChart 导出的机构树以及每个机构的职位和账号姓名
+type orgChart struct {
+	roots     []*model.Organization
+	paths     map[uint]string
+	positions map[uint][]string
+	accounts  func(organizationId uint) ([]string, error) // 写入时逐个机构查询账号姓名，为空时不导出账号
+}
+
+// isRoot 导出范围内的根节点，上级机构即使存在也不输出连线
+func (o *orgChart) isRoot(org *model.Organization) bool {
+	for _, root := range o.roots {
+		if root.ID == org.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// walk 先序遍历机构树
+func (o *orgChart) walk(fn func(org *model.Organization) error) error {
+	for _, org := range tree.Flatten(o.roots) {
+		if err := fn(org); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export 导出机构树，机构范围与机构列表一致，指定机构时只导出以该机构为根的子树
+func (o *OrganizationLogic) Export(c *gin.Context, req types2.OrganizationExportReq) (*types2.OrganizationExportResp, error) {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := o.snapshot(c)
+	if err != nil {
+		return nil, err
+	}
+	orgs := snapshot.clone(func(org *model.Organization) bool { return scope.visibleOrganization(org.ID) })
+	t := tree.Build(orgs, nil)
+	chart := &orgChart{roots: t.Forest(), paths: snapshot.paths.paths}
+	if req.OrganizationId != 0 {
+		root, ok := t.Get(req.OrganizationId)
+		if !ok {
+			return nil, fmt.Errorf("机构不存在或超出数据权限范围")
+		}
+		chart.roots = []*model.Organization{root}
+	}
+	var ids []uint
+	_ = chart.walk(func(org *model.Organization) error {
+		ids = append(ids, org.ID)
+		return nil
+	})
+	if req.Positions && len(ids) > 0 {
+		var positions []*model.Position
+		if err := o.db.WithContext(c).Select("id", "name", "organization_id").Where("organization_id IN ?", ids).
+			Order("id").Find(&positions).Error; err != nil {
+			o.l.Error(fmt.Sprintf("查询职位失败: %s", err.Error()))
+			return nil, fmt.Errorf("查询职位失败")
+		}
+		chart.positions = make(map[uint][]string)
+		for _, p := range positions {
+			chart.positions[p.OrganizationId] = append(chart.positions[p.OrganizationId], p.Name)
+		}
+	}
+	if req.Accounts {
+		// 遍历机构树时逐个机构查询，不一次性加载全部账号，只查询姓名，离职账号不导出
+		db := o.db.WithContext(c)
+		chart.accounts = func(organizationId uint) ([]string, error) {
+			var names []string
+			if err := db.Model(&model.Account{}).Scopes(scope.Accounts).
+				Where(accountTable+".organization_id = ? AND "+accountTable+".is_leave = ?", organizationId, false).
+				Order(accountTable+".id").Pluck(accountTable+".user_name", &names).Error; err != nil {
+				o.l.Error(fmt.Sprintf("查询账号失败: %s", err.Error()))
+				return nil, fmt.Errorf("查询账号失败")
+			}
+			return names, nil
+		}
+	}
+
+	resp := &types2.OrganizationExportResp{}
+	switch req.Format {
+	case "csv":
+		resp.ContentType = "text/csv; charset=utf-8"
+		resp.Write = chart.writeCsv
+	case "dot":
+		resp.ContentType = "text/vnd.graphviz; charset=utf-8"
+		resp.Write = chart.writeDot
+	case "mermaid":
+		req.Format = "mmd"
+		resp.ContentType = "text/plain; charset=utf-8"
+		resp.Write = chart.writeMermaid
+	default:
+		req.Format = "json"
+		resp.ContentType = "application/json; charset=utf-8"
+		resp.Write = chart.writeJson
+	}
+	resp.FileName = fmt.Sprintf("organizations-%s.%s", time.Now().Format("20060102150405"), req.Format)
+	return resp, nil
+}
+
+// writeJson 按树形结构输出，每个节点包含 id、name、type、path，以及可选的 positions、accounts 和 children
+func (o *orgChart) writeJson(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if err := o.writeJsonNodes(bw, o.roots); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (o *orgChart) writeJsonNodes(w *bufio.Writer, orgs []*model.Organization) error {
+	w.WriteByte('[')
+	for i, org := range orgs {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		node := map[string]interface{}{"id": org.ID, "name": org.Name, "type": org.Type, "path": o.paths[org.ID]}
+		if o.positions != nil {
+			node["positions"] = nonNil(o.positions[org.ID])
+		}
+		if o.accounts != nil {
+			accounts, err := o.accounts(org.ID)
+			if err != nil {
+				return err
+			}
+			node["accounts"] = nonNil(accounts)
+		}
+		data, err := json.Marshal(node)
+		if err != nil {
+			return err
+		}
+		// 去掉结尾的大括号后追加下级机构
+		w.Write(data[:len(data)-1])
+		w.WriteString(`,"children":`)
+		if err := o.writeJsonNodes(w, org.Children); err != nil {
+			return err
+		}
+		if _, err := w.WriteString("}"); err != nil {
+			return err
+		}
+	}
+	_, err := w.WriteString("]")
+	return err
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// csvFormulaPrefix 以这些字符开头的单元格会被 Excel 等表格软件当作公式执行
+const csvFormulaPrefix = "=+-@\t\r"
+
+// csvCell 以公式字符开头的单元格前加单引号，避免导出的名称被当作公式执行
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefix, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// writeCsv 每个机构一行，path 为从顶层开始的完整名称路径，职位和账号姓名以分号分隔，名称类的单元格按 csvCell 转义
+func (o *orgChart) writeCsv(w io.Writer) error {
+	// 写入 BOM，避免 Excel 打开中文乱码
+	if _, err := io.WriteString(w, "\xEF\xBB\xBF"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	header := []string{"id", "parentId", "name", "type", "level", "path"}
+	if o.positions != nil {
+		header = append(header, "positions")
+	}
+	if o.accounts != nil {
+		header = append(header, "accounts")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	err := o.walk(func(org *model.Organization) error {
+		record := []string{
+			strconv.FormatUint(uint64(org.ID), 10),
+			strconv.FormatUint(uint64(org.ParentId), 10),
+			csvCell(org.Name),
+			csvCell(org.Type),
+			strconv.Itoa(org.Level),
+			csvCell(o.paths[org.ID]),
+		}
+		if o.positions != nil {
+			record = append(record, csvCell(strings.Join(o.positions[org.ID], ";")))
+		}
+		if o.accounts != nil {
+			accounts, err := o.accounts(org.ID)
+			if err != nil {
+				return err
+			}
+			record = append(record, csvCell(strings.Join(accounts, ";")))
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// label 节点显示的多行文本：机构名称，以及可选的职位和账号姓名
+func (o *orgChart) label(org *model.Organization) ([]string, error) {
+	lines := []string{org.Name}
+	if positions := o.positions[org.ID]; len(positions) > 0 {
+		lines = append(lines, "职位: "+strings.Join(positions, "、"))
+	}
+	if o.accounts != nil {
+		accounts, err := o.accounts(org.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(accounts) > 0 {
+			lines = append(lines, "成员: "+strings.Join(accounts, "、"))
+		}
+	}
+	return lines, nil
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ")
+
+// writeDot 输出 Graphviz DOT，节点 ID 为 n<机构ID>
+func (o *orgChart) writeDot(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph organization {\n  rankdir=TB;\n  node [shape=box];\n")
+	err := o.walk(func(org *model.Organization) error {
+		lines, err := o.label(org)
+		if err != nil {
+			return err
+		}
+		for i, line := range lines {
+			lines[i] = dotEscaper.Replace(line)
+		}
+		fmt.Fprintf(bw, "  n%d [label=\"%s\"];\n", org.ID, strings.Join(lines, `\n`))
+		if !o.isRoot(org) {
+			_, err := fmt.Fprintf(bw, "  n%d -> n%d;\n", org.ParentId, org.ID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ")
+
+// writeMermaid 输出 Mermaid 流程图，节点 ID 为 n<机构ID>
+func (o *orgChart) writeMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("graph TD\n")
+	err := o.walk(func(org *model.Organization) error {
+		lines, err := o.label(org)
+		if err != nil {
+			return err
+		}
+		for i, line := range lines {
+			lines[i] = mermaidEscaper.Replace(line)
+		}
+		fmt.Fprintf(bw, "  n%d[\"%s\"]\n", org.ID, strings.Join(lines, "<br/>"))
+		if !o.isRoot(org) {
+			_, err := fmt.Fprintf(bw, "  n%d --> n%d\n", org.ParentId, org.ID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
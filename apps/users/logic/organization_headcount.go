@@ -0,0 +1,335 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+	"time"
+)
+
+const headcountCacheKey = "ikubexjob:organization:headcount" // 人员统计的原始数据
+
+// headcountRow 按机构、职位、状态分组的账号数量
+type headcountRow struct {
+	OrganizationId uint  `json:"organizationId"`
+	PositionId     uint  `json:"positionId"`
+	IsDisabled     bool  `json:"isDisabled"`
+	IsLeave        bool  `json:"isLeave"`
+	Count          int64 `json:"count"`
+}
+
+// periodRow 按机构和月份或日期分组的账号数量
+type periodRow struct {
+	OrganizationId uint   `json:"organizationId"`
+	Period         string `json:"period"`
+	Count          int64  `json:"count"`
+}
+
+// headcountData 全部机构的统计原始数据，按机构分组后缓存，查询时再按机构树和数据权限汇总
+type headcountData struct {
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Counts      []headcountRow  `json:"counts"`
+	Hires       []periodRow     `json:"hires"`
+	Logins      []periodRow     `json:"logins"`
+	Positions   map[uint]string `json:"positions"`
+}
+
+type headcountCache struct {
+	mu    sync.RWMutex
+	data  *headcountData
+	group singleflight.Group
+}
+
+var headcount = &headcountCache{}
+
+func (h *headcountCache) logger() *zap.Logger {
+	return global.L.Named(apps.AppName).Named(apps.AppOrganization).Named("headcount")
+}
+
+func (h *headcountCache) ttl() time.Duration {
+	if global.C.Headcount.TTL <= 0 {
+		return time.Minute
+	}
+	return time.Duration(global.C.Headcount.TTL) * time.Minute
+}
+
+// get 返回有效期内的统计数据，进程内没有时读取 redis，都没有时从数据库统计并写入 redis
+func (h *headcountCache) get(ctx context.Context, db *gorm.DB) (*headcountData, error) {
+	h.mu.RLock()
+	data := h.data
+	h.mu.RUnlock()
+	if data != nil && time.Since(data.GeneratedAt) < h.ttl() {
+		return data, nil
+	}
+	value, err, _ := h.group.Do("headcount", func() (interface{}, error) {
+		ctx := context.WithoutCancel(ctx)
+		data, err := h.load(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		h.mu.Lock()
+		h.data = data
+		h.mu.Unlock()
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*headcountData), nil
+}
+
+func (h *headcountCache) load(ctx context.Context, db *gorm.DB) (*headcountData, error) {
+	var rdb *redis.Client
+	if global.RDB != nil {
+		rdb = global.RDB.GetClient()
+	}
+	if rdb != nil {
+		raw, err := rdb.Get(ctx, headcountCacheKey).Bytes()
+		if err == nil {
+			var data headcountData
+			if err := json.Unmarshal(raw, &data); err == nil && time.Since(data.GeneratedAt) < h.ttl() {
+				return &data, nil
+			}
+		} else if !errors.Is(err, redis.Nil) {
+			h.logger().Warn(fmt.Sprintf("读取人员统计缓存失败，从数据库统计: %s", err.Error()))
+		}
+	}
+	data, err := collectHeadcount(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if rdb != nil {
+		if raw, err := json.Marshal(data); err == nil {
+			if err := rdb.Set(ctx, headcountCacheKey, raw, h.ttl()).Err(); err != nil {
+				h.logger().Warn(fmt.Sprintf("写入人员统计缓存失败: %s", err.Error()))
+			}
+		}
+	}
+	return data, nil
+}
+
+// collectHeadcount 从数据库统计全部机构的人数、每月入职人数和每天登录人数
+func collectHeadcount(ctx context.Context, db *gorm.DB) (*headcountData, error) {
+	now := time.Now()
+	data := &headcountData{GeneratedAt: now, Positions: make(map[uint]string)}
+	db = db.WithContext(ctx)
+	if err := db.Model(&model.Account{}).
+		Select("organization_id, position_id, is_disabled, is_leave, COUNT(*) AS count").
+		Group("organization_id, position_id, is_disabled, is_leave").
+		Scan(&data.Counts).Error; err != nil {
+		return nil, fmt.Errorf("统计机构人数失败: %w", err)
+	}
+	months, days := headcountMonths(now), headcountDays(now)
+	if err := db.Model(&model.Account{}).
+		Select("organization_id, DATE_FORMAT(hire_date, '%Y-%m') AS period, COUNT(*) AS count").
+		Where("hire_date >= ?", months[0]+"-01").
+		Group("organization_id, period").
+		Scan(&data.Hires).Error; err != nil {
+		return nil, fmt.Errorf("统计入职人数失败: %w", err)
+	}
+	if err := db.Model(&model.Account{}).
+		Select("organization_id, DATE_FORMAT(last_login_time, '%Y-%m-%d') AS period, COUNT(*) AS count").
+		Where("last_login_time >= ?", days[0]).
+		Group("organization_id, period").
+		Scan(&data.Logins).Error; err != nil {
+		return nil, fmt.Errorf("统计登录人数失败: %w", err)
+	}
+	var positions []*model.Position
+	if err := db.Unscoped().Select("id", "name").Find(&positions).Error; err != nil {
+		return nil, fmt.Errorf("查询职位失败: %w", err)
+	}
+	for _, p := range positions {
+		data.Positions[p.ID] = p.Name
+	}
+	return data, nil
+}
+
+// headcountMonths 统计入职人数的月份，从早到晚，包含当月
+func headcountMonths(now time.Time) []string {
+	n := global.C.Headcount.Months
+	if n <= 0 {
+		n = 12
+	}
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	months := make([]string, n)
+	for i := 0; i < n; i++ {
+		months[i] = first.AddDate(0, i-n+1, 0).Format("2006-01")
+	}
+	return months
+}
+
+// headcountDays 统计登录人数的日期，从早到晚，包含当天
+func headcountDays(now time.Time) []string {
+	n := global.C.Headcount.Days
+	if n <= 0 {
+		n = 30
+	}
+	days := make([]string, n)
+	for i := 0; i < n; i++ {
+		days[i] = now.AddDate(0, 0, i-n+1).Format(dateLayout)
+	}
+	return days
+}
+
+// addHeadcount 已离职的账号只计入离职人数，禁用人数不包含已离职
+func addHeadcount(count *types2.HeadcountCount, row headcountRow) {
+	switch {
+	case row.IsLeave:
+		count.Left += row.Count
+	case row.IsDisabled:
+		count.Disabled += row.Count
+	default:
+		count.Active += row.Count
+	}
+}
+
+func sumHeadcount(dst *types2.HeadcountCount, src types2.HeadcountCount) {
+	dst.Active += src.Active
+	dst.Disabled += src.Disabled
+	dst.Left += src.Left
+}
+
+// positionHeadcounts 按人数倒序，人数相同时按职位 ID 排序
+func positionHeadcounts(counts map[uint]int64, names map[uint]string) []*types2.PositionHeadcount {
+	list := make([]*types2.PositionHeadcount, 0, len(counts))
+	for id, count := range counts {
+		list = append(list, &types2.PositionHeadcount{PositionId: id, PositionName: names[id], Count: count})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].PositionId < list[j].PositionId
+	})
+	return list
+}
+
+// periodHeadcounts 按给定的月份或日期汇总机构范围内的人数，没有数据的周期为 0
+func periodHeadcounts(rows []periodRow, periods []string, orgIds map[uint]bool) []*types2.HeadcountPeriod {
+	counts := make(map[string]int64, len(periods))
+	for _, row := range rows {
+		if orgIds[row.OrganizationId] {
+			counts[row.Period] += row.Count
+		}
+	}
+	list := make([]*types2.HeadcountPeriod, 0, len(periods))
+	for _, period := range periods {
+		list = append(list, &types2.HeadcountPeriod{Period: period, Count: counts[period]})
+	}
+	return list
+}
+
+// collectHeadcountIds 收集统计树中的全部机构，数据权限范围外的机构不在统计树中
+func collectHeadcountIds(node *types2.OrganizationHeadcount, orgIds map[uint]bool) {
+	orgIds[node.OrganizationId] = true
+	for _, child := range node.Children {
+		collectHeadcountIds(child, orgIds)
+	}
+}
+
+// Headcount 机构人员统计：每个机构的直属人数、包含下级机构的人数和职位分布，以及机构范围内每月入职人数、每天登录人数
+// 统计数据按 headcount.ttl 缓存，不随账号变更实时刷新
+func (o *OrganizationLogic) Headcount(c *gin.Context, req types2.OrganizationHeadcountReq) (*types2.OrganizationHeadcountResp, error) {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	if req.OrganizationId != 0 {
+		if err := scope.checkOrganization(req.OrganizationId); err != nil {
+			return nil, err
+		}
+	}
+	snapshot, err := o.snapshot(c)
+	if err != nil {
+		return nil, err
+	}
+	data, err := headcount.get(c, o.db)
+	if err != nil {
+		o.l.Error(fmt.Sprintf("查询人员统计失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询人员统计失败")
+	}
+	nodes := make(map[uint]*types2.OrganizationHeadcount, len(snapshot.Orgs))
+	for _, org := range snapshot.Orgs {
+		nodes[org.ID] = &types2.OrganizationHeadcount{
+			OrganizationId: org.ID,
+			Name:           org.Name,
+			TreeName:       snapshot.paths.paths[org.ID],
+			Type:           org.Type,
+		}
+	}
+	positions := make(map[uint]map[uint]int64)
+	for _, row := range data.Counts {
+		node, ok := nodes[row.OrganizationId]
+		if !ok {
+			continue
+		}
+		addHeadcount(&node.Direct, row)
+		if !row.IsLeave {
+			if positions[row.OrganizationId] == nil {
+				positions[row.OrganizationId] = make(map[uint]int64)
+			}
+			positions[row.OrganizationId][row.PositionId] += row.Count
+		}
+	}
+	// 快照按层级排序，倒序遍历时下级机构先于上级机构汇总完成。
+	// 数据权限范围外的机构不汇总到上级机构，也不出现在统计树中
+	for i := len(snapshot.Orgs) - 1; i >= 0; i-- {
+		org := snapshot.Orgs[i]
+		node := nodes[org.ID]
+		sumHeadcount(&node.Total, node.Direct)
+		node.Positions = positionHeadcounts(positions[org.ID], data.Positions)
+		parent, ok := nodes[org.ParentId]
+		if !ok || !scope.hasOrganization(org.ID) {
+			continue
+		}
+		sumHeadcount(&parent.Total, node.Total)
+		if len(positions[org.ID]) > 0 && positions[org.ParentId] == nil {
+			positions[org.ParentId] = make(map[uint]int64)
+		}
+		for positionId, count := range positions[org.ID] {
+			positions[org.ParentId][positionId] += count
+		}
+		parent.Children = append([]*types2.OrganizationHeadcount{node}, parent.Children...)
+	}
+	// 指定机构时只返回该机构，否则返回数据权限范围内的顶层机构
+	var roots []*types2.OrganizationHeadcount
+	if req.OrganizationId != 0 {
+		node, ok := nodes[req.OrganizationId]
+		if !ok {
+			return nil, fmt.Errorf("机构不存在")
+		}
+		roots = append(roots, node)
+	} else {
+		for _, org := range snapshot.Orgs {
+			if !scope.hasOrganization(org.ID) {
+				continue
+			}
+			if _, ok := nodes[org.ParentId]; ok && scope.hasOrganization(org.ParentId) {
+				continue
+			}
+			roots = append(roots, nodes[org.ID])
+		}
+	}
+	orgIds := make(map[uint]bool)
+	for _, root := range roots {
+		collectHeadcountIds(root, orgIds)
+	}
+	return &types2.OrganizationHeadcountResp{
+		GeneratedAt:   data.GeneratedAt,
+		Organizations: roots,
+		Hires:         periodHeadcounts(data.Hires, headcountMonths(data.GeneratedAt), orgIds),
+		Logins:        periodHeadcounts(data.Logins, headcountDays(data.GeneratedAt), orgIds),
+	}, nil
+}
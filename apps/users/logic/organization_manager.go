@@ -0,0 +1,429 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/sql"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+var organizationManagerTable = (&model.OrganizationManager{}).TableName()
+
+// reportingLines 由机构树和机构负责人推导出的汇报关系
+// 账号的直属上级为从所在机构开始向上第一个有负责人的机构的负责人，账号自己负责的机构跳过
+type reportingLines struct {
+	snapshot *organizationSnapshot
+	managers map[uint][]uint // 机构 ID 到负责人账号 ID
+}
+
+// reportingLevel 上级链中的一级
+type reportingLevel struct {
+	organizationId uint
+	managerIds     []uint
+}
+
+func loadReportingLines(ctx context.Context, db *gorm.DB) (*reportingLines, error) {
+	snapshot, err := orgCache.get(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var rows []*model.OrganizationManager
+	if err := db.WithContext(ctx).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	r := &reportingLines{snapshot: snapshot, managers: make(map[uint][]uint)}
+	for _, row := range rows {
+		r.managers[row.OrganizationId] = append(r.managers[row.OrganizationId], row.AccountId)
+	}
+	return r, nil
+}
+
+func containsId(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// chain 账号的上级链，从所在机构开始逐级向上，每个有负责人的机构为一级，第一级为直属上级
+func (r *reportingLines) chain(accountId, organizationId uint) []reportingLevel {
+	var levels []reportingLevel
+	seen := make(map[uint]bool)
+	for id := organizationId; id != 0 && !seen[id]; {
+		seen[id] = true
+		org, ok := r.snapshot.byId[id]
+		if !ok {
+			break
+		}
+		if managers := r.managers[id]; len(managers) > 0 && !containsId(managers, accountId) {
+			levels = append(levels, reportingLevel{organizationId: id, managerIds: managers})
+		}
+		id = org.ParentId
+	}
+	return levels
+}
+
+// reports 账号的下属，只在账号负责的机构及其下级机构中查找，indirect 为 false 时只返回直属下属
+func (r *reportingLines) reports(ctx context.Context, db *gorm.DB, accountId uint, indirect bool) ([]uint, error) {
+	orgIds := make(map[uint]bool)
+	for orgId, managers := range r.managers {
+		if _, ok := r.snapshot.byId[orgId]; ok && containsId(managers, accountId) {
+			for _, id := range r.snapshot.descendantIds(orgId) {
+				orgIds[id] = true
+			}
+		}
+	}
+	if len(orgIds) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, 0, len(orgIds))
+	for id := range orgIds {
+		ids = append(ids, id)
+	}
+	var candidates []*model.Account
+	if err := db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).Select("id", "organization_id").
+		Where("organization_id IN ?", ids).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	var reports []uint
+	for _, a := range candidates {
+		if a.ID == accountId {
+			continue
+		}
+		for i, level := range r.chain(a.ID, a.OrganizationId) {
+			if containsId(level.managerIds, accountId) {
+				if i == 0 || indirect {
+					reports = append(reports, a.ID)
+				}
+				break
+			}
+		}
+	}
+	return reports, nil
+}
+
+// managedOrganizationNames 账号负责的未删除机构的名称
+func managedOrganizationNames(ctx context.Context, db *gorm.DB, accountId uint) ([]string, error) {
+	var names []string
+	orgTable := (&model.Organization{}).TableName()
+	err := db.WithContext(ctx).Model(&model.OrganizationManager{}).
+		Joins(fmt.Sprintf("JOIN %s o ON o.id = %s.organization_id AND o.deleted_at = 0", orgTable, organizationManagerTable)).
+		Where(organizationManagerTable+".account_id = ?", accountId).
+		Pluck("o.name", &names).Error
+	return names, err
+}
+
+// managerAccounts 按 ID 查询负责人账号，保持 ID 的顺序
+func managerAccounts(ctx context.Context, db *gorm.DB, ids []uint) ([]*model.Account, error) {
+	accounts := make([]*model.Account, 0, len(ids))
+	if len(ids) == 0 {
+		return accounts, nil
+	}
+	var list []*model.Account
+	if err := accountWithNames(db.WithContext(ctx)).Where(accountTable+".id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	byId := make(map[uint]*model.Account, len(list))
+	for _, a := range list {
+		byId[a.ID] = a
+	}
+	for _, id := range ids {
+		if a, ok := byId[id]; ok {
+			accounts = append(accounts, a)
+		}
+	}
+	return accounts, nil
+}
+
+// Managers 查询机构的负责人
+func (o *OrganizationLogic) Managers(c *gin.Context, id types.SearchId) ([]*model.Account, error) {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.visibleOrganization(id.Id) {
+		return nil, errOutOfDataScope
+	}
+	var ids []uint
+	if err := o.db.WithContext(c).Model(&model.OrganizationManager{}).Where("organization_id = ?", id.Id).
+		Order("id").Pluck("account_id", &ids).Error; err != nil {
+		o.l.Error(fmt.Sprintf("查询机构负责人失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("查询机构负责人失败")
+	}
+	accounts, err := managerAccounts(c, o.db, ids)
+	if err != nil {
+		o.l.Error(fmt.Sprintf("查询机构负责人失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("查询机构负责人失败")
+	}
+	return accounts, nil
+}
+
+// SetManagers 设置机构的负责人，覆盖原有的负责人，离职账号不能作为负责人
+func (o *OrganizationLogic) SetManagers(c *gin.Context, id types.SearchId, req *types2.OrganizationManagerReq) ([]*model.Account, error) {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.checkOrganization(id.Id); err != nil {
+		return nil, err
+	}
+	if err := o.db.WithContext(c).Where("id = ?", id.Id).First(&model.Organization{}).Error; err != nil {
+		o.l.Error(fmt.Sprintf("查询机构信息失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("查询机构信息失败")
+	}
+	ids := make([]uint, 0, len(req.AccountIds))
+	for _, accountId := range req.AccountIds {
+		if !containsId(ids, accountId) {
+			ids = append(ids, accountId)
+		}
+	}
+	errManagerAccount := fmt.Errorf("负责人账号不存在或已离职")
+	err = o.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// 锁定负责人账号，与删除账号互斥，避免设置为负责人的同时账号被删除
+		if len(ids) > 0 {
+			var accounts []*model.Account
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+				Where("id IN ? AND is_leave = ?", ids, false).Find(&accounts).Error; err != nil {
+				return err
+			}
+			if len(accounts) != len(ids) {
+				return errManagerAccount
+			}
+		}
+		remove := tx.Where("organization_id = ?", id.Id)
+		if len(ids) > 0 {
+			remove = remove.Where("account_id NOT IN ?", ids)
+		}
+		if err := remove.Delete(&model.OrganizationManager{}).Error; err != nil {
+			return err
+		}
+		var existing []uint
+		if err := tx.Model(&model.OrganizationManager{}).Where("organization_id = ?", id.Id).Pluck("account_id", &existing).Error; err != nil {
+			return err
+		}
+		for _, accountId := range ids {
+			if containsId(existing, accountId) {
+				continue
+			}
+			if err := tx.Create(&model.OrganizationManager{OrganizationId: id.Id, AccountId: accountId}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errManagerAccount) {
+		return nil, err
+	}
+	if err != nil {
+		o.l.Error(fmt.Sprintf("设置机构负责人失败, id: %d, error: %s", id.Id, err.Error()))
+		return nil, fmt.Errorf("设置机构负责人失败")
+	}
+	o.l.Info(fmt.Sprintf("设置机构负责人, id: %d, accountIds: %v", id.Id, ids))
+	return o.Managers(c, id)
+}
+
+// visibleAccount 账号必须在数据权限范围内
+func (l *AccountLogic) visibleAccount(c *gin.Context, id uint) (*model.Account, error) {
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	var account model.Account
+	if err := l.db.WithContext(c).Scopes(scope.Accounts).Where(accountTable+".id = ?", id).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("账号不存在或超出数据权限范围")
+	}
+	return &account, nil
+}
+
+// writableAccount 账号必须在数据权限的写范围内，本人不因本人可见而可写
+func (l *AccountLogic) writableAccount(c *gin.Context, id uint) (*model.Account, error) {
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	var account model.Account
+	if err := l.db.WithContext(c).Scopes(scope.WritableAccounts).Where(accountTable+".id = ?", id).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("账号不存在或超出数据权限范围")
+	}
+	return &account, nil
+}
+
+// Managers 查询账号的上级链，第一级为直属上级
+func (l *AccountLogic) Managers(c *gin.Context, id types.SearchId) ([]*types2.AccountManagerLevel, error) {
+	account, err := l.visibleAccount(c, id.Id)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := loadReportingLines(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询机构负责人失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询机构负责人失败")
+	}
+	chain := lines.chain(account.ID, account.OrganizationId)
+	var ids []uint
+	for _, level := range chain {
+		ids = append(ids, level.managerIds...)
+	}
+	accounts, err := managerAccounts(c, l.db, ids)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询上级账号失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询上级账号失败")
+	}
+	byId := make(map[uint]*model.Account, len(accounts))
+	for _, a := range accounts {
+		byId[a.ID] = a
+	}
+	levels := make([]*types2.AccountManagerLevel, 0, len(chain))
+	for _, level := range chain {
+		item := &types2.AccountManagerLevel{
+			OrganizationId:   level.organizationId,
+			OrganizationName: lines.snapshot.byId[level.organizationId].Name,
+			TreeName:         lines.snapshot.paths.paths[level.organizationId],
+			Managers:         make([]*model.Account, 0, len(level.managerIds)),
+		}
+		for _, managerId := range level.managerIds {
+			if a, ok := byId[managerId]; ok {
+				item.Managers = append(item.Managers, a)
+			}
+		}
+		levels = append(levels, item)
+	}
+	return levels, nil
+}
+
+// Reports 分页查询账号的下属，只返回数据权限范围内的账号
+func (l *AccountLogic) Reports(c *gin.Context, id types.SearchId, query types2.AccountReportQueryReq) (*types.QueryResponse, error) {
+	account, err := l.visibleAccount(c, id.Id)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := loadReportingLines(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询机构负责人失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询机构负责人失败")
+	}
+	ids, err := lines.reports(c, l.db, account.ID, query.Indirect)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询下属账号失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询下属账号失败")
+	}
+	scope, err := loadDataScope(c, l.db)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	var list []*model.Account
+	db := accountWithNames(l.db.WithContext(c)).Scopes(scope.Accounts).Where(accountTable+".id IN ?", ids).
+		Order(fmt.Sprintf("%s.id %s", accountTable, query.Sort))
+	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询下属账号失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询下属账号失败")
+	}
+	return queryRes, nil
+}
+
+// MyManagers 查询当前账号的上级链
+func (l *AccountLogic) MyManagers(c *gin.Context) ([]*types2.AccountManagerLevel, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	return l.Managers(c, types.SearchId{Id: claims.AccountId})
+}
+
+// MyReports 分页查询当前账号的下属
+func (l *AccountLogic) MyReports(c *gin.Context, query types2.AccountReportQueryReq) (*types.QueryResponse, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	return l.Reports(c, types.SearchId{Id: claims.AccountId}, query)
+}
+
+// Handover 将账号负责的全部机构转交给另一个账号，接手账号已是负责人的机构只移除原账号
+func (l *AccountLogic) Handover(c *gin.Context, id types.SearchId, req *types2.AccountHandoverReq) error {
+	if id.Id == req.AccountId {
+		return fmt.Errorf("不能转交给自己")
+	}
+	if _, err := l.writableAccount(c, id.Id); err != nil {
+		return err
+	}
+	target, err := l.visibleAccount(c, req.AccountId)
+	if err != nil {
+		return fmt.Errorf("接手账号不存在或超出数据权限范围")
+	}
+	if target.IsLeave {
+		return fmt.Errorf("接手账号已离职")
+	}
+	var orgIds []uint
+	err = l.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		orgIds, err = handoverManagers(tx, id.Id, req.AccountId)
+		return err
+	})
+	if err != nil {
+		l.l.Error(fmt.Sprintf("转交负责机构失败, id: %d, error: %s", id.Id, err.Error()))
+		return fmt.Errorf("转交负责机构失败")
+	}
+	l.l.Info(fmt.Sprintf("账号 %d 负责的机构 %v 转交给账号 %d", id.Id, orgIds, req.AccountId))
+	return nil
+}
+
+// handoverManagers 把账号负责的全部机构转交给另一个账号，接手账号已经是负责人的机构直接移除，返回转交的机构
+func handoverManagers(tx *gorm.DB, fromId, toId uint) ([]uint, error) {
+	var rows []*model.OrganizationManager
+	if err := tx.Where("account_id = ?", fromId).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	orgIds := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		orgIds = append(orgIds, row.OrganizationId)
+		var count int64
+		if err := tx.Model(&model.OrganizationManager{}).
+			Where("organization_id = ? AND account_id = ?", row.OrganizationId, toId).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			if err := tx.Delete(row).Error; err != nil {
+				return nil, err
+			}
+		} else if err := tx.Model(row).Update("account_id", toId).Error; err != nil {
+			return nil, err
+		}
+	}
+	return orgIds, nil
+}
+
+// checkNotManager 仍是机构负责人的账号不能删除，需要先转交或调整机构负责人。
+// 锁定账号的负责人记录，调用方需要在同一事务内删除账号
+func (l *AccountLogic) checkNotManager(c *gin.Context, tx *gorm.DB, id uint) error {
+	var managers []*model.OrganizationManager
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("account_id = ?", id).Find(&managers).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询账号负责的机构失败: %s", err.Error()))
+		return fmt.Errorf("查询账号负责的机构失败")
+	}
+	if len(managers) == 0 {
+		return nil
+	}
+	names, err := managedOrganizationNames(c, tx, id)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询账号负责的机构失败: %s", err.Error()))
+		return fmt.Errorf("查询账号负责的机构失败")
+	}
+	if len(names) > 0 {
+		return fmt.Errorf("账号仍是机构 %s 的负责人，请先转交负责的机构", strings.Join(names, "、"))
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	positionConflictMerge  = "merge"
+	positionConflictRename = "rename"
+	positionNameMaxLength  = 64
+)
+
+// errRestructureDryRun 预览时回滚事务
+var errRestructureDryRun = errors.New("dry run")
+
+// Merge 把机构的下级机构、职位和账号全部移动到目标机构后删除该机构
+func (o *OrganizationLogic) Merge(c *gin.Context, id types.SearchId, req *types2.OrganizationRestructureReq) (*types2.OrganizationRestructureResp, error) {
+	return o.restructure(c, id.Id, req, true)
+}
+
+// Dissolve 解散机构：下级机构移动到上级机构下，职位和账号移动到目标机构，然后删除该机构
+func (o *OrganizationLogic) Dissolve(c *gin.Context, id types.SearchId, req *types2.OrganizationRestructureReq) (*types2.OrganizationRestructureResp, error) {
+	return o.restructure(c, id.Id, req, false)
+}
+
+// restructure 在一个事务内完成合并或解散，预览时执行全部校验和写入后回滚，返回的内容与实际执行一致
+func (o *OrganizationLogic) restructure(c *gin.Context, id uint, req *types2.OrganizationRestructureReq, merge bool) (*types2.OrganizationRestructureResp, error) {
+	action := "解散"
+	if merge {
+		action = "合并"
+	}
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.checkOrganization(id); err != nil {
+		return nil, err
+	}
+	if err := scope.checkOrganization(req.TargetId); err != nil {
+		return nil, err
+	}
+	if req.Conflict == "" {
+		req.Conflict = positionConflictMerge
+	}
+	resp := &types2.OrganizationRestructureResp{
+		DryRun:         req.DryRun,
+		OrganizationId: id,
+		TargetId:       req.TargetId,
+		Organizations:  []*types2.RestructureOrganization{},
+		Positions:      []*types2.RestructurePosition{},
+		Accounts:       []*types2.RestructureAccount{},
+		Transfers:      []uint{},
+		Managers:       []uint{},
+	}
+	err = o.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// 锁定机构表，避免与移动机构并发执行
+		var orgs []*model.Organization
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&orgs).Error; err != nil {
+			return fmt.Errorf("查询机构信息失败")
+		}
+		t := tree.Build(orgs, nil)
+		org, ok := t.Get(id)
+		if !ok {
+			return fmt.Errorf("机构不存在")
+		}
+		if org.ParentId == 0 {
+			return fmt.Errorf("主节点不允许%s", action)
+		}
+		target, ok := t.Get(req.TargetId)
+		if !ok {
+			return fmt.Errorf("目标机构不存在")
+		}
+		if target.ID == org.ID {
+			return fmt.Errorf("目标机构不能是机构自身")
+		}
+		// 合并时下级机构挂到目标机构下，解散时挂到上级机构下
+		parentId := org.ParentId
+		if merge {
+			if strings.HasPrefix(target.Path, org.SubtreePath()) {
+				return fmt.Errorf("不能合并到下级机构")
+			}
+			parentId = target.ID
+		} else if err := scope.checkOrganization(parentId); err != nil {
+			return err
+		}
+		children := append([]*model.Organization{}, org.Children...)
+		for _, child := range children {
+			resp.Organizations = append(resp.Organizations, &types2.RestructureOrganization{
+				Id:      child.ID,
+				Name:    child.Name,
+				OldPath: organizationPath(t, child.ID),
+				NewPath: organizationPath(t, parentId) + "/" + child.Name,
+			})
+			if _, err := o.move(c, tx, child.ID, parentId); err != nil {
+				return err
+			}
+		}
+		positionMap, err := restructurePositions(tx, org, target, req.Conflict, resp)
+		if err != nil {
+			return err
+		}
+		if err := restructureAccounts(tx, org, target, positionMap, action, resp); err != nil {
+			return err
+		}
+		// 待生效的调动改为新的机构和职位
+		var transfers []*model.AccountTransfer
+		if err := tx.Where("status = ?", model.TransferStatusPending).Find(&transfers).Error; err != nil {
+			return fmt.Errorf("查询待生效的调动失败")
+		}
+		for _, transfer := range transfers {
+			orgId, positionId := transfer.ToOrganizationId, transfer.ToPositionId
+			if orgId == org.ID {
+				orgId = target.ID
+			}
+			if mapped, ok := positionMap[positionId]; ok {
+				positionId = mapped
+			}
+			if orgId == transfer.ToOrganizationId && positionId == transfer.ToPositionId {
+				continue
+			}
+			if err := tx.Model(transfer).Updates(map[string]interface{}{
+				"to_organization_id": orgId,
+				"to_position_id":     positionId,
+			}).Error; err != nil {
+				return fmt.Errorf("修改待生效的调动失败")
+			}
+			resp.Transfers = append(resp.Transfers, transfer.ID)
+		}
+		// 机构负责人不转给目标机构，由调用方按预览结果重新设置
+		var managers []*model.OrganizationManager
+		if err := tx.Where("organization_id = ?", org.ID).Find(&managers).Error; err != nil {
+			return fmt.Errorf("查询机构负责人失败")
+		}
+		for _, manager := range managers {
+			resp.Managers = append(resp.Managers, manager.AccountId)
+		}
+		if err := tx.Where("organization_id = ?", org.ID).Delete(&model.OrganizationManager{}).Error; err != nil {
+			return fmt.Errorf("解除机构负责人失败")
+		}
+		if err := tx.Where("id = ?", org.ID).Delete(&model.Organization{}).Error; err != nil {
+			return fmt.Errorf("删除机构失败")
+		}
+		if req.DryRun {
+			return errRestructureDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errRestructureDryRun) {
+		return resp, nil
+	}
+	if err != nil {
+		o.l.Error(fmt.Sprintf("%s机构失败, id: %d, targetId: %d, error: %s", action, id, req.TargetId, err.Error()))
+		return nil, fmt.Errorf("%s机构失败: %w", action, err)
+	}
+	o.l.Info(fmt.Sprintf("%s机构 %d -> %d: 下级机构 %d 个, 职位 %d 个, 账号 %d 个",
+		action, id, req.TargetId, len(resp.Organizations), len(resp.Positions), len(resp.Accounts)))
+	orgCache.invalidate(c)
+	return resp, nil
+}
+
+// restructurePositions 把机构的职位移动到目标机构，同名职位按 conflict 合并或重命名，返回被合并的职位到目标职位的映射
+func restructurePositions(tx *gorm.DB, org, target *model.Organization, conflict string, resp *types2.OrganizationRestructureResp) (map[uint]uint, error) {
+	var positions []*model.Position
+	if err := tx.Where("organization_id = ?", org.ID).Order("id").Find(&positions).Error; err != nil {
+		return nil, fmt.Errorf("查询机构职位失败")
+	}
+	positionMap := make(map[uint]uint)
+	if len(positions) == 0 {
+		return positionMap, nil
+	}
+	if err := target.CheckPositions(); err != nil {
+		return nil, fmt.Errorf("目标机构%w", err)
+	}
+	var existing []*model.Position
+	if err := tx.Where("organization_id = ?", target.ID).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("查询目标机构职位失败")
+	}
+	names := make(map[string]uint, len(existing))
+	for _, p := range existing {
+		names[p.Name] = p.ID
+	}
+	for _, p := range positions {
+		item := &types2.RestructurePosition{Id: p.ID, Name: p.Name, Action: "move", TargetId: p.ID, TargetName: p.Name}
+		if existingId, ok := names[p.Name]; ok {
+			if conflict == positionConflictMerge {
+				item.Action, item.TargetId = positionConflictMerge, existingId
+				positionMap[p.ID] = existingId
+				if err := tx.Delete(p).Error; err != nil {
+					return nil, fmt.Errorf("合并职位 %s 失败", p.Name)
+				}
+				resp.Positions = append(resp.Positions, item)
+				continue
+			}
+			item.Action = positionConflictRename
+			item.TargetName = fmt.Sprintf("%s（%s）", p.Name, org.Name)
+			if _, ok := names[item.TargetName]; ok {
+				return nil, fmt.Errorf("职位 %s 重命名为 %s 后仍与目标机构的职位重名", p.Name, item.TargetName)
+			}
+			if utf8.RuneCountInString(item.TargetName) > positionNameMaxLength {
+				return nil, fmt.Errorf("职位 %s 重命名后超过 %d 个字符", p.Name, positionNameMaxLength)
+			}
+		}
+		if err := tx.Model(p).Updates(map[string]interface{}{"organization_id": target.ID, "name": item.TargetName}).Error; err != nil {
+			return nil, fmt.Errorf("移动职位 %s 失败", p.Name)
+		}
+		names[item.TargetName] = p.ID
+		resp.Positions = append(resp.Positions, item)
+	}
+	return positionMap, nil
+}
+
+// restructureAccounts 把机构的账号移动到目标机构，使用被合并职位的账号改为目标职位，在职账号从当天开始新的任职记录
+func restructureAccounts(tx *gorm.DB, org, target *model.Organization, positionMap map[uint]uint, reason string, resp *types2.OrganizationRestructureResp) error {
+	merged := make([]uint, 0, len(positionMap))
+	for id := range positionMap {
+		merged = append(merged, id)
+	}
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id = ?", org.ID)
+	if len(merged) > 0 {
+		query = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id = ? OR position_id IN ?", org.ID, merged)
+	}
+	var accounts []*model.Account
+	if err := query.Order("id").Find(&accounts).Error; err != nil {
+		return fmt.Errorf("查询机构账号失败")
+	}
+	// 被合并职位的在职账号计入目标职位的编制
+	adding := make(map[uint]int64)
+	for _, account := range accounts {
+		if mapped, ok := positionMap[account.PositionId]; ok && !account.IsLeave {
+			adding[mapped]++
+		}
+	}
+	for positionId, count := range adding {
+		if err := checkPositionQuota(tx, positionId, count); err != nil {
+			return err
+		}
+	}
+	checked := false
+	for _, account := range accounts {
+		item := &types2.RestructureAccount{
+			Id:                account.ID,
+			UserName:          account.UserName,
+			OldOrganizationId: account.OrganizationId,
+			OrganizationId:    account.OrganizationId,
+			OldPositionId:     account.PositionId,
+			PositionId:        account.PositionId,
+		}
+		if account.OrganizationId == org.ID {
+			if !checked {
+				if err := target.CheckAccounts(); err != nil {
+					return fmt.Errorf("目标机构%w", err)
+				}
+				checked = true
+			}
+			item.OrganizationId = target.ID
+		}
+		if mapped, ok := positionMap[account.PositionId]; ok {
+			item.PositionId = mapped
+		}
+		if err := tx.Model(&model.Account{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+			"organization_id": item.OrganizationId,
+			"position_id":     item.PositionId,
+		}).Error; err != nil {
+			return fmt.Errorf("移动账号 %s 失败", account.UserName)
+		}
+		// 离职账号已经结束任职，只修改归属
+		if !account.IsLeave {
+			// 尚未入职的账号从入职日期开始
+			startDate := today()
+			if compareDate(account.HireDate, startDate) > 0 {
+				startDate = account.HireDate
+			}
+			if err := recordAssignment(tx, &model.AccountAssignment{
+				AccountId:      account.ID,
+				OrganizationId: item.OrganizationId,
+				PositionId:     item.PositionId,
+				StartDate:      startDate,
+				Reason:         "机构" + reason,
+			}); err != nil {
+				return fmt.Errorf("记录账号 %s 的任职失败: %w", account.UserName, err)
+			}
+		}
+		resp.Accounts = append(resp.Accounts, item)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package logic
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	commonModel "github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var registerConcat sync.Once
+
+// newTestDB 每个测试使用独立的内存数据库，sqlite 没有 CONCAT，注册一个与 MySQL 行为一致的函数
+func newTestDB(t *testing.T) *gorm.DB {
+	registerConcat.Do(func() {
+		err := gosqlite.RegisterDeterministicScalarFunction("concat", -1, func(ctx *gosqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			var b strings.Builder
+			for _, arg := range args {
+				switch v := arg.(type) {
+				case nil:
+					return nil, nil
+				case []byte:
+					b.Write(v)
+				default:
+					fmt.Fprint(&b, v)
+				}
+			}
+			return b.String(), nil
+		})
+		require.NoError(t, err, "注册 concat 函数失败")
+	})
+	global.L = zap.NewNop()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err, "打开测试数据库失败")
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(
+		&model.Organization{}, &model.Position{}, &model.Account{}, &model.AccountAssignment{},
+		&model.AccountTransfer{}, &model.OrganizationManager{}, &model.OrganizationMove{},
+		&model.AccountOffboarding{}, &model.SecurityEvent{},
+	), "迁移测试表失败")
+	return db
+}
+
+// newTestContext 数据权限为全部数据的请求上下文
+func newTestContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", nil)
+	c.Set(dataScopeKey, &dataScope{all: true})
+	return c
+}
+
+// restructureFixture 公司下有研发部和产品部，研发部下有后端组，都是部门，研发部和产品部都有工程师职位
+type restructureFixture struct {
+	db      *gorm.DB
+	logic   *OrganizationLogic
+	rd      *model.Organization // 被合并的机构
+	product *model.Organization // 目标机构
+	backend *model.Organization
+	rdEng   *model.Position
+	rdPm    *model.Position
+	prodEng *model.Position
+}
+
+func newRestructureFixture(t *testing.T, productEngQuota int, productPositions ...string) *restructureFixture {
+	db := newTestDB(t)
+	f := &restructureFixture{db: db, logic: &OrganizationLogic{l: zap.NewNop(), db: db}}
+	company := &model.Organization{Name: "公司"}
+	require.NoError(t, db.Create(company).Error)
+	f.rd = &model.Organization{Name: "研发部", ParentId: company.ID}
+	require.NoError(t, db.Create(f.rd).Error)
+	f.product = &model.Organization{Name: "产品部", ParentId: company.ID}
+	require.NoError(t, db.Create(f.product).Error)
+	f.backend = &model.Organization{Name: "后端组", ParentId: f.rd.ID}
+	require.NoError(t, db.Create(f.backend).Error)
+
+	f.rdEng = &model.Position{Name: "工程师", OrganizationId: f.rd.ID}
+	f.rdPm = &model.Position{Name: "产品经理", OrganizationId: f.rd.ID}
+	f.prodEng = &model.Position{Name: "工程师", OrganizationId: f.product.ID, Quota: productEngQuota}
+	require.NoError(t, db.Create([]*model.Position{f.rdEng, f.rdPm, f.prodEng}).Error)
+	for _, name := range productPositions {
+		require.NoError(t, db.Create(&model.Position{Name: name, OrganizationId: f.product.ID}).Error)
+	}
+
+	hireDate := commonModel.DateTime{Time: time.Now().AddDate(-1, 0, 0)}
+	newAccount := func(name string, org *model.Organization, position *model.Position) {
+		account := &model.Account{
+			UserName:       name,
+			Account:        name,
+			Mobile:         name,
+			Email:          name + "@example.com",
+			WorkNumber:     name,
+			HireDate:       hireDate,
+			OrganizationId: org.ID,
+			PositionId:     position.ID,
+		}
+		require.NoError(t, db.Create(account).Error)
+	}
+	// 研发部有两名工程师和一名产品经理
+	newAccount("product-eng", f.product, f.prodEng)
+	newAccount("rd-eng-1", f.rd, f.rdEng)
+	newAccount("rd-eng-2", f.rd, f.rdEng)
+	newAccount("rd-pm", f.rd, f.rdPm)
+	return f
+}
+
+// accountPositions 账号当前的机构和职位
+func (f *restructureFixture) accountPositions(t *testing.T) map[string][2]uint {
+	var accounts []*model.Account
+	require.NoError(t, f.db.Find(&accounts).Error)
+	result := make(map[string][2]uint, len(accounts))
+	for _, account := range accounts {
+		result[account.Account] = [2]uint{account.OrganizationId, account.PositionId}
+	}
+	return result
+}
+
+func TestRestructurePositionConflict(t *testing.T) {
+	tests := []struct {
+		name     string
+		conflict string
+		existing []string // 目标机构已有的其他职位
+		wantErr  string
+		check    func(t *testing.T, f *restructureFixture, resp *types2.OrganizationRestructureResp)
+	}{
+		{
+			name:     "同名职位合并",
+			conflict: positionConflictMerge,
+			check: func(t *testing.T, f *restructureFixture, resp *types2.OrganizationRestructureResp) {
+				actions := map[uint]*types2.RestructurePosition{}
+				for _, p := range resp.Positions {
+					actions[p.Id] = p
+				}
+				assert.Equal(t, positionConflictMerge, actions[f.rdEng.ID].Action)
+				assert.Equal(t, f.prodEng.ID, actions[f.rdEng.ID].TargetId)
+				assert.Equal(t, "move", actions[f.rdPm.ID].Action)
+
+				var count int64
+				require.NoError(t, f.db.Model(&model.Position{}).Where("id = ?", f.rdEng.ID).Count(&count).Error)
+				assert.Zero(t, count, "被合并的职位应删除")
+				positions := f.accountPositions(t)
+				assert.Equal(t, [2]uint{f.product.ID, f.prodEng.ID}, positions["rd-eng-1"], "账号改为目标职位")
+				assert.Equal(t, [2]uint{f.product.ID, f.prodEng.ID}, positions["rd-eng-2"])
+				assert.Equal(t, [2]uint{f.product.ID, f.rdPm.ID}, positions["rd-pm"], "不冲突的职位随账号移动")
+			},
+		},
+		{
+			name:     "同名职位重命名",
+			conflict: positionConflictRename,
+			check: func(t *testing.T, f *restructureFixture, resp *types2.OrganizationRestructureResp) {
+				var renamed model.Position
+				require.NoError(t, f.db.Where("id = ?", f.rdEng.ID).First(&renamed).Error)
+				assert.Equal(t, "工程师（研发部）", renamed.Name)
+				assert.Equal(t, f.product.ID, renamed.OrganizationId)
+				positions := f.accountPositions(t)
+				assert.Equal(t, [2]uint{f.product.ID, f.rdEng.ID}, positions["rd-eng-1"], "账号沿用重命名后的职位")
+			},
+		},
+		{
+			name:     "重命名后仍然重名",
+			conflict: positionConflictRename,
+			existing: []string{"工程师（研发部）"},
+			wantErr:  "重命名为 工程师（研发部） 后仍与目标机构的职位重名",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newRestructureFixture(t, 0, tt.existing...)
+			resp, err := f.logic.Merge(newTestContext(), types.SearchId{Id: f.rd.ID}, &types2.OrganizationRestructureReq{
+				TargetId: f.product.ID,
+				Conflict: tt.conflict,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Equal(t, f.rd.ID, f.accountPositions(t)["rd-eng-1"][0], "失败时回滚")
+				return
+			}
+			require.NoError(t, err)
+			var backend model.Organization
+			require.NoError(t, f.db.Where("id = ?", f.backend.ID).First(&backend).Error)
+			assert.Equal(t, f.product.ID, backend.ParentId, "下级机构挂到目标机构下")
+			assert.Equal(t, f.product.SubtreePath(), backend.Path, "下级机构的祖先路径同步修改")
+			var count int64
+			require.NoError(t, f.db.Model(&model.Organization{}).Where("id = ?", f.rd.ID).Count(&count).Error)
+			assert.Zero(t, count, "被合并的机构应删除")
+			tt.check(t, f, resp)
+		})
+	}
+}
+
+func TestRestructurePositionQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		quota   int
+		wantErr bool
+	}{
+		{name: "编制足够", quota: 3},
+		{name: "超出编制", quota: 2, wantErr: true},
+		{name: "不限编制", quota: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newRestructureFixture(t, tt.quota)
+			before := f.accountPositions(t)
+			_, err := f.logic.Merge(newTestContext(), types.SearchId{Id: f.rd.ID}, &types2.OrganizationRestructureReq{
+				TargetId: f.product.ID,
+				Conflict: positionConflictMerge,
+			})
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, errPositionQuota), "应返回编制已满: %v", err)
+			assert.Equal(t, before, f.accountPositions(t), "超出编制时不修改账号")
+			var positions int64
+			require.NoError(t, f.db.Model(&model.Position{}).Where("organization_id = ?", f.rd.ID).Count(&positions).Error)
+			assert.EqualValues(t, 2, positions, "超出编制时不移动职位")
+		})
+	}
+}
+
+func TestRestructureDryRun(t *testing.T) {
+	for _, merge := range []bool{true, false} {
+		t.Run(fmt.Sprintf("merge=%v", merge), func(t *testing.T) {
+			f := newRestructureFixture(t, 0)
+			before := f.accountPositions(t)
+			var orgsBefore []*model.Organization
+			require.NoError(t, f.db.Order("id").Find(&orgsBefore).Error)
+
+			req := &types2.OrganizationRestructureReq{TargetId: f.product.ID, DryRun: true}
+			var resp *types2.OrganizationRestructureResp
+			var err error
+			if merge {
+				resp, err = f.logic.Merge(newTestContext(), types.SearchId{Id: f.rd.ID}, req)
+			} else {
+				resp, err = f.logic.Dissolve(newTestContext(), types.SearchId{Id: f.rd.ID}, req)
+			}
+			require.NoError(t, err)
+			assert.True(t, resp.DryRun)
+			assert.Len(t, resp.Organizations, 1, "预览包含下级机构")
+			assert.Len(t, resp.Positions, 2, "预览包含职位")
+			assert.Len(t, resp.Accounts, 3, "预览包含账号")
+
+			assert.Equal(t, before, f.accountPositions(t), "预览不修改账号")
+			var orgsAfter []*model.Organization
+			require.NoError(t, f.db.Order("id").Find(&orgsAfter).Error)
+			require.Len(t, orgsAfter, len(orgsBefore), "预览不删除机构")
+			for i := range orgsBefore {
+				assert.Equal(t, orgsBefore[i].ParentId, orgsAfter[i].ParentId, "预览不移动机构 %s", orgsBefore[i].Name)
+				assert.Equal(t, orgsBefore[i].Path, orgsAfter[i].Path)
+			}
+			var moves, assignments int64
+			require.NoError(t, f.db.Model(&model.OrganizationMove{}).Count(&moves).Error)
+			assert.Zero(t, moves, "预览不记录机构移动")
+			require.NoError(t, f.db.Model(&model.AccountAssignment{}).Count(&assignments).Error)
+			assert.EqualValues(t, 4, assignments, "预览不记录任职")
+		})
+	}
+}
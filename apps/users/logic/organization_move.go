@@ -0,0 +1,146 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/sql"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// Move 将机构及其全部下级机构移动到新的上级机构下
+func (o *OrganizationLogic) Move(c *gin.Context, id types.SearchId, req *types2.OrganizationMoveReq) (*model.Organization, error) {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.checkOrganization(id.Id); err != nil {
+		return nil, err
+	}
+	if err := scope.checkOrganization(req.ParentId); err != nil {
+		return nil, err
+	}
+	var org *model.Organization
+	err = o.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		org, err = o.move(c, tx, id.Id, req.ParentId)
+		return err
+	})
+	if err != nil {
+		o.l.Error(fmt.Sprintf("移动机构失败, id: %d, parentId: %d, error: %s", id.Id, req.ParentId, err.Error()))
+		return nil, err
+	}
+	orgCache.invalidate(c)
+	return org, nil
+}
+
+// move 在事务内修改上级机构并重新计算整棵子树的层级，拒绝移动到自身或下级机构、机构类型不允许的上级机构以及超过最大层级
+func (o *OrganizationLogic) move(c *gin.Context, tx *gorm.DB, id, parentId uint) (*model.Organization, error) {
+	// 锁定机构表，避免并发移动形成环
+	var orgs []*model.Organization
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&orgs).Error; err != nil {
+		return nil, fmt.Errorf("查询机构信息失败")
+	}
+	t := tree.Build(orgs, nil)
+	org, ok := t.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("机构不存在")
+	}
+	if org.ParentId == 0 {
+		return nil, fmt.Errorf("主节点不允许移动")
+	}
+	parent, ok := t.Get(parentId)
+	if !ok {
+		return nil, fmt.Errorf("上级机构不存在")
+	}
+	if org.ParentId == parentId {
+		return org, nil
+	}
+	// 子树包含机构自身
+	var subtree []uint
+	for _, node := range tree.Flatten([]*model.Organization{org}) {
+		if node.ID == parentId {
+			return nil, fmt.Errorf("不能移动到自身或下级机构下")
+		}
+		subtree = append(subtree, node.ID)
+	}
+	// 机构类型必须允许挂在新的上级机构下，子树内的上下级关系不变，只需要校验每个机构移动后的层级
+	if err := org.CheckParent(parent); err != nil {
+		return nil, err
+	}
+	delta := parent.Level + 1 - org.Level
+	for _, node := range tree.Flatten([]*model.Organization{org}) {
+		if node.Level+delta > node.MaxLevel() {
+			return nil, fmt.Errorf("移动后机构 %s 的层级超过 %d 级", node.Name, node.MaxLevel())
+		}
+	}
+
+	move := &model.OrganizationMove{
+		OrganizationId: id,
+		OldParentId:    org.ParentId,
+		NewParentId:    parentId,
+		OldPath:        organizationPath(t, id),
+	}
+	oldPrefix, newPath := org.SubtreePath(), parent.SubtreePath()
+	if err := tx.Model(&model.Organization{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"parent_id": parentId, "path": newPath}).Error; err != nil {
+		return nil, fmt.Errorf("修改上级机构失败")
+	}
+	// 下级机构的祖先路径替换公共前缀
+	org.Path = newPath
+	if err := tx.Model(&model.Organization{}).Where("path LIKE ?", oldPrefix+"%").
+		Update("path", gorm.Expr("CONCAT(?, SUBSTRING(path, ?))", org.SubtreePath(), len(oldPrefix)+1)).Error; err != nil {
+		return nil, fmt.Errorf("修改下级机构路径失败")
+	}
+	if delta != 0 {
+		if err := tx.Model(&model.Organization{}).Where("id IN ?", subtree).Update("level", gorm.Expr("level + ?", delta)).Error; err != nil {
+			return nil, fmt.Errorf("修改机构层级失败")
+		}
+	}
+	org.ParentId = parentId
+	org.Level += delta
+	move.NewPath = organizationPath(t, id)
+	if claims, err := utils.GetClaims(c); err == nil {
+		move.OperatorId = claims.AccountId
+		move.Operator = claims.Account
+	}
+	if err := tx.Create(move).Error; err != nil {
+		return nil, fmt.Errorf("记录机构移动失败")
+	}
+	o.l.Info(fmt.Sprintf("移动机构 %d: %s -> %s", id, move.OldPath, move.NewPath))
+	return org, nil
+}
+
+// organizationPath 拼接从主体机构到当前机构的名称路径
+func organizationPath(t *tree.Tree[*model.Organization], id uint) string {
+	var names []string
+	for _, org := range t.Path(id) {
+		names = append(names, org.Name)
+	}
+	return strings.Join(names, "/")
+}
+
+// Moves 分页查询机构的移动记录
+func (o *OrganizationLogic) Moves(c *gin.Context, id types.SearchId, query types2.OrganizationMoveQueryReq) (*types.QueryResponse, error) {
+	scope, err := o.dataScope(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.checkOrganization(id.Id); err != nil {
+		return nil, err
+	}
+	var list []*model.OrganizationMove
+	db := o.db.WithContext(c).Model(&model.OrganizationMove{}).Where("organization_id = ?", id.Id).
+		Order(fmt.Sprintf("id %s", query.Sort))
+	queryRes, err := sql.GetQueryResponse(db, query.Pagination, list)
+	if err != nil {
+		o.l.Error(fmt.Sprintf("查询机构移动记录失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询机构移动记录失败")
+	}
+	return queryRes, nil
+}
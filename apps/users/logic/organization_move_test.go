@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+)
+
+// moveFixture 在 restructureFixture 的基础上，后端组下有存储组，产品部下有设计组、视觉组，共 4 级
+type moveFixture struct {
+	*restructureFixture
+	storage *model.Organization
+	design  *model.Organization
+	visual  *model.Organization
+}
+
+func newMoveFixture(t *testing.T) *moveFixture {
+	f := &moveFixture{restructureFixture: newRestructureFixture(t, 0)}
+	f.storage = &model.Organization{Name: "存储组", ParentId: f.backend.ID}
+	require.NoError(t, f.db.Create(f.storage).Error)
+	f.design = &model.Organization{Name: "设计组", ParentId: f.product.ID}
+	require.NoError(t, f.db.Create(f.design).Error)
+	f.visual = &model.Organization{Name: "视觉组", ParentId: f.design.ID}
+	require.NoError(t, f.db.Create(f.visual).Error)
+	return f
+}
+
+// orgs 按名称返回机构当前的上级、路径和层级
+func (f *moveFixture) orgs(t *testing.T) map[string]*model.Organization {
+	var list []*model.Organization
+	require.NoError(t, f.db.Find(&list).Error)
+	result := make(map[string]*model.Organization, len(list))
+	for _, org := range list {
+		result[org.Name] = org
+	}
+	return result
+}
+
+func TestOrganizationMove(t *testing.T) {
+	tests := []struct {
+		name    string
+		org     func(f *moveFixture) *model.Organization
+		parent  func(f *moveFixture) *model.Organization
+		wantErr string
+		check   func(t *testing.T, f *moveFixture, orgs map[string]*model.Organization)
+	}{
+		{
+			name:    "移动到自身",
+			org:     func(f *moveFixture) *model.Organization { return f.rd },
+			parent:  func(f *moveFixture) *model.Organization { return f.rd },
+			wantErr: "不能移动到自身或下级机构下",
+		},
+		{
+			name:    "移动到下级机构",
+			org:     func(f *moveFixture) *model.Organization { return f.rd },
+			parent:  func(f *moveFixture) *model.Organization { return f.storage },
+			wantErr: "不能移动到自身或下级机构下",
+		},
+		{
+			name:    "超过最大层级",
+			org:     func(f *moveFixture) *model.Organization { return f.rd },
+			parent:  func(f *moveFixture) *model.Organization { return f.visual },
+			wantErr: "移动后机构 后端组 的层级超过 5 级",
+		},
+		{
+			name:   "深层子树的路径和层级",
+			org:    func(f *moveFixture) *model.Organization { return f.backend },
+			parent: func(f *moveFixture) *model.Organization { return f.design },
+			check: func(t *testing.T, f *moveFixture, orgs map[string]*model.Organization) {
+				backend, storage := orgs["后端组"], orgs["存储组"]
+				assert.Equal(t, f.design.ID, backend.ParentId)
+				assert.Equal(t, f.design.SubtreePath(), backend.Path, "机构自身的路径")
+				assert.Equal(t, 4, backend.Level)
+				assert.Equal(t, backend.SubtreePath(), storage.Path, "下级机构的路径替换公共前缀")
+				assert.Equal(t, 5, storage.Level, "下级机构的层级随之调整")
+				assert.Equal(t, f.rd.Path, orgs["研发部"].Path, "原上级机构不受影响")
+				assert.Equal(t, f.visual.Path, orgs["视觉组"].Path, "新上级机构的其他下级不受影响")
+			},
+		},
+		{
+			name:   "移动记录",
+			org:    func(f *moveFixture) *model.Organization { return f.backend },
+			parent: func(f *moveFixture) *model.Organization { return f.product },
+			check: func(t *testing.T, f *moveFixture, orgs map[string]*model.Organization) {
+				storage := orgs["存储组"]
+				assert.Equal(t, fmt.Sprintf("%s%d/", f.product.SubtreePath(), f.backend.ID), storage.Path)
+				assert.Equal(t, 4, storage.Level)
+				var moves []*model.OrganizationMove
+				require.NoError(t, f.db.Find(&moves).Error)
+				require.Len(t, moves, 1)
+				assert.Equal(t, f.backend.ID, moves[0].OrganizationId)
+				assert.Equal(t, f.rd.ID, moves[0].OldParentId)
+				assert.Equal(t, f.product.ID, moves[0].NewParentId)
+				assert.Equal(t, "公司/研发部/后端组", moves[0].OldPath)
+				assert.Equal(t, "公司/产品部/后端组", moves[0].NewPath)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMoveFixture(t)
+			before := f.orgs(t)
+			org, parent := tt.org(f), tt.parent(f)
+			_, err := f.logic.Move(newTestContext(), types.SearchId{Id: org.ID}, &types2.OrganizationMoveReq{ParentId: parent.ID})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				after := f.orgs(t)
+				for name, org := range before {
+					assert.Equal(t, [3]interface{}{org.ParentId, org.Path, org.Level}, [3]interface{}{after[name].ParentId, after[name].Path, after[name].Level}, "失败时 %s 不变", name)
+				}
+				var count int64
+				require.NoError(t, f.db.Model(&model.OrganizationMove{}).Count(&count).Error)
+				assert.Zero(t, count, "失败时不记录移动")
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, f, f.orgs(t))
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"gorm.io/gorm"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	passwordResetKeyPrefix        = "ikubexjob:password_reset:"         // 重置令牌 ikubexjob:password_reset:<令牌摘要> -> 账号ID
+	passwordResetAccountKeyPrefix = "ikubexjob:password_reset:account:" // 账号当前有效的令牌摘要，用于作废旧令牌
+	passwordResetResendInterval   = time.Minute                         // 同一账号重复发送的最小间隔
+	passwordResetMailTimeout      = 30 * time.Second
+)
+
+var errPasswordResetInvalid = errors.New("重置链接无效或已过期，请重新找回密码")
+
+func passwordResetKey(tokenHash string) string {
+	return passwordResetKeyPrefix + tokenHash
+}
+
+func passwordResetAccountKey(accountId uint) string {
+	return fmt.Sprintf("%s%d", passwordResetAccountKeyPrefix, accountId)
+}
+
+func passwordResetTTL() time.Duration {
+	if global.C.PasswordReset.TTL <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(global.C.PasswordReset.TTL) * time.Minute
+}
+
+// ForgotPassword 按账号或邮箱发送重置密码链接
+// 无论账号是否存在都返回成功，避免通过接口枚举账号
+func (l *AccountLogic) ForgotPassword(c *gin.Context, req *types2.AccountForgotPasswordReq) error {
+	if global.RDB == nil || global.Mailer == nil {
+		return fmt.Errorf("找回密码未启用，请联系管理员")
+	}
+	// 账号和邮箱是不同的唯一列，同一输入可能分别匹配两个账号，优先按账号精确匹配
+	var account model.Account
+	err := l.db.WithContext(c).Where("account = ?", req.Account).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = l.db.WithContext(c).Where("email = ?", req.Account).First(&account).Error
+	}
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			l.l.Error(fmt.Sprintf("查询找回密码账号失败: %s", err.Error()))
+			return fmt.Errorf("找回密码失败，请稍后重试")
+		}
+		l.l.Info(fmt.Sprintf("找回密码账号不存在: %s", req.Account))
+		securityEventLogic.Record(c, 0, req.Account, model.EventPasswordForgot, false, "账号不存在")
+		return nil
+	}
+	if account.IsDisabled || account.IsLeave {
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventPasswordForgot, false, "账号已禁用或离职")
+		return nil
+	}
+	rdb := global.RDB.GetClient()
+	ttl := passwordResetTTL()
+	accountKey := passwordResetAccountKey(account.ID)
+	// 限制发送频率，防止被用来轰炸邮箱
+	if remain, err := rdb.TTL(c, accountKey).Result(); err == nil && remain > ttl-passwordResetResendInterval {
+		securityEventLogic.Record(c, account.ID, account.Account, model.EventPasswordForgot, false, "发送过于频繁")
+		return nil
+	}
+	token, err := utils.GenerateResetToken()
+	if err != nil {
+		return err
+	}
+	tokenHash := utils.HashToken(token)
+	// 作废之前发送的令牌，同一账号只有最新的链接有效
+	if old, err := rdb.Get(c, accountKey).Result(); err == nil {
+		rdb.Del(c, passwordResetKey(old))
+	}
+	pipe := rdb.TxPipeline()
+	pipe.Set(c, passwordResetKey(tokenHash), account.ID, ttl)
+	pipe.Set(c, accountKey, tokenHash, ttl)
+	if _, err := pipe.Exec(c); err != nil {
+		l.l.Error(fmt.Sprintf("保存重置令牌失败: %s", err.Error()))
+		return nil
+	}
+	link := passwordResetLink(token)
+	body := fmt.Sprintf("您好 %s：\n\n请在 %d 分钟内打开以下链接重置密码，链接只能使用一次：\n%s\n\n如果不是您本人操作，请忽略本邮件。",
+		account.UserName, int(ttl.Minutes()), link)
+	// 异步发送，避免响应时间暴露账号是否存在
+	go func(email string) {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetMailTimeout)
+		defer cancel()
+		if err := global.Mailer.Send(ctx, []string{email}, "重置密码", body); err != nil {
+			l.l.Error(fmt.Sprintf("发送重置密码邮件失败: %s", err.Error()))
+		}
+	}(account.Email)
+	securityEventLogic.Record(c, account.ID, account.Account, model.EventPasswordForgot, true, "")
+	return nil
+}
+
+func passwordResetLink(token string) string {
+	base := global.C.PasswordReset.URL
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "token=" + url.QueryEscape(token)
+}
+
+// ResetPasswordByToken 使用邮件中的令牌重置密码，成功后吊销账号的全部会话
+func (l *AccountLogic) ResetPasswordByToken(c *gin.Context, req *types2.AccountResetPasswordByTokenReq) error {
+	if global.RDB == nil {
+		return fmt.Errorf("找回密码未启用，请联系管理员")
+	}
+	newPassword, err := utils.DecodeBase64Password(req.NewPassword)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("解密密码失败: %s", err.Error()))
+		return fmt.Errorf("解密密码失败")
+	}
+	if !utils.CheckPasswordComplexity(newPassword) {
+		return fmt.Errorf("不满足密码复杂度要求，要求: 至少 12 位包含数字、字母、特殊字符")
+	}
+	rdb := global.RDB.GetClient()
+	// 读取后立即删除，保证令牌只能使用一次
+	value, err := rdb.GetDel(c, passwordResetKey(utils.HashToken(req.Token))).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			l.l.Error(fmt.Sprintf("查询重置令牌失败: %s", err.Error()))
+		}
+		return errPasswordResetInvalid
+	}
+	accountId, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return errPasswordResetInvalid
+	}
+	rdb.Del(c, passwordResetAccountKey(uint(accountId)))
+
+	var account model.Account
+	if err := l.db.WithContext(c).Where("id = ?", accountId).First(&account).Error; err != nil {
+		return errPasswordResetInvalid
+	}
+	if account.IsDisabled || account.IsLeave {
+		return errPasswordResetInvalid
+	}
+	if err := account.SetPassword(newPassword); err != nil {
+		l.l.Error(fmt.Sprintf("设置密码失败: %s", err.Error()))
+		return fmt.Errorf("设置密码失败")
+	}
+	updates := map[string]interface{}{"password": account.Password, "is_change_password": false}
+	if err := l.db.WithContext(c).Model(&model.Account{}).Where("id = ?", account.ID).Updates(updates).Error; err != nil {
+		l.l.Error(fmt.Sprintf("重置密码失败: %s", err.Error()))
+		return fmt.Errorf("重置密码失败")
+	}
+	if err := sessionLogic.RevokeAll(c, account.ID); err != nil {
+		l.l.Error(fmt.Sprintf("吊销账号 %d 的会话失败: %s", account.ID, err.Error()))
+	}
+	securityEventLogic.Record(c, account.ID, account.Account, model.EventPasswordReset, true, "邮件找回")
+	return nil
+}
@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/yanshicheng/ikube-gin-xjob/apps/users"
@@ -11,42 +12,69 @@ import (
 	"github.com/yanshicheng/ikube-gin-xjob/global"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var _ service.PositionService = (*PositionLogic)(nil)
 
 var positionLogic = &PositionLogic{}
 
+// positionTable 职位表名，查询在职账号数量时需要限定列所属的表
+var positionTable = (&model.Position{}).TableName()
+
+var errPositionQuota = errors.New("职位编制已满")
+
 type PositionLogic struct {
 	l  *zap.Logger
 	db *gorm.DB
 }
 
+// List 查询职位以及每个职位的在职账号数量，不指定机构时查询数据权限范围内的全部职位
 func (o *PositionLogic) List(c *gin.Context, search types2.PositionListSearchReq) ([]*model.Position, error) {
-	var list []*model.Position
+	scope, err := loadDataScope(c, o.db)
+	if err != nil {
+		o.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	db := o.db.WithContext(c).Model(&model.Position{}).Scopes(scope.Positions).
+		Select(fmt.Sprintf("%s.*, (SELECT COUNT(*) FROM %s a WHERE a.position_id = %s.id AND a.is_leave = ? AND a.deleted_at = 0) AS account_count",
+			positionTable, accountTable, positionTable), false)
 	if search.OrganizationId != 0 {
-		if err := o.db.WithContext(c).Model(&model.Position{}).Where("organization_id = ?", search.OrganizationId).Find(&list).Error; err != nil {
-			o.l.Error(fmt.Sprintf("获取机构职位信息失败: %s， ID: %d", err.Error(), search.OrganizationId))
-			return nil, fmt.Errorf("获取机构职位信息失败,ID: %d", search.OrganizationId)
-		}
-	} else {
-		if err := o.db.WithContext(c).Model(&model.Position{}).Find(&list).Error; err != nil {
-			o.l.Error(fmt.Sprintf("获取职位所有信息失败: %s", err.Error()))
-			return nil, fmt.Errorf("获取职位所有信息失败,查询失败")
+		ids := []uint{search.OrganizationId}
+		if search.IncludeSubOrganizations {
+			if ids, err = organizationDescendantIds(c, o.db, search.OrganizationId); err != nil {
+				o.l.Error(fmt.Sprintf("查询下级机构失败: %s", err.Error()))
+				return nil, fmt.Errorf("查询下级机构失败")
+			}
 		}
+		db = db.Where(positionTable+".organization_id IN ?", ids)
+	}
+	if search.Grade != "" {
+		db = db.Where(positionTable+".grade = ?", search.Grade)
+	}
+	if search.Family != "" {
+		db = db.Where(positionTable+".family = ?", search.Family)
+	}
+	var list []*model.Position
+	if err := db.Order(positionTable + ".organization_id, " + positionTable + ".id").Find(&list).Error; err != nil {
+		o.l.Error(fmt.Sprintf("获取职位信息失败: %s， 机构ID: %d", err.Error(), search.OrganizationId))
+		return nil, fmt.Errorf("获取职位信息失败")
 	}
 	return list, nil
 }
 func (o *PositionLogic) Create(c *gin.Context, req *model.Position) error {
+	if err := o.checkOrganization(c, req.OrganizationId); err != nil {
+		return err
+	}
 	// 创建职位，先查询机构是否存在
 	var org model.Organization
 	if err := o.db.WithContext(c).Model(&model.Organization{}).Where("id = ?", req.OrganizationId).First(&org).Error; err != nil {
 		o.l.Error(fmt.Sprintf("查询机构失败无法创建职位: %s， ID: %d", err.Error(), req.OrganizationId))
 		return fmt.Errorf("查询机构失败无法创建职位,ID: %d", req.OrganizationId)
 	}
-	//  主体机构不允许创建职位
-	if org.ParentId == 0 {
-		return fmt.Errorf("创建职位失败，主体机构不允许创建职位")
+	// 机构类型必须允许创建职位
+	if err := org.CheckPositions(); err != nil {
+		return fmt.Errorf("创建职位失败，%w", err)
 	}
 	if err := o.db.WithContext(c).Model(&model.Position{}).Create(&req).Error; err != nil {
 		o.l.Error(fmt.Sprintf("创建职位失败: %s", err.Error()))
@@ -55,8 +83,22 @@ func (o *PositionLogic) Create(c *gin.Context, req *model.Position) error {
 	return nil
 }
 func (o *PositionLogic) Put(c *gin.Context, search types.SearchId, req *model.Position) (*model.Position, error) {
-	// 只允许修改名称
-	updates := map[string]interface{}{"name": req.Name}
+	if err := o.checkPosition(c, search.Id); err != nil {
+		return nil, err
+	}
+	// 编制不能少于当前在职人数
+	if req.Quota > 0 {
+		var count int64
+		if err := o.db.WithContext(c).Model(&model.Account{}).Where("position_id = ? AND is_leave = ?", search.Id, false).Count(&count).Error; err != nil {
+			o.l.Error(fmt.Sprintf("查询职位在职人数失败: %s", err.Error()))
+			return nil, fmt.Errorf("查询职位在职人数失败")
+		}
+		if int64(req.Quota) < count {
+			return nil, fmt.Errorf("编制不能少于当前在职人数 %d", count)
+		}
+	}
+	// 只允许修改名称、职级、职族和编制，不能修改所属机构
+	updates := map[string]interface{}{"name": req.Name, "grade": req.Grade, "family": req.Family, "quota": req.Quota}
 	if err := o.db.WithContext(c).Model(&model.Position{}).Where("id = ?", search.Id).Updates(updates).Error; err != nil {
 		o.l.Error(fmt.Sprintf("更新职位失败: %s", err.Error()))
 		return nil, fmt.Errorf("更新职位失败: %d", search.Id)
@@ -72,6 +114,9 @@ func (o *PositionLogic) Put(c *gin.Context, search types.SearchId, req *model.Po
 }
 
 func (o *PositionLogic) Delete(c *gin.Context, id types.SearchId) error {
+	if err := o.checkPosition(c, id.Id); err != nil {
+		return err
+	}
 	// 先判断有没有用户占用职位
 	var count int64
 	if err := o.db.WithContext(c).Model(&model.Account{}).Where("position_id = ?", id.Id).Count(&count).Error; err != nil {
@@ -100,6 +145,114 @@ func (o *PositionLogic) Delete(c *gin.Context, id types.SearchId) error {
 	return nil
 }
 
+// Reassign 把职位在数据权限范围内的全部账号调整到目标职位，账号所属机构不变，在职账号从当天开始新的任职记录
+func (o *PositionLogic) Reassign(c *gin.Context, id types.SearchId, req *types2.PositionReassignReq) (*types2.PositionReassignResp, error) {
+	if id.Id == req.TargetId {
+		return nil, fmt.Errorf("目标职位不能是职位自身")
+	}
+	if err := o.checkPosition(c, id.Id); err != nil {
+		return nil, err
+	}
+	if err := o.checkPosition(c, req.TargetId); err != nil {
+		return nil, err
+	}
+	scope, err := loadDataScope(c, o.db)
+	if err != nil {
+		o.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询数据权限失败")
+	}
+	resp := &types2.PositionReassignResp{PositionId: id.Id, TargetId: req.TargetId, Accounts: []uint{}}
+	err = o.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var accounts []*model.Account
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope.WritableAccounts).
+			Where(accountTable+".position_id = ?", id.Id).Order(accountTable + ".id").Find(&accounts).Error; err != nil {
+			return err
+		}
+		var active int64
+		for _, account := range accounts {
+			if !account.IsLeave {
+				active++
+			}
+		}
+		if err := checkPositionQuota(tx, req.TargetId, active); err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			if err := tx.Model(&model.Account{}).Where("id = ?", account.ID).Update("position_id", req.TargetId).Error; err != nil {
+				return err
+			}
+			resp.Accounts = append(resp.Accounts, account.ID)
+			// 离职账号已经结束任职，只修改职位
+			if account.IsLeave {
+				continue
+			}
+			startDate := today()
+			if compareDate(account.HireDate, startDate) > 0 {
+				startDate = account.HireDate
+			}
+			if err := recordAssignment(tx, &model.AccountAssignment{
+				AccountId:      account.ID,
+				OrganizationId: account.OrganizationId,
+				PositionId:     req.TargetId,
+				StartDate:      startDate,
+				Reason:         "职位调整",
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		o.l.Error(fmt.Sprintf("调整职位账号失败, id: %d, targetId: %d, error: %s", id.Id, req.TargetId, err.Error()))
+		if errors.Is(err, errPositionQuota) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("调整职位账号失败")
+	}
+	o.l.Info(fmt.Sprintf("职位 %d 的 %d 个账号调整到职位 %d", id.Id, len(resp.Accounts), req.TargetId))
+	return resp, nil
+}
+
+// checkPositionQuota 职位设置了编制时，在职账号数量加上新增的数量不能超过编制
+// 锁定职位记录，同一职位的并发新增按顺序校验
+func checkPositionQuota(tx *gorm.DB, positionId uint, adding int64) error {
+	var position model.Position
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", positionId).First(&position).Error; err != nil {
+		return fmt.Errorf("职位 %d 不存在或已删除", positionId)
+	}
+	if position.Quota == 0 || adding == 0 {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&model.Account{}).Where("position_id = ? AND is_leave = ?", positionId, false).Count(&count).Error; err != nil {
+		return err
+	}
+	if count+adding > int64(position.Quota) {
+		return fmt.Errorf("%w: 职位 %s 编制 %d 人，当前在职 %d 人", errPositionQuota, position.Name, position.Quota, count)
+	}
+	return nil
+}
+
+// checkOrganization 只能管理数据权限范围内机构的职位
+func (o *PositionLogic) checkOrganization(c *gin.Context, organizationId uint) error {
+	scope, err := loadDataScope(c, o.db)
+	if err != nil {
+		o.l.Error(fmt.Sprintf("查询数据权限失败: %s", err.Error()))
+		return fmt.Errorf("查询数据权限失败")
+	}
+	return scope.checkOrganization(organizationId)
+}
+
+// checkPosition 校验职位所属机构在数据权限范围内
+func (o *PositionLogic) checkPosition(c *gin.Context, id uint) error {
+	var position model.Position
+	if err := o.db.WithContext(c).Select("id", "organization_id").Where("id = ?", id).First(&position).Error; err != nil {
+		o.l.Error(fmt.Sprintf("查询职位失败: %s", err.Error()))
+		return fmt.Errorf("查询职位失败,ID: %d", id)
+	}
+	return o.checkOrganization(c, position.OrganizationId)
+}
+
 // 只需要保证 全局对象Config和全局Logger已经加载完成
 func (o *PositionLogic) Config() {
 	o.l = global.L.Named(users.AppName).Named(users.AppPosition).Named("logic")
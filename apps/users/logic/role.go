@@ -0,0 +1,142 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	upmsModel "github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
+	"gorm.io/gorm"
+	"sort"
+)
+
+// accountGroupIds 查询账号所在的用户组：直接加入的用户组以及它们的全部上级用户组，已删除的用户组不生效
+func accountGroupIds(ctx context.Context, db *gorm.DB, accountId uint) ([]uint, error) {
+	groupTable := (&model.Group{}).TableName()
+	groupAccountTable := (&model.GroupAccount{}).TableName()
+	groupChildTable := (&model.GroupChild{}).TableName()
+	var ids []uint
+	if err := db.WithContext(ctx).Model(&model.GroupAccount{}).
+		Joins(fmt.Sprintf("JOIN %s g ON g.id = %s.group_id AND g.deleted_at = 0", groupTable, groupAccountTable)).
+		Where(groupAccountTable+".account_id = ?", accountId).
+		Pluck(groupAccountTable+".group_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	visited := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		visited[id] = true
+	}
+	// 逐层向上查找，已访问的用户组不再展开
+	for frontier := ids; len(frontier) > 0; {
+		var parents []uint
+		if err := db.WithContext(ctx).Model(&model.GroupChild{}).
+			Joins(fmt.Sprintf("JOIN %s g ON g.id = %s.group_id AND g.deleted_at = 0", groupTable, groupChildTable)).
+			Where(groupChildTable+".child_id IN ?", frontier).
+			Pluck(groupChildTable+".group_id", &parents).Error; err != nil {
+			return nil, err
+		}
+		frontier = nil
+		for _, id := range parents {
+			if !visited[id] {
+				visited[id] = true
+				ids = append(ids, id)
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// accountRoleIds 查询账号生效的角色：直接绑定的角色以及所在用户组绑定的角色
+func accountRoleIds(ctx context.Context, db *gorm.DB, accountId uint) ([]uint, error) {
+	var ids []uint
+	if err := db.WithContext(ctx).Model(&model.AccountRole{}).Where("account_id = ?", accountId).
+		Pluck("role_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	groupIds, err := accountGroupIds(ctx, db, accountId)
+	if err != nil {
+		return nil, err
+	}
+	if len(groupIds) > 0 {
+		var groupRoleIds []uint
+		if err := db.WithContext(ctx).Model(&model.GroupRole{}).Where("group_id IN ?", groupIds).
+			Pluck("role_id", &groupRoleIds).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range groupRoleIds {
+			if !containsId(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// accountRoleNames 查询账号生效的角色名称
+func accountRoleNames(ctx context.Context, db *gorm.DB, accountId uint) ([]string, error) {
+	roles, err := accountRoles(ctx, db, accountId)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names, nil
+}
+
+// accountRoles 查询账号生效的角色，已删除的角色不生效
+func accountRoles(ctx context.Context, db *gorm.DB, accountId uint) ([]*upmsModel.Role, error) {
+	roles := []*upmsModel.Role{}
+	ids, err := accountRoleIds(ctx, db, accountId)
+	if err != nil || len(ids) == 0 {
+		return roles, err
+	}
+	err = db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&roles).Error
+	return roles, err
+}
+
+// accountMenus 查询账号角色可见的菜单和授权的按钮权限标识，包含角色从上级角色继承的部分，
+// 按钮不出现在菜单树中，只授权了子菜单或按钮时补齐上级菜单后组装为树形结构
+func accountMenus(ctx context.Context, db *gorm.DB, accountId uint) ([]*upmsModel.Menu, []string, error) {
+	menus, permissions := []*upmsModel.Menu{}, []string{}
+	roleIds, err := accountRoleIds(ctx, db, accountId)
+	if err != nil || len(roleIds) == 0 {
+		return menus, permissions, err
+	}
+	if roleIds, err = upmsModel.RoleAncestorIds(ctx, db, roleIds); err != nil {
+		return nil, nil, err
+	}
+	var menuIds []uint
+	err = db.WithContext(ctx).Model(&upmsModel.RoleMenu{}).Where("role_id IN ?", roleIds).
+		Distinct().Pluck("menu_id", &menuIds).Error
+	if err != nil || len(menuIds) == 0 {
+		return menus, permissions, err
+	}
+	var all []*upmsModel.Menu
+	if err := db.WithContext(ctx).Order("order_no ASC, id ASC").Find(&all).Error; err != nil {
+		return nil, nil, err
+	}
+	granted := make(map[uint]bool, len(menuIds))
+	for _, id := range menuIds {
+		granted[id] = true
+	}
+	pages := make([]*upmsModel.Menu, 0, len(all))
+	for _, menu := range all {
+		if menu.Type != upmsModel.MenuTypeButton {
+			pages = append(pages, menu)
+			continue
+		}
+		// 授权按钮时同样需要展示按钮所在的页面
+		if granted[menu.ID] {
+			permissions = append(permissions, menu.Permission)
+			granted[menu.TreeParentId()] = true
+		}
+	}
+	if result := tree.Search(pages, func(m *upmsModel.Menu) bool { return granted[m.ID] }, nil); result != nil {
+		menus = result
+	}
+	sort.Strings(permissions)
+	return menus, permissions, nil
+}
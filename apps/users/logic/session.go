@@ -0,0 +1,353 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/service"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/middleware"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"go.uber.org/zap"
+	"time"
+)
+
+var _ service.SessionService = (*SessionLogic)(nil)
+
+var sessionLogic = &SessionLogic{}
+
+const (
+	sessionKeyPrefix        = "ikubexjob:session:"         // 会话详情 ikubexjob:session:<jti>
+	sessionAccountKeyPrefix = "ikubexjob:session:account:" // 账号会话集合 ikubexjob:session:account:<id>，按登录时间排序
+	sessionTouchInterval    = time.Minute                  // 最后活动时间的最小刷新间隔，避免每次请求都写 Redis
+)
+
+var errSessionStoreDisabled = errors.New("会话存储未启用，请先启用 redis 配置")
+
+type SessionLogic struct {
+	l   *zap.Logger
+	rdb *redis.Client
+}
+
+func sessionKey(id string) string {
+	return sessionKeyPrefix + id
+}
+
+func sessionAccountKey(accountId uint) string {
+	return fmt.Sprintf("%s%d", sessionAccountKeyPrefix, accountId)
+}
+
+// Create 登录成功后创建会话，超出单账号会话上限时踢出最早登录的会话
+func (l *SessionLogic) Create(c *gin.Context, accountId uint, account, id, refreshId string) error {
+	if l.rdb == nil {
+		return nil
+	}
+	now := time.Now()
+	session := &types2.Session{
+		Id:           id,
+		AccountId:    accountId,
+		Account:      account,
+		UserAgent:    c.Request.UserAgent(),
+		Ip:           c.ClientIP(),
+		LoginTime:    now,
+		LastActivity: now,
+		RefreshId:    refreshId,
+	}
+	if err := l.save(c, session, utils.RefreshTokenTTL); err != nil {
+		return err
+	}
+	accountKey := sessionAccountKey(accountId)
+	pipe := l.rdb.TxPipeline()
+	pipe.ZAdd(c, accountKey, &redis.Z{Score: float64(now.UnixNano()), Member: id})
+	pipe.Expire(c, accountKey, utils.RefreshTokenTTL)
+	if _, err := pipe.Exec(c); err != nil {
+		return err
+	}
+	return l.evict(c, accountId)
+}
+
+// evict 清理已过期的会话，并按配置的并发上限踢出最早的会话
+func (l *SessionLogic) evict(ctx context.Context, accountId uint) error {
+	ids, err := l.accountSessionIds(ctx, accountId)
+	if err != nil {
+		return err
+	}
+	limit := global.C.Session.MaxPerAccount
+	if limit <= 0 || len(ids) <= limit {
+		return nil
+	}
+	// ids 按登录时间升序排列，超出部分从最早的开始踢出
+	for _, id := range ids[:len(ids)-limit] {
+		l.l.Info(fmt.Sprintf("账号 %d 会话数超出上限 %d，踢出会话: %s", accountId, limit, id))
+		if err := l.remove(ctx, accountId, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accountSessionIds 返回账号仍然有效的会话 ID，同时清理集合中已过期的成员
+func (l *SessionLogic) accountSessionIds(ctx context.Context, accountId uint) ([]string, error) {
+	accountKey := sessionAccountKey(accountId)
+	ids, err := l.rdb.ZRange(ctx, accountKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	alive := make([]string, 0, len(ids))
+	for _, id := range ids {
+		exists, err := l.rdb.Exists(ctx, sessionKey(id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			l.rdb.ZRem(ctx, accountKey, id)
+			continue
+		}
+		alive = append(alive, id)
+	}
+	return alive, nil
+}
+
+func (l *SessionLogic) get(ctx context.Context, id string) (*types2.Session, error) {
+	data, err := l.rdb.Get(ctx, sessionKey(id)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var session types2.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (l *SessionLogic) save(ctx context.Context, session *types2.Session, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return l.rdb.Set(ctx, sessionKey(session.Id), data, ttl).Err()
+}
+
+// update 在事务中修改会话，会话在读取后被其他请求修改时返回 redis.TxFailedErr，避免覆盖其他请求更换的刷新令牌编号
+func (l *SessionLogic) update(ctx context.Context, id string, ttl time.Duration, fn func(*types2.Session) error) error {
+	key := sessionKey(id)
+	return l.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			return err
+		}
+		var session types2.Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			return err
+		}
+		if err := fn(&session); err != nil {
+			return err
+		}
+		if data, err = json.Marshal(&session); err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, ttl)
+			return nil
+		})
+		return err
+	}, key)
+}
+
+func (l *SessionLogic) remove(ctx context.Context, accountId uint, id string) error {
+	pipe := l.rdb.TxPipeline()
+	pipe.Del(ctx, sessionKey(id))
+	pipe.ZRem(ctx, sessionAccountKey(accountId), id)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Check 鉴权中间件的附加校验，会话被吊销或踢出后令牌立即失效
+func (l *SessionLogic) Check(c *gin.Context, claims *utils.JWTClaims) error {
+	if l.rdb == nil {
+		return nil
+	}
+	session, err := l.get(c, claims.Id)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return fmt.Errorf("登录会话已失效，请重新登录")
+		}
+		l.l.Error(fmt.Sprintf("查询会话失败: %s", err.Error()))
+		return fmt.Errorf("查询会话失败")
+	}
+	if session.AccountId != claims.AccountId {
+		return fmt.Errorf("登录会话已失效，请重新登录")
+	}
+	now := time.Now()
+	if now.Sub(session.LastActivity) >= sessionTouchInterval {
+		// 活动时间只是参考信息，会话同时被其他请求修改时放弃本次更新
+		err := l.update(c, claims.Id, redis.KeepTTL, func(session *types2.Session) error {
+			session.LastActivity = now
+			session.Ip = c.ClientIP()
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.TxFailedErr) && !errors.Is(err, redis.Nil) {
+			l.l.Warn(fmt.Sprintf("更新会话活动时间失败: %s", err.Error()))
+		}
+	}
+	return nil
+}
+
+// Refresh 刷新令牌时校验刷新令牌编号并更换为 nextRefreshId，同时延长会话有效期。
+// 刷新令牌只能使用一次，已被更换的刷新令牌再次使用说明令牌可能已泄露，吊销整个会话
+func (l *SessionLogic) Refresh(c *gin.Context, accountId uint, id, refreshId, nextRefreshId string) error {
+	if l.rdb == nil {
+		return nil
+	}
+	errExpired := fmt.Errorf("登录会话已失效，请重新登录")
+	reused := false
+	err := l.update(c, id, utils.RefreshTokenTTL, func(session *types2.Session) error {
+		if session.AccountId != accountId {
+			return errExpired
+		}
+		if session.RefreshId != refreshId {
+			reused = true
+			return errExpired
+		}
+		session.RefreshId = nextRefreshId
+		session.LastActivity = time.Now()
+		session.Ip = c.ClientIP()
+		session.UserAgent = c.Request.UserAgent()
+		return nil
+	})
+	if reused {
+		l.l.Warn(fmt.Sprintf("账号 %d 的会话 %s 重复使用了已失效的刷新令牌，吊销该会话", accountId, id))
+		if err := l.remove(c, accountId, id); err != nil {
+			l.l.Error(fmt.Sprintf("吊销会话失败: %s", err.Error()))
+		}
+		return errExpired
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, errExpired), errors.Is(err, redis.Nil):
+		return errExpired
+	case errors.Is(err, redis.TxFailedErr):
+		// 同一刷新令牌的并发请求只有一个能成功
+		return fmt.Errorf("刷新令牌已被使用，请重新登录")
+	default:
+		l.l.Error(fmt.Sprintf("刷新会话失败: %s", err.Error()))
+		return fmt.Errorf("刷新会话失败")
+	}
+	return l.rdb.Expire(c, sessionAccountKey(accountId), utils.RefreshTokenTTL).Err()
+}
+
+func (l *SessionLogic) list(c *gin.Context, accountId uint) ([]*types2.Session, error) {
+	if l.rdb == nil {
+		return nil, errSessionStoreDisabled
+	}
+	ids, err := l.accountSessionIds(c, accountId)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询会话失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询会话失败")
+	}
+	var currentId string
+	if claims, err := utils.GetClaims(c); err == nil {
+		currentId = claims.Id
+	}
+	// 按登录时间倒序返回
+	list := make([]*types2.Session, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		session, err := l.get(c, ids[i])
+		if err != nil {
+			continue
+		}
+		session.Current = session.Id == currentId
+		session.RefreshId = ""
+		list = append(list, session)
+	}
+	return list, nil
+}
+
+func (l *SessionLogic) revoke(c *gin.Context, accountId uint, id string) error {
+	if l.rdb == nil {
+		return errSessionStoreDisabled
+	}
+	session, err := l.get(c, id)
+	if err != nil || session.AccountId != accountId {
+		return fmt.Errorf("吊销会话失败: 未找到指定的会话")
+	}
+	if err := l.remove(c, accountId, id); err != nil {
+		l.l.Error(fmt.Sprintf("吊销会话失败: %s", err.Error()))
+		return fmt.Errorf("吊销会话失败")
+	}
+	return nil
+}
+
+// RevokeAll 吊销账号的全部会话
+func (l *SessionLogic) RevokeAll(ctx context.Context, accountId uint) error {
+	if l.rdb == nil {
+		return nil
+	}
+	ids, err := l.rdb.ZRange(ctx, sessionAccountKey(accountId), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	keys := []string{sessionAccountKey(accountId)}
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+	return l.rdb.Del(ctx, keys...).Err()
+}
+
+func (l *SessionLogic) List(c *gin.Context) ([]*types2.Session, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	return l.list(c, claims.AccountId)
+}
+
+func (l *SessionLogic) Revoke(c *gin.Context, req types2.SessionSearchReq) error {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return err
+	}
+	return l.revoke(c, claims.AccountId, req.Id)
+}
+
+// AccountList 管理员查询其他账号的会话，账号需要在数据权限范围内
+func (l *SessionLogic) AccountList(c *gin.Context, req types2.SessionAccountReq) ([]*types2.Session, error) {
+	if _, err := accountLogic.visibleAccount(c, req.AccountId); err != nil {
+		return nil, err
+	}
+	return l.list(c, req.AccountId)
+}
+
+// AccountRevoke 管理员踢出其他账号的会话，账号需要在数据权限的写范围内
+func (l *SessionLogic) AccountRevoke(c *gin.Context, req types2.SessionAccountRevokeReq) error {
+	if _, err := accountLogic.writableAccount(c, req.AccountId); err != nil {
+		return err
+	}
+	return l.revoke(c, req.AccountId, req.Id)
+}
+
+// Config 只需要保证 全局对象Config和全局Logger已经加载完成
+func (l *SessionLogic) Config() {
+	l.l = global.L.Named(apps.AppName).Named(apps.AppSession).Named("logic")
+	if global.RDB != nil {
+		l.rdb = global.RDB.GetClient()
+	} else {
+		l.l.Warn("未启用 redis，会话管理不可用")
+	}
+	middleware.RegistryClaimsChecker(l.Check)
+}
+
+func (l *SessionLogic) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppSession)
+}
+
+func init() {
+	// 注册
+	router.RegistryLogic(sessionLogic)
+}
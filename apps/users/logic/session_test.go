@@ -0,0 +1,99 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"go.uber.org/zap"
+)
+
+// newTestSessionLogic 使用 miniredis 保存会话
+func newTestSessionLogic(t *testing.T) (*SessionLogic, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return &SessionLogic{l: zap.NewNop(), rdb: rdb}, mr
+}
+
+func sessionClaims(accountId uint, id string) *utils.JWTClaims {
+	return &utils.JWTClaims{AccountId: accountId, StandardClaims: jwt.StandardClaims{Id: id}}
+}
+
+func TestSessionRefreshRotation(t *testing.T) {
+	l, _ := newTestSessionLogic(t)
+	c := newTestContext()
+	require.NoError(t, l.Create(c, 1, "alice", "s1", "r1"))
+
+	require.NoError(t, l.Refresh(c, 1, "s1", "r1", "r2"), "第一次刷新")
+	session, err := l.get(c, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, "r2", session.RefreshId, "刷新后更换刷新令牌编号")
+
+	require.NoError(t, l.Refresh(c, 1, "s1", "r2", "r3"), "使用新的刷新令牌继续刷新")
+	assert.NoError(t, l.Check(c, sessionClaims(1, "s1")), "刷新不影响会话")
+}
+
+func TestSessionRefreshReuse(t *testing.T) {
+	l, mr := newTestSessionLogic(t)
+	c := newTestContext()
+	require.NoError(t, l.Create(c, 1, "alice", "s1", "r1"))
+	require.NoError(t, l.Create(c, 1, "alice", "s2", "x1"))
+	require.NoError(t, l.Refresh(c, 1, "s1", "r1", "r2"))
+
+	assert.Error(t, l.Refresh(c, 1, "s1", "r1", "r3"), "已更换的刷新令牌不能再次使用")
+	assert.False(t, mr.Exists(sessionKey("s1")), "重复使用刷新令牌时吊销会话")
+	ids, err := l.accountSessionIds(c, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s2"}, ids, "只吊销重复使用刷新令牌的会话")
+	assert.Error(t, l.Refresh(c, 1, "s1", "r2", "r3"), "会话吊销后最新的刷新令牌同样失效")
+	assert.Error(t, l.Check(c, sessionClaims(1, "s1")), "会话吊销后访问令牌失效")
+}
+
+func TestSessionRefreshOtherAccount(t *testing.T) {
+	l, _ := newTestSessionLogic(t)
+	c := newTestContext()
+	require.NoError(t, l.Create(c, 1, "alice", "s1", "r1"))
+
+	assert.Error(t, l.Refresh(c, 2, "s1", "r1", "r2"), "不能刷新其他账号的会话")
+	session, err := l.get(c, "s1")
+	require.NoError(t, err, "其他账号的请求不吊销会话")
+	assert.Equal(t, "r1", session.RefreshId)
+	assert.Error(t, l.Refresh(c, 1, "missing", "r1", "r2"), "会话不存在")
+}
+
+func TestSessionRevokeAll(t *testing.T) {
+	l, mr := newTestSessionLogic(t)
+	c := newTestContext()
+	require.NoError(t, l.Create(c, 1, "alice", "s1", "r1"))
+	require.NoError(t, l.Create(c, 1, "alice", "s2", "r2"))
+	require.NoError(t, l.Create(c, 2, "bob", "s3", "r3"))
+
+	require.NoError(t, l.RevokeAll(c, 1))
+	assert.False(t, mr.Exists(sessionKey("s1")))
+	assert.False(t, mr.Exists(sessionKey("s2")))
+	assert.False(t, mr.Exists(sessionAccountKey(1)))
+	assert.Error(t, l.Check(c, sessionClaims(1, "s1")), "吊销后访问令牌失效")
+	assert.Error(t, l.Refresh(c, 1, "s2", "r2", "r4"), "吊销后刷新令牌失效")
+	assert.NoError(t, l.Check(c, sessionClaims(2, "s3")), "不影响其他账号")
+}
+
+func TestSessionEvict(t *testing.T) {
+	l, _ := newTestSessionLogic(t)
+	c := newTestContext()
+	limit := global.C.Session.MaxPerAccount
+	global.C.Session.MaxPerAccount = 2
+	t.Cleanup(func() { global.C.Session.MaxPerAccount = limit })
+
+	for _, id := range []string{"s1", "s2", "s3"} {
+		require.NoError(t, l.Create(c, 1, "alice", id, "r-"+id))
+	}
+	ids, err := l.accountSessionIds(c, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s2", "s3"}, ids, "超出上限时踢出最早登录的会话")
+}
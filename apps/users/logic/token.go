@@ -0,0 +1,195 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	apps "github.com/yanshicheng/ikube-gin-xjob/apps/users"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/service"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/middleware"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/version"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"time"
+)
+
+var _ service.ApiTokenService = (*ApiTokenLogic)(nil)
+
+var apiTokenLogic = &ApiTokenLogic{}
+
+type ApiTokenLogic struct {
+	l  *zap.Logger
+	db *gorm.DB
+}
+
+func (l *ApiTokenLogic) Create(c *gin.Context, req *types2.ApiTokenCreateReq) (*types2.ApiTokenCreateResp, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	// 令牌只能由登录会话创建，避免令牌套娃
+	if claims.Type == types.TokenTypeApi {
+		return nil, fmt.Errorf("API 令牌不允许创建新的令牌")
+	}
+	// 授权范围必须是账号已有角色的子集
+	roles, err := accountRoleNames(c, l.db, claims.AccountId)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询账号角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询账号角色失败")
+	}
+	owned := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		owned[role] = struct{}{}
+	}
+	for _, scope := range req.Scopes {
+		if _, ok := owned[scope]; !ok {
+			return nil, fmt.Errorf("账号未拥有角色: %s", scope)
+		}
+	}
+	token, prefix, err := utils.GenerateApiToken()
+	if err != nil {
+		l.l.Error(fmt.Sprintf("生成令牌失败: %s", err.Error()))
+		return nil, fmt.Errorf("生成令牌失败")
+	}
+	apiToken := &model.ApiToken{
+		AccountId: claims.AccountId,
+		Name:      req.Name,
+		Prefix:    prefix,
+		TokenHash: utils.HashToken(token),
+		Scopes:    req.Scopes,
+	}
+	if req.ExpireDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpireDays)
+		apiToken.ExpiresAt = &expiresAt
+	}
+	if err := l.db.WithContext(c).Create(apiToken).Error; err != nil {
+		l.l.Error(fmt.Sprintf("创建令牌失败: %s", err.Error()))
+		return nil, fmt.Errorf("创建令牌失败")
+	}
+	return &types2.ApiTokenCreateResp{Token: token, ApiToken: apiToken}, nil
+}
+
+func (l *ApiTokenLogic) List(c *gin.Context) ([]*model.ApiToken, error) {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	return l.AccountList(c, types2.ApiTokenAccountReq{AccountId: claims.AccountId})
+}
+
+func (l *ApiTokenLogic) Delete(c *gin.Context, id types.SearchId) error {
+	claims, err := utils.GetClaims(c)
+	if err != nil {
+		return err
+	}
+	result := l.db.WithContext(c).Where("id = ? AND account_id = ?", id.Id, claims.AccountId).Delete(&model.ApiToken{})
+	if err := result.Error; err != nil {
+		l.l.Error(fmt.Sprintf("吊销令牌失败: %s", err.Error()))
+		return fmt.Errorf("吊销令牌失败")
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("吊销令牌失败: 未找到指定的令牌")
+	}
+	return nil
+}
+
+// AccountList 查询账号的令牌，管理员查询其他账号时账号必须在数据权限范围内
+func (l *ApiTokenLogic) AccountList(c *gin.Context, req types2.ApiTokenAccountReq) ([]*model.ApiToken, error) {
+	if _, err := accountLogic.visibleAccount(c, req.AccountId); err != nil {
+		return nil, err
+	}
+	var list []*model.ApiToken
+	if err := l.db.WithContext(c).Where("account_id = ?", req.AccountId).Order("id DESC").Find(&list).Error; err != nil {
+		l.l.Error(fmt.Sprintf("查询令牌失败: %s", err.Error()))
+		return nil, fmt.Errorf("查询令牌失败")
+	}
+	return list, nil
+}
+
+// AccountDelete 管理员吊销任意账号的令牌，令牌所属账号必须在数据权限的写范围内
+func (l *ApiTokenLogic) AccountDelete(c *gin.Context, id types.SearchId) error {
+	var token model.ApiToken
+	if err := l.db.WithContext(c).Where("id = ?", id.Id).First(&token).Error; err != nil {
+		return fmt.Errorf("吊销令牌失败: 未找到指定的令牌")
+	}
+	if _, err := accountLogic.writableAccount(c, token.AccountId); err != nil {
+		return err
+	}
+	result := l.db.WithContext(c).Where("id = ?", id.Id).Delete(&model.ApiToken{})
+	if err := result.Error; err != nil {
+		l.l.Error(fmt.Sprintf("吊销令牌失败: %s", err.Error()))
+		return fmt.Errorf("吊销令牌失败")
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("吊销令牌失败: 未找到指定的令牌")
+	}
+	return nil
+}
+
+// Verify 校验 API 令牌，成功后记录使用时间和来源 IP，并返回与 JWT 一致的声明信息
+func (l *ApiTokenLogic) Verify(c *gin.Context, token string) (*utils.JWTClaims, error) {
+	var apiToken model.ApiToken
+	if err := l.db.WithContext(c).Where("prefix = ? AND token_hash = ?", utils.ApiTokenPrefixOf(token), utils.HashToken(token)).First(&apiToken).Error; err != nil {
+		return nil, fmt.Errorf("令牌无效")
+	}
+	now := time.Now()
+	if apiToken.IsExpired(now) {
+		return nil, fmt.Errorf("令牌已过期")
+	}
+	var account model.Account
+	if err := l.db.WithContext(c).Where("id = ?", apiToken.AccountId).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("令牌无效")
+	}
+	if account.IsDisabled || account.IsLeave {
+		return nil, fmt.Errorf("账号已被禁用")
+	}
+	// 令牌授权范围与账号当前角色取交集，账号被收回的角色令牌同样失效
+	roles, err := accountRoleNames(c, l.db, account.ID)
+	if err != nil {
+		l.l.Error(fmt.Sprintf("查询账号角色失败: %s", err.Error()))
+		return nil, fmt.Errorf("令牌校验失败")
+	}
+	scopes := make([]string, 0, len(apiToken.Scopes))
+	for _, scope := range apiToken.Scopes {
+		for _, role := range roles {
+			if scope == role {
+				scopes = append(scopes, scope)
+				break
+			}
+		}
+	}
+	updates := map[string]interface{}{"last_used_at": now, "last_used_ip": c.ClientIP()}
+	if err := l.db.WithContext(c).Model(&model.ApiToken{}).Where("id = ?", apiToken.ID).UpdateColumns(updates).Error; err != nil {
+		l.l.Warn(fmt.Sprintf("更新令牌使用记录失败: %s", err.Error()))
+	}
+	claims := &utils.JWTClaims{
+		AccountId:   account.ID,
+		Account:     account.Account,
+		Type:        types.TokenTypeApi,
+		Application: utils.ApplicationRole{Application: version.IkubeopsProjectName, Role: scopes},
+	}
+	claims.Id = apiToken.Prefix
+	return claims, nil
+}
+
+// Config 只需要保证 全局对象Config和全局Logger已经加载完成
+func (l *ApiTokenLogic) Config() {
+	l.l = global.L.Named(apps.AppName).Named(apps.AppToken).Named("logic")
+	l.db = global.DB.GetDb()
+	// 注册到鉴权中间件，与 JWT 共用同一鉴权入口
+	middleware.RegistryTokenVerifier(utils.ApiTokenPrefix, l.Verify)
+}
+
+func (l *ApiTokenLogic) Name() string {
+	return fmt.Sprintf("%s.%s", apps.AppName, apps.AppToken)
+}
+
+func init() {
+	// 注册
+	router.RegistryLogic(apiTokenLogic)
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"time"
+)
+
+func init() {
+	model.Register(&AccountOffboarding{})
+}
+
+// AccountOffboarding 账号的离职记录，保留期满后由后台任务匿名化账号的个人信息
+type AccountOffboarding struct {
+	model.Model
+	AccountId      uint           `json:"accountId" gorm:"type:int;not null;index;comment:账号ID"`
+	LeaveDate      model.DateTime `json:"leaveDate" gorm:"type:date;not null;comment:离职日期"`
+	SuccessorId    uint           `json:"successorId" gorm:"type:int;not null;default:0;comment:交接人ID"` // 负责的机构转交给交接人，0 表示无需交接
+	Reason         string         `json:"reason" gorm:"type:varchar(128);not null;default:'';comment:离职原因"`
+	Roles          []string       `json:"roles" gorm:"type:varchar(512);not null;serializer:json;comment:离职时解除的角色"`
+	Organizations  []uint         `json:"organizations" gorm:"type:varchar(512);not null;serializer:json;comment:转交给交接人的机构"`
+	AnonymizeAfter model.DateTime `json:"anonymizeAfter" gorm:"type:date;index;comment:匿名化日期"` // 为空表示不匿名化
+	AnonymizedAt   *time.Time     `json:"anonymizedAt" gorm:"type:datetime;comment:匿名化时间"`
+	AnonymizeError string         `json:"anonymizeError,omitempty" gorm:"type:varchar(256);not null;default:'';comment:匿名化失败原因"` // 最近一次匿名化失败的原因，成功后清空
+	OperatorId     uint           `json:"operatorId" gorm:"type:int;not null;default:0;comment:操作人ID"`
+	Operator       string         `json:"operator" gorm:"type:varchar(32);not null;default:'';comment:操作人"`
+}
+
+func (o *AccountOffboarding) TableName() string {
+	return "ikubexjob_user_account_offboarding"
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"fmt"
+	"github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+func init() {
+	model.Register(&AccountAssignment{}, &AccountTransfer{})
+}
+
+// 调动状态
+const (
+	TransferStatusPending  = "pending"  // 待生效
+	TransferStatusApplied  = "applied"  // 已生效
+	TransferStatusCanceled = "canceled" // 已取消
+	TransferStatusFailed   = "failed"   // 生效失败，例如目标机构已删除
+)
+
+// AccountAssignment 账号的任职记录，每次调整机构或职位时结束当前记录并开始新的记录
+// 结束日期为空（零值）表示当前任职，开始日期当天生效，结束日期当天不再属于该机构
+type AccountAssignment struct {
+	model.Model
+	AccountId      uint           `json:"accountId" gorm:"type:int;not null;index;comment:账号ID"`
+	OrganizationId uint           `json:"organizationId" gorm:"type:int;not null;index;comment:机构ID"`
+	PositionId     uint           `json:"positionId" gorm:"type:int;not null;comment:职位ID"`
+	StartDate      model.DateTime `json:"startDate" gorm:"type:date;not null;comment:开始日期"`
+	EndDate        model.DateTime `json:"endDate" gorm:"type:date;comment:结束日期"`
+	TransferId     uint           `json:"transferId" gorm:"type:int;not null;default:0;comment:调动ID"` // 由调动产生时记录调动 ID
+	Reason         string         `json:"reason" gorm:"type:varchar(128);not null;default:'';comment:原因"`
+	TreeName       string         `json:"treeName,omitempty" gorm:"-"`                  // 机构名称路径，查询时填充
+	PositionName   string         `json:"positionName,omitempty" gorm:"->;-:migration"` // 只读，查询时关联职位表填充
+}
+
+func (a *AccountAssignment) TableName() string {
+	return "ikubexjob_user_account_assignment"
+}
+
+// AfterMigrate 为没有任职记录的账号按入职日期补充当前任职记录
+func (a *AccountAssignment) AfterMigrate(db *gorm.DB) error {
+	accountTable := (&Account{}).TableName()
+	return db.Exec(fmt.Sprintf(`INSERT INTO %s (created_at, updated_at, deleted_at, account_id, organization_id, position_id, start_date, transfer_id, reason)
+SELECT NOW(), NOW(), 0, a.id, a.organization_id, a.position_id, a.hire_date, 0, '初始化'
+FROM %s a WHERE a.deleted_at = 0 AND NOT EXISTS (SELECT 1 FROM %s s WHERE s.account_id = a.id AND s.deleted_at = 0)`,
+		a.TableName(), accountTable, a.TableName())).Error
+}
+
+// AccountTransfer 账号调动，生效日期不晚于当天时立即生效，否则由后台任务在生效日期生效
+type AccountTransfer struct {
+	model.Model
+	AccountId          uint           `json:"accountId" gorm:"type:int;not null;index;comment:账号ID"`
+	FromOrganizationId uint           `json:"fromOrganizationId" gorm:"type:int;not null;default:0;comment:原机构ID"` // 生效时记录
+	FromPositionId     uint           `json:"fromPositionId" gorm:"type:int;not null;default:0;comment:原职位ID"`     // 生效时记录
+	ToOrganizationId   uint           `json:"toOrganizationId" gorm:"type:int;not null;comment:目标机构ID"`
+	ToPositionId       uint           `json:"toPositionId" gorm:"type:int;not null;comment:目标职位ID"`
+	EffectiveDate      model.DateTime `json:"effectiveDate" gorm:"type:date;not null;index;comment:生效日期"`
+	Status             string         `json:"status" gorm:"type:varchar(16);not null;index;comment:状态"`
+	Reason             string         `json:"reason" gorm:"type:varchar(128);not null;default:'';comment:原因"`
+	Error              string         `json:"error,omitempty" gorm:"type:varchar(256);not null;default:'';comment:失败原因"`
+	AppliedAt          *time.Time     `json:"appliedAt,omitempty" gorm:"type:datetime;comment:生效时间"`
+	OperatorId         uint           `json:"operatorId" gorm:"type:int;not null;default:0;comment:操作人ID"`
+	Operator           string         `json:"operator" gorm:"type:varchar(32);not null;default:'';comment:操作人"`
+}
+
+func (t *AccountTransfer) TableName() string {
+	return "ikubexjob_user_account_transfer"
+}
@@ -0,0 +1,38 @@
+package model
+
+import "github.com/yanshicheng/ikube-gin-xjob/common/model"
+
+func init() {
+	model.Register(&SecurityEvent{})
+}
+
+// 安全事件类型
+const (
+	EventLoginSuccess     = "login_success"
+	EventLoginFailure     = "login_failure"
+	EventLogout           = "logout"
+	EventPasswordChange   = "password_change"
+	EventPasswordReset    = "password_reset"
+	EventPasswordForgot   = "password_forgot"
+	EventTokenRefresh     = "token_refresh"
+	EventTwoFactorSuccess = "2fa_success"
+	EventTwoFactorFailure = "2fa_failure"
+	EventEmailChange      = "email_change"
+	EventOffboard         = "offboard"
+)
+
+// SecurityEvent 登录及账号安全相关的审计事件
+type SecurityEvent struct {
+	model.Model
+	AccountId uint   `json:"accountId" gorm:"type:int;not null;default:0;index;comment:账号ID"`
+	Account   string `json:"account" gorm:"type:varchar(32);not null;index;comment:账号"`
+	Type      string `json:"type" gorm:"type:varchar(32);not null;index;comment:事件类型"`
+	Success   bool   `json:"success" gorm:"type:tinyint(1);not null;comment:是否成功"`
+	Reason    string `json:"reason" gorm:"type:varchar(255);not null;default:'';comment:原因"`
+	Ip        string `json:"ip" gorm:"type:varchar(64);not null;default:'';index;comment:来源IP"`
+	UserAgent string `json:"userAgent" gorm:"type:varchar(255);not null;default:'';comment:客户端"`
+}
+
+func (e *SecurityEvent) TableName() string {
+	return "ikubexjob_user_security_event"
+}
@@ -0,0 +1,73 @@
+package model
+
+import "github.com/yanshicheng/ikube-gin-xjob/common/model"
+
+func init() {
+	model.Register(&Group{}, &GroupAccount{}, &GroupChild{}, &GroupRole{})
+	model.RegisterRecyclable(&model.Recyclable{Name: "group", Title: "用户组", Model: &Group{}})
+}
+
+// Group 用户组，不属于机构树，可以包含任意机构的账号以及其他用户组
+// 用户组绑定的角色对全部成员生效，下级用户组的成员同样是上级用户组的成员
+type Group struct {
+	model.Model
+	Name string `json:"name" form:"name" binding:"required,max=32" gorm:"type:varchar(32);not null;comment:用户组名称"`
+	Desc string `json:"desc" form:"desc" binding:"max=128" gorm:"type:varchar(128);not null;default:'';comment:描述"`
+}
+
+func (g *Group) TableName() string {
+	return "ikubexjob_user_group"
+}
+
+// UniqueIndexes 用户组名称唯一
+func (g *Group) UniqueIndexes() map[string][]string {
+	return map[string][]string{"uk_name": {"name"}}
+}
+
+// GroupAccount 用户组的账号成员
+type GroupAccount struct {
+	model.Model
+	GroupId   uint `json:"groupId" gorm:"type:int;not null;comment:用户组ID"`
+	AccountId uint `json:"accountId" gorm:"type:int;not null;index;comment:账号ID"`
+}
+
+func (g *GroupAccount) TableName() string {
+	return "ikubexjob_user_group_account"
+}
+
+// UniqueIndexes 同一账号不能重复加入同一用户组
+func (g *GroupAccount) UniqueIndexes() map[string][]string {
+	return map[string][]string{"uk_group_account": {"group_id", "account_id"}}
+}
+
+// GroupChild 用户组的下级用户组，一个用户组可以同时属于多个上级用户组，不能形成环
+type GroupChild struct {
+	model.Model
+	GroupId uint `json:"groupId" gorm:"type:int;not null;comment:用户组ID"`
+	ChildId uint `json:"childId" gorm:"type:int;not null;index;comment:下级用户组ID"`
+}
+
+func (g *GroupChild) TableName() string {
+	return "ikubexjob_user_group_child"
+}
+
+// UniqueIndexes 同一用户组不能重复包含同一下级用户组
+func (g *GroupChild) UniqueIndexes() map[string][]string {
+	return map[string][]string{"uk_group_child": {"group_id", "child_id"}}
+}
+
+// GroupRole 用户组绑定的角色
+type GroupRole struct {
+	model.Model
+	GroupId uint `json:"groupId" gorm:"type:int;not null;comment:用户组ID"`
+	RoleId  uint `json:"roleId" gorm:"type:int;not null;index;comment:角色ID"`
+}
+
+func (g *GroupRole) TableName() string {
+	return "ikubexjob_user_group_role"
+}
+
+// UniqueIndexes 同一用户组不能重复绑定同一角色
+func (g *GroupRole) UniqueIndexes() map[string][]string {
+	return map[string][]string{"uk_group_role": {"group_id", "role_id"}}
+}
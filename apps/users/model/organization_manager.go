@@ -0,0 +1,23 @@
+package model
+
+import "github.com/yanshicheng/ikube-gin-xjob/common/model"
+
+func init() {
+	model.Register(&OrganizationManager{})
+}
+
+// OrganizationManager 机构负责人，一个机构可以有多个负责人，一个账号也可以负责多个机构
+type OrganizationManager struct {
+	model.Model
+	OrganizationId uint `json:"organizationId" gorm:"type:int;not null;index;comment:机构ID"`
+	AccountId      uint `json:"accountId" gorm:"type:int;not null;index;comment:负责人账号ID"`
+}
+
+func (m *OrganizationManager) TableName() string {
+	return "ikubexjob_user_organization_manager"
+}
+
+// UniqueIndexes 同一账号不能重复成为同一机构的负责人
+func (m *OrganizationManager) UniqueIndexes() map[string][]string {
+	return map[string][]string{"uk_organization_account": {"organization_id", "account_id"}}
+}
@@ -0,0 +1,23 @@
+package model
+
+import "github.com/yanshicheng/ikube-gin-xjob/common/model"
+
+func init() {
+	model.Register(&OrganizationMove{})
+}
+
+// OrganizationMove 机构移动的审计记录，保存移动前后的完整路径
+type OrganizationMove struct {
+	model.Model
+	OrganizationId uint   `json:"organizationId" gorm:"type:int;not null;index;comment:机构ID"`
+	OldParentId    uint   `json:"oldParentId" gorm:"type:int;not null;comment:原上级机构"`
+	NewParentId    uint   `json:"newParentId" gorm:"type:int;not null;comment:新上级机构"`
+	OldPath        string `json:"oldPath" gorm:"type:varchar(512);not null;comment:原路径"`
+	NewPath        string `json:"newPath" gorm:"type:varchar(512);not null;comment:新路径"`
+	OperatorId     uint   `json:"operatorId" gorm:"type:int;not null;default:0;comment:操作人ID"`
+	Operator       string `json:"operator" gorm:"type:varchar(32);not null;default:'';comment:操作人"`
+}
+
+func (m *OrganizationMove) TableName() string {
+	return "ikubexjob_user_organization_move"
+}
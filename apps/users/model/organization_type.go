@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/types"
+)
+
+// 机构类型，各类型的规则在配置文件 organization.types 中设置
+const (
+	OrganizationTypeCompany    = "company"
+	OrganizationTypeDivision   = "division"
+	OrganizationTypeDepartment = "department"
+	OrganizationTypeTeam       = "team"
+)
+
+// defaultOrganizationType 未指定类型时主体机构为公司，其他机构为部门
+func defaultOrganizationType(parentId uint) string {
+	if parentId == 0 {
+		return OrganizationTypeCompany
+	}
+	return OrganizationTypeDepartment
+}
+
+// organizationTypeTitle 机构类型的名称，未配置的类型返回类型本身
+func organizationTypeTitle(name string) string {
+	if rule, ok := global.C.Organization.Types[name]; ok && rule.Title != "" {
+		return rule.Title
+	}
+	return name
+}
+
+// TypeRule 查询机构类型的规则
+func (o *Organization) TypeRule() (types.OrganizationTypeConfig, error) {
+	rule, ok := global.C.Organization.Types[o.Type]
+	if !ok {
+		return rule, fmt.Errorf("机构类型 %s 不存在", o.Type)
+	}
+	return rule, nil
+}
+
+// MaxLevel 机构所在的最大层级，取机构类型和全部机构最大层级中较小的一个
+func (o *Organization) MaxLevel() int {
+	maxLevel := global.C.Organization.MaxLevel
+	if rule, err := o.TypeRule(); err == nil && rule.MaxLevel > 0 && rule.MaxLevel < maxLevel {
+		maxLevel = rule.MaxLevel
+	}
+	return maxLevel
+}
+
+// CheckParent 校验机构类型能否作为上级机构的下级，parent 为空时校验能否作为主体机构
+func (o *Organization) CheckParent(parent *Organization) error {
+	rule, err := o.TypeRule()
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		if !rule.Root {
+			return fmt.Errorf("%s不能作为主体机构", organizationTypeTitle(o.Type))
+		}
+		return nil
+	}
+	for _, t := range rule.Parents {
+		if t == parent.Type {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s不能作为%s的下级机构", organizationTypeTitle(o.Type), organizationTypeTitle(parent.Type))
+}
+
+// CheckPositions 校验机构类型是否允许创建职位
+func (o *Organization) CheckPositions() error {
+	rule, err := o.TypeRule()
+	if err != nil {
+		return err
+	}
+	if !rule.Positions {
+		return fmt.Errorf("%s类型的机构不允许创建职位", organizationTypeTitle(o.Type))
+	}
+	return nil
+}
+
+// CheckAccounts 校验机构类型是否允许账号归属
+func (o *Organization) CheckAccounts() error {
+	rule, err := o.TypeRule()
+	if err != nil {
+		return err
+	}
+	if !rule.Accounts {
+		return fmt.Errorf("%s类型的机构不允许添加账号", organizationTypeTitle(o.Type))
+	}
+	return nil
+}
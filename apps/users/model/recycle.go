@@ -0,0 +1,82 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"gorm.io/gorm"
+)
+
+func init() {
+	model.RegisterRecyclable(
+		&model.Recyclable{Name: "account", Title: "账号", Model: &Account{}, Validate: validateAccountRestore},
+		&model.Recyclable{Name: "organization", Title: "机构", Model: &Organization{}, Validate: validateOrganizationRestore},
+		&model.Recyclable{Name: "position", Title: "职位", Model: &Position{}, Validate: validatePositionRestore},
+	)
+}
+
+// exists 查询未删除的记录是否存在
+func exists(tx *gorm.DB, m interface{}, id uint) (bool, error) {
+	var count int64
+	if err := tx.Model(m).Where("id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// validateAccountRestore 账号所属的机构和职位必须存在，机构类型必须允许账号归属
+func validateAccountRestore(tx *gorm.DB, record interface{}) error {
+	account := record.(*Account)
+	var org Organization
+	if err := tx.Where("id = ?", account.OrganizationId).First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("账号所属机构 %d 不存在或已删除，请先恢复机构", account.OrganizationId)
+		}
+		return err
+	}
+	if err := org.CheckAccounts(); err != nil {
+		return err
+	}
+	if ok, err := exists(tx, &Position{}, account.PositionId); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("账号所属职位 %d 不存在或已删除，请先恢复职位", account.PositionId)
+	}
+	return nil
+}
+
+// validateOrganizationRestore 上级机构必须存在，上级机构删除期间可能被移动，按上级机构重新计算路径和层级
+func validateOrganizationRestore(tx *gorm.DB, record interface{}) error {
+	org := record.(*Organization)
+	if org.ParentId == 0 {
+		return org.CheckParent(nil)
+	}
+	var parent Organization
+	if err := tx.Where("id = ?", org.ParentId).First(&parent).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("上级机构 %d 不存在或已删除，请先恢复上级机构", org.ParentId)
+		}
+		return err
+	}
+	if err := org.CheckParent(&parent); err != nil {
+		return err
+	}
+	if parent.Level+1 > org.MaxLevel() {
+		return fmt.Errorf("恢复后层级超过 %d 级", org.MaxLevel())
+	}
+	return tx.Unscoped().Model(&Organization{}).Where("id = ?", org.ID).
+		UpdateColumns(map[string]interface{}{"path": parent.SubtreePath(), "level": parent.Level + 1}).Error
+}
+
+// validatePositionRestore 职位所属的机构必须存在，机构类型必须允许创建职位
+func validatePositionRestore(tx *gorm.DB, record interface{}) error {
+	position := record.(*Position)
+	var org Organization
+	if err := tx.Where("id = ?", position.OrganizationId).First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("职位所属机构 %d 不存在或已删除，请先恢复机构", position.OrganizationId)
+		}
+		return err
+	}
+	return org.CheckPositions()
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"time"
+)
+
+func init() {
+	model.Register(&ApiToken{})
+}
+
+// ApiToken 账号的长期 API 令牌，供脚本和 CI 使用，只保存令牌摘要
+type ApiToken struct {
+	model.Model
+	AccountId  uint       `json:"accountId" gorm:"type:int;not null;index;comment:账号ID"`
+	Name       string     `json:"name" gorm:"type:varchar(32);not null;comment:令牌名称"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16);not null;index;comment:令牌前缀"`
+	TokenHash  string     `json:"-" gorm:"type:char(64);not null;uniqueIndex;comment:令牌摘要"`
+	Scopes     []string   `json:"scopes" gorm:"type:varchar(512);not null;serializer:json;comment:授权角色"`
+	ExpiresAt  *time.Time `json:"expiresAt" gorm:"type:datetime;comment:过期时间"`
+	LastUsedAt *time.Time `json:"lastUsedAt" gorm:"type:datetime;comment:最后使用时间"`
+	LastUsedIp string     `json:"lastUsedIp" gorm:"type:varchar(64);not null;default:'';comment:最后使用IP"`
+}
+
+func (t *ApiToken) TableName() string {
+	return "ikubexjob_user_api_token"
+}
+
+// IsExpired 判断令牌是否已过期，未设置过期时间的令牌永不过期
+func (t *ApiToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
@@ -3,9 +3,12 @@ package model
 import (
 	"fmt"
 	"github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
 	"github.com/yanshicheng/ikube-gin-xjob/utils"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,36 +18,59 @@ func init() {
 	model.Register(&Account{}, &Organization{}, &Position{}, &AccountRole{})
 }
 
-const OrganizationLevel = 5
-
 type Account struct {
 	model.Model
 	UserName             string         `json:"userName" form:"userName" binding:"required,max=32" gorm:"type:varchar(32);not null;comment:姓名"`
-	Account              string         `json:"account" form:"Account" binding:"required,max=32" gorm:"type:varchar(32);uniqueIndex;not null;comment:账号"`
+	Account              string         `json:"account" form:"Account" binding:"required,max=32" gorm:"type:varchar(32);not null;comment:账号"`
 	Password             string         `json:"password" form:"password" binding:"max=24" gorm:"type:varchar(256);not null;comment:密码"`
 	Icon                 string         `json:"icon" form:"icon" gorm:"type:varchar(256);not null;comment:头像"`
-	Mobile               string         `json:"mobile" form:"mobile" binding:"required,max=11" gorm:"type:char(11);unique;not null;comment:手机号"`
-	Email                string         `json:"email" form:"email" binding:"required,max=36,email" gorm:"type:varchar(36);unique;not null;comment:邮箱"`
-	WorkNumber           string         `json:"workNumber" form:"workNumber" binding:"required,max=24" gorm:"type:varchar(24);unique;not null;comment:工号"`
+	Mobile               string         `json:"mobile" form:"mobile" binding:"required,max=11" gorm:"type:char(11);not null;comment:手机号"`
+	Email                string         `json:"email" form:"email" binding:"required,max=36,email" gorm:"type:varchar(36);not null;comment:邮箱"`
+	WorkNumber           string         `json:"workNumber" form:"workNumber" binding:"required,max=24" gorm:"type:varchar(24);not null;comment:工号"`
 	HireDate             model.DateTime `json:"hireDate" form:"hireDate" binding:"required" gorm:"type:date;not null;comment:入职时间"`
 	IsChangePassword     bool           `json:"isChangePassword" form:"isChangePassword" binding:"boolean" gorm:"type:tinyint(1);not null;default:true;comment:是否需要重置密码"`
 	IsDisabled           bool           `json:"isDisabled" form:"isDisabled" binding:"boolean" gorm:"type:tinyint(1);not null;default:false;comment:是否禁用"`
 	IsLeave              bool           `json:"isLeave" form:"isLeave" binding:"boolean" gorm:"type:tinyint(1);not null;default:false;comment:是否离职"`
+	LeaveDate            model.DateTime `json:"leaveDate" form:"leaveDate" gorm:"type:date;comment:离职日期"`                                     // 办理离职时填写
 	PositionId           uint           `json:"positionId" form:"positionId" binding:"required,number" gorm:"type:int;not null;comment:职位ID"` // 对应职位表
 	OrganizationId       uint           `json:"organizationId" form:"organizationId" binding:"required,number" gorm:"type:int;not null;comment:组织Id"`
 	LastLoginTime        *time.Time     `json:"lastLoginTime" form:"lastLoginTime" gorm:"type:datetime;comment:上次登录时间"`
-	OrganizationName     string         `json:"organizationName,omitempty" gorm:"-"`
+	OrganizationName     string         `json:"organizationName,omitempty" gorm:"->;-:migration"` // 只读，查询时关联机构表填充
 	OrganizationTreeName string         `json:"organizationTreeName,omitempty" gorm:"-"`
-	PositionName         string         `json:"positionName,omitempty" gorm:"-"`
+	PositionName         string         `json:"positionName,omitempty" gorm:"->;-:migration"` // 只读，查询时关联职位表填充
+}
+
+// UniqueIndexes 账号、手机号、邮箱、工号在未删除的账号中唯一
+func (u *Account) UniqueIndexes() map[string][]string {
+	return map[string][]string{
+		"uk_account":     {"account"},
+		"uk_mobile":      {"mobile"},
+		"uk_email":       {"email"},
+		"uk_work_number": {"work_number"},
+	}
 }
 
 // AfterFind 钩子自动在查找后运行
 func (u *Account) AfterFind(tx *gorm.DB) (err error) {
-	baseURL := "http://127.0.0.1:9909" // 更改为你的域名
-	u.Icon = baseURL + u.Icon
+	u.Icon = utils.StorageURL(u.Icon)
 	return nil
 }
 
+// AfterCreate 创建账号后从入职日期开始第一条任职记录，导入账号同样适用
+func (u *Account) AfterCreate(tx *gorm.DB) error {
+	startDate := u.HireDate
+	if startDate.IsZero() {
+		startDate = model.DateTime{Time: time.Now()}
+	}
+	return tx.Session(&gorm.Session{NewDB: true}).Create(&AccountAssignment{
+		AccountId:      u.ID,
+		OrganizationId: u.OrganizationId,
+		PositionId:     u.PositionId,
+		StartDate:      startDate,
+		Reason:         "入职",
+	}).Error
+}
+
 // 定义表名
 func (u *Account) TableName() string {
 	return "ikubexjob_user_account"
@@ -69,32 +95,69 @@ type Organization struct {
 	model.Model
 	Name     string          `json:"name" form:"name" binding:"required,max=32" gorm:"type:varchar(32);ngit ot null;comment:团队"`
 	ParentId uint            `json:"parentId" form:"parentId" binding:"number" gorm:"type:int;not null;comment:父级"`
+	Type     string          `json:"type" form:"type" binding:"max=16" gorm:"type:varchar(16);not null;default:'';comment:机构类型"` // 为空时主体机构为公司，其他机构为部门，可用的类型由配置文件 organization.types 决定
 	Level    int             `json:"level,omitempty" form:"level"  gorm:"type:int;not null;comment:层级"`
+	Path     string          `json:"path,omitempty" gorm:"type:varchar(255);not null;default:'';index;comment:祖先路径"` // 祖先机构 ID 路径，例如 /1/3/，主节点为 /
 	Desc     string          `json:"desc,omitempty" form:"desc" binding:"max=56" gorm:"type:varchar(56);not null;comment:描述"`
 	Children []*Organization `json:"children,omitempty" gorm:"-"` // 使用指针类型存储子组织
 }
 
-// 递归函数，通过 GORM 查询数据库，返回从顶层到当前组织的层级结构
+// GetFullHierarchy 按祖先路径一次查询出全部上级机构，返回从顶层到当前组织的名称路径
 func (o *Organization) GetFullHierarchy(db *gorm.DB) (string, error) {
-	if o.ParentId == 0 {
-		return o.Name, nil
-	}
-	var parent Organization
-	if err := db.First(&parent, o.ParentId).Error; err != nil {
-		return "", err
-	}
-	parentHierarchy, err := parent.GetFullHierarchy(db)
+	ancestors, err := o.Ancestors(db)
 	if err != nil {
 		return "", err
 	}
-	return parentHierarchy + "/" + o.Name, nil
+	names := make([]string, 0, len(ancestors)+1)
+	for _, a := range ancestors {
+		names = append(names, a.Name)
+	}
+	return strings.Join(append(names, o.Name), "/"), nil
+}
+
+// AncestorIds 解析祖先路径，返回从顶层到直接上级的机构 ID
+func (o *Organization) AncestorIds() []uint {
+	var ids []uint
+	for _, part := range strings.Split(strings.Trim(o.Path, "/"), "/") {
+		if id, err := strconv.ParseUint(part, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// SubtreePath 下级机构祖先路径的公共前缀
+func (o *Organization) SubtreePath() string {
+	return fmt.Sprintf("%s%d/", o.Path, o.ID)
+}
+
+// Ancestors 一次查询出全部上级机构，按层级从顶层开始排序
+func (o *Organization) Ancestors(db *gorm.DB) ([]*Organization, error) {
+	var ancestors []*Organization
+	ids := o.AncestorIds()
+	if len(ids) == 0 {
+		return ancestors, nil
+	}
+	if err := db.Where("id IN ?", ids).Order("level").Find(&ancestors).Error; err != nil {
+		return nil, err
+	}
+	return ancestors, nil
 }
+
 func (u *Organization) TableName() string {
 	return "ikubexjob_user_organization"
 }
 
+func (o *Organization) TreeId() uint                             { return o.ID }
+func (o *Organization) TreeParentId() uint                       { return o.ParentId }
+func (o *Organization) TreeChildren() []*Organization            { return o.Children }
+func (o *Organization) SetTreeChildren(children []*Organization) { o.Children = children }
+
 // 机构表 创建钩子函数
 func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.Type == "" {
+		o.Type = defaultOrganizationType(o.ParentId)
+	}
 	// 检查是否有父节点，如果没有父节点，则为根节点
 	if o.ParentId != 0 {
 		// 如果 ParentID 不为0，说明此节点有父节点
@@ -108,70 +171,129 @@ func (o *Organization) BeforeCreate(tx *gorm.DB) error {
 			return err // 返回错误，中断创建操作
 		}
 
-		// 如果父节点查询成功，设置当前节点的层级为父节点层级 + 1
-		o.Level = parent.Level + 1
-
-		// 检查层级是否超过5
-		if o.Level > OrganizationLevel {
-			// 如果层级超过5，返回错误
-			return fmt.Errorf("cannot add beyond level %d", OrganizationLevel)
+		// 机构类型必须允许挂在上级机构的类型下
+		if err := o.CheckParent(&parent); err != nil {
+			return err
+		}
+		// 如果父节点查询成功，设置当前节点的层级为父节点层级 + 1，层级不能超过机构类型的上限
+		level, err := tree.ChildLevel(parent.Level, o.MaxLevel())
+		if err != nil {
+			return err
 		}
+		o.Level = level
+		o.Path = parent.SubtreePath()
 	} else {
 		// 如果 ParentID 为0，说明此节点没有父节点，即它是一个根节点
+		if err := o.CheckParent(nil); err != nil {
+			return err
+		}
 		o.Level = 1 // 设置根节点的层级为1
+		o.Path = "/"
 	}
 	// 如果所有检查都通过，没有错误，则返回 nil，允许创建操作继续进行
 	return nil
 }
 
+// GetAllDescendants 按祖先路径一次查询出全部下级机构并组装为树形结构，ID 为 0 时返回整棵树
+// 上级机构缺失的节点直接丢弃，不影响其他节点
 func (org *Organization) GetAllDescendants(db *gorm.DB) ([]*Organization, error) {
 	var allOrgs []*Organization
-	err := db.Find(&allOrgs).Error
-	if err != nil {
+	query := db.Order("level, id")
+	if org.ID != 0 {
+		query = query.Where("path LIKE ?", org.SubtreePath()+"%")
+	}
+	if err := query.Find(&allOrgs).Error; err != nil {
 		return nil, err
 	}
-
-	orgMap := make(map[uint]*Organization)
-	var rootOrgs []*Organization
-
-	// 将所有组织存入 map，便于快速查找
-	for _, o := range allOrgs {
-		orgMap[o.ID] = o
+	t := tree.Build(allOrgs, nil)
+	if org.ID == 0 {
+		return t.Roots, nil
 	}
-
-	// 构建树形结构
-	for _, o := range allOrgs {
-		if o.ParentId == 0 {
-			rootOrgs = append(rootOrgs, o)
-		} else {
-			parent := orgMap[uint(o.ParentId)]
-			parent.Children = append(parent.Children, o)
+	// 直接下级机构的上级是当前机构，不在查询结果中
+	var children []*Organization
+	for _, o := range t.Orphans {
+		if o.ParentId == org.ID {
+			children = append(children, o)
 		}
 	}
+	return children, nil
+}
 
-	if org.ID == 0 {
-		return rootOrgs, nil
-	} else {
-		return orgMap[uint(org.ID)].Children, nil
+// AfterMigrate 迁移后按上级机构回填祖先路径和层级，未设置类型的主体机构回填为公司、其他机构回填为部门，已经正确的记录不会更新
+func (org *Organization) AfterMigrate(db *gorm.DB) error {
+	if err := db.Unscoped().Model(&Organization{}).Where("type = '' AND parent_id = 0").
+		UpdateColumn("type", OrganizationTypeCompany).Error; err != nil {
+		return err
+	}
+	if err := db.Unscoped().Model(&Organization{}).Where("type = ''").
+		UpdateColumn("type", OrganizationTypeDepartment).Error; err != nil {
+		return err
+	}
+	var orgs []*Organization
+	if err := db.Unscoped().Select("id", "parent_id", "level", "path").Find(&orgs).Error; err != nil {
+		return err
+	}
+	orgMap := make(map[uint]*Organization, len(orgs))
+	for _, o := range orgs {
+		orgMap[o.ID] = o
+	}
+	for _, o := range orgs {
+		path, level := "/", 1
+		ids := []string{}
+		// 沿上级机构向上查找，层级有上限，超出说明数据存在环
+		for parentId := o.ParentId; parentId != 0 && len(ids) < len(orgs); {
+			parent, ok := orgMap[parentId]
+			if !ok {
+				break
+			}
+			ids = append([]string{strconv.FormatUint(uint64(parentId), 10)}, ids...)
+			parentId = parent.ParentId
+		}
+		if len(ids) > 0 {
+			path = "/" + strings.Join(ids, "/") + "/"
+			level = len(ids) + 1
+		}
+		if o.Path == path && o.Level == level {
+			continue
+		}
+		if err := db.Unscoped().Model(&Organization{}).Where("id = ?", o.ID).
+			UpdateColumns(map[string]interface{}{"path": path, "level": level}).Error; err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 type Position struct {
 	model.Model
-	Name           string `json:"name" form:"name" binding:"required,max=64" gorm:"type:varchar(64);not null;comment:职位名称;uniqueIndex:org_name_unique"`
-	OrganizationId uint   `json:"organizationId" form:"organizationId" binding:"required" gorm:"type:int;not null;comment:组织ID;uniqueIndex:org_name_unique"`
+	Name           string `json:"name" form:"name" binding:"required,max=64" gorm:"type:varchar(64);not null;comment:职位名称"`
+	OrganizationId uint   `json:"organizationId" form:"organizationId" binding:"required" gorm:"type:int;not null;comment:组织ID"`
+	Grade          string `json:"grade" form:"grade" binding:"max=16" gorm:"type:varchar(16);not null;default:'';index;comment:职级"`   // 例如 P5、M2
+	Family         string `json:"family" form:"family" binding:"max=32" gorm:"type:varchar(32);not null;default:'';index;comment:职族"` // 例如 技术、产品、销售
+	Quota          int    `json:"quota" form:"quota" binding:"min=0" gorm:"type:int;not null;default:0;comment:编制"`                   // 在职账号数量上限，0 表示不限制
+	AccountCount   int64  `json:"accountCount" gorm:"->;-:migration"`                                                                 // 在职账号数量，只在职位列表中查询
 }
 
 func (p *Position) TableName() string {
 	return "ikubexjob_user_position"
 }
 
+// UniqueIndexes 同一机构下职位名称唯一
+func (p *Position) UniqueIndexes() map[string][]string {
+	return map[string][]string{"org_name_unique": {"organization_id", "name"}}
+}
+
 type AccountRole struct {
 	model.Model
-	RoleId    uint `json:"roleId" form:"roleId" binding:"required,number" gorm:"type:int;not null;uniqueIndex:idx_role_account;comment:角色"`
-	AccountId uint `json:"accountId" form:"accountId" binding:"required,number" gorm:"type:int;not null;uniqueIndex:idx_role_account;comment:用户"`
+	RoleId    uint `json:"roleId" form:"roleId" binding:"required,number" gorm:"type:int;not null;comment:角色"`
+	AccountId uint `json:"accountId" form:"accountId" binding:"required,number" gorm:"type:int;not null;comment:用户"`
 }
 
 func (r *AccountRole) TableName() string {
-	return "ikubexjob_user_account"
+	return "ikubexjob_user_account_role"
+}
+
+// UniqueIndexes 同一账号不能重复绑定同一角色
+func (r *AccountRole) UniqueIndexes() map[string][]string {
+	return map[string][]string{"idx_role_account": {"role_id", "account_id"}}
 }
@@ -1,23 +1,48 @@
-package service
-
-import (
-	"github.com/gin-gonic/gin"
-	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
-	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
-	"github.com/yanshicheng/ikube-gin-xjob/common/errorx"
-	"github.com/yanshicheng/ikube-gin-xjob/common/types"
-	"github.com/yanshicheng/ikube-gin-xjob/utils"
-)
-
-type AccountService interface {
-	Create(*gin.Context, *types2.AccountCreateReq) (*model.Account, error)
-	Delete(*gin.Context, types.SearchId) error
-	Get(*gin.Context, types.SearchId) (*model.Account, error)
-	List(*gin.Context, types2.AccountQueryReq) (*types.QueryResponse, error) // TODO 分页
-	Put(*gin.Context, types.SearchId, *types2.AccountCreateReq) (*model.Account, error)
-	RestPassword(*gin.Context, *types2.AccountRestPasswordReq) error
-	ChangePassword(*gin.Context, *types2.AccountChangePasswordReq) error
-	Login(*gin.Context, *types2.AccountLoginReq) (*utils.JWTResponse, errorx.ErrorCode, error)
-	Logout(*gin.Context) error
-	ChangeIcon(*gin.Context) (types2.AccountIconResp, error)
-}
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	upmsModel "github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/errorx"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+)
+
+type AccountService interface {
+	Create(*gin.Context, *types2.AccountCreateReq) (*model.Account, error)
+	Delete(*gin.Context, types.SearchId) error
+	Get(*gin.Context, types.SearchId) (*model.Account, error)
+	List(*gin.Context, types2.AccountQueryReq) (*types.QueryResponse, error) // TODO 分页
+	Put(*gin.Context, types.SearchId, *types2.AccountCreateReq) (*model.Account, error)
+	RestPassword(*gin.Context, *types2.AccountRestPasswordReq) error
+	ChangePassword(*gin.Context, *types2.AccountChangePasswordReq) error
+	Login(*gin.Context, *types2.AccountLoginReq) (*utils.JWTResponse, errorx.ErrorCode, error)
+	Refresh(*gin.Context, *types2.AccountRefreshReq) (*utils.JWTResponse, errorx.ErrorCode, error)
+	Logout(*gin.Context) error
+	ChangeIcon(*gin.Context) (types2.AccountIconResp, error)
+	Import(*gin.Context, types2.AccountImportReq) (*types2.AccountImportResp, error)
+	Export(*gin.Context, types2.AccountExportReq) (*types2.AccountExportResp, error)
+	Me(*gin.Context) (*model.Account, error)
+	UpdateMe(*gin.Context, *types2.AccountProfileUpdateReq) (*model.Account, error)
+	RequestEmailChange(*gin.Context, *types2.AccountEmailChangeReq) error
+	VerifyEmailChange(*gin.Context, *types2.AccountEmailVerifyReq) error
+	ChangeMyPassword(*gin.Context, *types2.AccountMePasswordReq) error
+	MyRoles(*gin.Context) ([]*upmsModel.Role, error)
+	MyMenus(*gin.Context) (*types2.AccountMenusResp, error)
+	MyEvents(*gin.Context, types2.SecurityEventQueryReq) (*types.QueryResponse, error)
+	ForgotPassword(*gin.Context, *types2.AccountForgotPasswordReq) error
+	ResetPasswordByToken(*gin.Context, *types2.AccountResetPasswordByTokenReq) error
+	Managers(*gin.Context, types.SearchId) ([]*types2.AccountManagerLevel, error)
+	Reports(*gin.Context, types.SearchId, types2.AccountReportQueryReq) (*types.QueryResponse, error)
+	MyManagers(*gin.Context) ([]*types2.AccountManagerLevel, error)
+	MyReports(*gin.Context, types2.AccountReportQueryReq) (*types.QueryResponse, error)
+	Handover(*gin.Context, types.SearchId, *types2.AccountHandoverReq) error
+	Transfer(*gin.Context, types.SearchId, *types2.AccountTransferReq) (*model.AccountTransfer, error)
+	Transfers(*gin.Context, types.SearchId, types2.AccountTransferQueryReq) (*types.QueryResponse, error)
+	CancelTransfer(*gin.Context, types2.AccountTransferCancelReq) error
+	Assignments(*gin.Context, types.SearchId) ([]*model.AccountAssignment, error)
+	Offboard(*gin.Context, types.SearchId, *types2.AccountOffboardReq) (*model.AccountOffboarding, error)
+	Offboarding(*gin.Context, types.SearchId) (*model.AccountOffboarding, error)
+}
@@ -0,0 +1,11 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+)
+
+type SecurityEventService interface {
+	List(*gin.Context, types2.SecurityEventQueryReq) (*types.QueryResponse, error)
+}
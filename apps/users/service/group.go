@@ -0,0 +1,23 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	upmsModel "github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+)
+
+type GroupService interface {
+	List(*gin.Context, types2.GroupQueryReq) (*types.QueryResponse, error)
+	Create(*gin.Context, *model.Group) error
+	Put(*gin.Context, types.SearchId, *model.Group) (*model.Group, error)
+	Delete(*gin.Context, types.SearchId) error
+	Accounts(*gin.Context, types.SearchId, types2.GroupAccountQueryReq) (*types.QueryResponse, error)
+	AddAccounts(*gin.Context, types.SearchId, *types2.GroupAccountReq) error
+	RemoveAccounts(*gin.Context, types.SearchId, *types2.GroupAccountReq) error
+	Children(*gin.Context, types.SearchId) ([]*model.Group, error)
+	SetChildren(*gin.Context, types.SearchId, *types2.GroupChildReq) ([]*model.Group, error)
+	Roles(*gin.Context, types.SearchId) ([]*upmsModel.Role, error)
+	SetRoles(*gin.Context, types.SearchId, *types2.GroupRoleReq) ([]*upmsModel.Role, error)
+}
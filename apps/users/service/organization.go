@@ -1,16 +1,28 @@
-package service
-
-import (
-	"github.com/gin-gonic/gin"
-	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
-	otypes "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
-	"github.com/yanshicheng/ikube-gin-xjob/common/types"
-)
-
-type OrganizationService interface {
-	Get(*gin.Context, otypes.OrganizationGetSearchReq) ([]*model.Organization, error)
-	List(*gin.Context, otypes.OrganizationGetSearchReq) ([]*model.Organization, error)
-	Create(*gin.Context, *model.Organization) error
-	Put(*gin.Context, types.SearchId, *model.Organization) (*model.Organization, error)
-	Delete(*gin.Context, types.SearchId) error
-}
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	otypes "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+)
+
+type OrganizationService interface {
+	Get(*gin.Context, otypes.OrganizationGetSearchReq) ([]*model.Organization, error)
+	List(*gin.Context, otypes.OrganizationGetSearchReq) ([]*model.Organization, int64, error)
+	Version(*gin.Context) (*otypes.OrganizationVersionResp, error)
+	Types(*gin.Context) []*otypes.OrganizationTypeResp
+	Managers(*gin.Context, types.SearchId) ([]*model.Account, error)
+	SetManagers(*gin.Context, types.SearchId, *otypes.OrganizationManagerReq) ([]*model.Account, error)
+	Create(*gin.Context, *model.Organization) error
+	Put(*gin.Context, types.SearchId, *model.Organization) (*model.Organization, error)
+	Delete(*gin.Context, types.SearchId) error
+	Move(*gin.Context, types.SearchId, *otypes.OrganizationMoveReq) (*model.Organization, error)
+	Merge(*gin.Context, types.SearchId, *otypes.OrganizationRestructureReq) (*otypes.OrganizationRestructureResp, error)
+	Dissolve(*gin.Context, types.SearchId, *otypes.OrganizationRestructureReq) (*otypes.OrganizationRestructureResp, error)
+	Stats(*gin.Context, types.SearchId) (*otypes.OrganizationStatsResp, error)
+	Moves(*gin.Context, types.SearchId, otypes.OrganizationMoveQueryReq) (*types.QueryResponse, error)
+	Members(*gin.Context, types.SearchId, otypes.OrganizationMembersQueryReq) (*types.QueryResponse, error)
+	Headcount(*gin.Context, otypes.OrganizationHeadcountReq) (*otypes.OrganizationHeadcountResp, error)
+	Export(*gin.Context, otypes.OrganizationExportReq) (*otypes.OrganizationExportResp, error)
+}
@@ -12,4 +12,5 @@ type PositionService interface {
 	Create(*gin.Context, *model.Position) error
 	Put(*gin.Context, types.SearchId, *model.Position) (*model.Position, error)
 	Delete(*gin.Context, types.SearchId) error
+	Reassign(*gin.Context, types.SearchId, *types2.PositionReassignReq) (*types2.PositionReassignResp, error)
 }
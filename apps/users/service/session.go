@@ -0,0 +1,13 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+)
+
+type SessionService interface {
+	List(*gin.Context) ([]*types2.Session, error)
+	Revoke(*gin.Context, types2.SessionSearchReq) error
+	AccountList(*gin.Context, types2.SessionAccountReq) ([]*types2.Session, error)
+	AccountRevoke(*gin.Context, types2.SessionAccountRevokeReq) error
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	types2 "github.com/yanshicheng/ikube-gin-xjob/apps/users/types"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+)
+
+type ApiTokenService interface {
+	Create(*gin.Context, *types2.ApiTokenCreateReq) (*types2.ApiTokenCreateResp, error)
+	List(*gin.Context) ([]*model.ApiToken, error)
+	Delete(*gin.Context, types.SearchId) error
+	AccountList(*gin.Context, types2.ApiTokenAccountReq) ([]*model.ApiToken, error)
+	AccountDelete(*gin.Context, types.SearchId) error
+	Verify(*gin.Context, string) (*utils.JWTClaims, error)
+}
@@ -1,71 +1,188 @@
-package types
-
-import (
-	"github.com/yanshicheng/ikube-gin-xjob/common/model"
-	"github.com/yanshicheng/ikube-gin-xjob/common/types"
-	"time"
-)
-
-type AccountLoginReq struct {
-	Account  string `json:"account" form:"account" binding:"required,max=32"`
-	Password string `json:"password" form:"password" binding:"required,max=128"`
-}
-
-type AccountChangePasswordReq struct {
-	Account       string `json:"account" form:"account" binding:"required,max=32"`
-	Password      string `json:"password" form:"password" binding:"required,max=128"`
-	NewPassword   string `json:"newPassword" form:"newPassword" binding:"required,max=128"`
-	ReNewPassword string `json:"reNewPassword" form:"reNewPassword" binding:"required,max=128,eqfield=NewPassword"`
-}
-
-type AccountRestPasswordReq struct {
-	Account string `json:"account" form:"account" binding:"required,max=32"`
-}
-
-type AccountIconResp struct {
-	IconPath string `json:"iconPath"`
-}
-
-type AccountQueryReq struct {
-	types.Pagination
-	UserName       string `json:"userName" form:"userName" uri:"userName"`
-	Email          string `json:"email" form:"email" uri:"email"`
-	WorkNumber     string `json:"workNumber" form:"workNumber" uri:"workNumber"`
-	Mobile         string `json:"mobile" form:"mobile" uri:"mobile"`
-	Account        string `json:"account" form:"account" uri:"account"`
-	IsFrozen       bool   `json:"isFrozen" form:"isFrozen"`
-	IsDisabled     bool   `json:"isDisabled" form:"isDisabled"`
-	IsLeave        bool   `json:"isLeave" form:"isLeave" `
-	PositionId     *uint  `json:"PositionId" form:"PositionId" ` // 对应职位表
-	OrganizationId *uint  `json:"organizationId" form:"organizationId"`
-}
-
-type AccountCreateReq struct {
-	UserName       string         `json:"userName" form:"userName" binding:"required,max=32"`
-	Account        string         `json:"account" form:"account"  binding:"required,max=32"`
-	Mobile         string         `json:"mobile" from:"mobile" binding:"required,max=11"`
-	Email          string         `json:"email" form:"email" binding:"required,max=36,email"`
-	WorkNumber     string         `json:"workNumber" form:"workNumber" binding:"required,max=24"`
-	HireDate       model.DateTime `json:"hireDate" form:"hireDate" binding:"required"  time_format:"2006-01-02"`
-	PositionId     uint           `json:"PositionId" form:"PositionId" binding:"required,number" gorm:"type:int;not null;comment:职位Id"` // 对应职位表
-	OrganizationId uint           `json:"organizationId" form:"organizationId" binding:"required,number" gorm:"type:int;not null;comment:组织ID"`
-}
-
-type AccountListResp struct {
-	Id             uint      `json:"id"`
-	CreatedAt      time.Time `json:"createdAt" gorm:"type:datetime;autoCreateTime;comment:创建时间"` // 创建时间
-	UpdatedAt      time.Time `json:"updatedAt" gorm:"type:datetime;autoUpdateTime;comment:更新时间"`
-	UserName       string    `json:"userName" form:"userName" binding:"required,max=32" gorm:"type:varchar(32);not null;comment:姓名"`
-	Account        string    `json:"account" form:"Account" binding:"required,max=32" gorm:"type:varchar(32);unique_index;not null;comment:账号"`
-	Password       string    `json:"password" form:"password" binding:"max=24" gorm:"type:varchar(256);not null;comment:密码"`
-	Icon           string    `json:"icon" form:"icon" gorm:"type:varchar(256);not null;comment:头像"`
-	Mobile         string    `json:"mobile" form:"mobile" binding:"required,max=11" gorm:"type:char(11);unique_index;not null;comment:手机号"`
-	Email          string    `json:"email" form:"email" binding:"required,max=36,email" gorm:"type:varchar(36);unique_index;not null;comment:邮箱"`
-	WorkNumber     string    `json:"workNumber" form:"workNumber" binding:"required,max=24" gorm:"type:varchar(24);unique_index;not null;comment:工号"`
-	HireDate       time.Time `json:"hireDate" form:"hireDate" binding:"required" gorm:"type:date;not null;comment:入职时间"`
-	IsFrozen       bool      `json:"isFrozen" form:"isFrozen" binding:"boolean" gorm:"type:tinyint(1);not null;default:false;comment:是否冻结"`
-	IsDisabled     bool      `json:"isDisabled" form:"isDisabled" binding:"boolean" gorm:"type:tinyint(1);not null;default:false;comment:是否禁用"`
-	IsLeave        bool      `json:"isLeave" form:"isLeave" binding:"boolean" gorm:"type:tinyint(1);not null;default:false;comment:是否离职"`
-	PositionId     int       `json:"PositionId" form:"PositionId" binding:"required,number" gorm:"type:int;not null;comment:职位Id"` // 对应职位表
-	OrganizationId uint      `json:"organizationId" form:"organizationId" binding:"required,number" gorm:"type:int;not null;comment:组织ID"`
-}
+package types
+
+import (
+	upmsModel "github.com/yanshicheng/ikube-gin-xjob/apps/upms/model"
+	usersModel "github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/model"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"time"
+)
+
+type AccountLoginReq struct {
+	Account  string `json:"account" form:"account" binding:"required,max=32"`
+	Password string `json:"password" form:"password" binding:"required,max=128"`
+}
+
+type AccountChangePasswordReq struct {
+	Account       string `json:"account" form:"account" binding:"required,max=32"`
+	Password      string `json:"password" form:"password" binding:"required,max=128"`
+	NewPassword   string `json:"newPassword" form:"newPassword" binding:"required,max=128"`
+	ReNewPassword string `json:"reNewPassword" form:"reNewPassword" binding:"required,max=128,eqfield=NewPassword"`
+}
+
+type AccountRestPasswordReq struct {
+	Account string `json:"account" form:"account" binding:"required,max=32"`
+}
+
+type AccountIconResp struct {
+	IconPath string `json:"iconPath"`
+}
+
+type AccountQueryReq struct {
+	types.Pagination
+	UserName                string `json:"userName" form:"userName" uri:"userName"`
+	Email                   string `json:"email" form:"email" uri:"email"`
+	WorkNumber              string `json:"workNumber" form:"workNumber" uri:"workNumber"`
+	Mobile                  string `json:"mobile" form:"mobile" uri:"mobile"`
+	Account                 string `json:"account" form:"account" uri:"account"`
+	MatchMode               string `json:"matchMode" form:"matchMode" binding:"omitempty,oneof=prefix contains"` // 文本匹配方式，默认 contains
+	IsDisabled              bool   `json:"isDisabled" form:"isDisabled"`                                         // 为 true 时只查询已禁用账号，false 不过滤
+	IsLeave                 bool   `json:"isLeave" form:"isLeave" `                                              // 为 true 时只查询已离职账号，false 不过滤
+	PositionId              *uint  `json:"PositionId" form:"PositionId" `                                        // 对应职位表
+	OrganizationId          *uint  `json:"organizationId" form:"organizationId"`
+	IncludeSubOrganizations bool   `json:"includeSubOrganizations" form:"includeSubOrganizations"` // 同时查询下级机构的账号
+	OrderBy                 string `json:"orderBy" form:"orderBy" binding:"omitempty,oneof=id userName account workNumber hireDate lastLoginTime createdAt organizationName positionName"`
+}
+
+type AccountCreateReq struct {
+	UserName       string         `json:"userName" form:"userName" binding:"required,max=32"`
+	Account        string         `json:"account" form:"account"  binding:"required,max=32"`
+	Mobile         string         `json:"mobile" from:"mobile" binding:"required,max=11"`
+	Email          string         `json:"email" form:"email" binding:"required,max=36,email"`
+	WorkNumber     string         `json:"workNumber" form:"workNumber" binding:"required,max=24"`
+	HireDate       model.DateTime `json:"hireDate" form:"hireDate" binding:"required"  time_format:"2006-01-02"`
+	PositionId     uint           `json:"PositionId" form:"PositionId" binding:"required,number" gorm:"type:int;not null;comment:职位Id"` // 对应职位表
+	OrganizationId uint           `json:"organizationId" form:"organizationId" binding:"required,number" gorm:"type:int;not null;comment:组织ID"`
+}
+
+type AccountListResp struct {
+	Id             uint      `json:"id"`
+	CreatedAt      time.Time `json:"createdAt" gorm:"type:datetime;autoCreateTime;comment:创建时间"` // 创建时间
+	UpdatedAt      time.Time `json:"updatedAt" gorm:"type:datetime;autoUpdateTime;comment:更新时间"`
+	UserName       string    `json:"userName" form:"userName" binding:"required,max=32" gorm:"type:varchar(32);not null;comment:姓名"`
+	Account        string    `json:"account" form:"Account" binding:"required,max=32" gorm:"type:varchar(32);unique_index;not null;comment:账号"`
+	Password       string    `json:"password" form:"password" binding:"max=24" gorm:"type:varchar(256);not null;comment:密码"`
+	Icon           string    `json:"icon" form:"icon" gorm:"type:varchar(256);not null;comment:头像"`
+	Mobile         string    `json:"mobile" form:"mobile" binding:"required,max=11" gorm:"type:char(11);unique_index;not null;comment:手机号"`
+	Email          string    `json:"email" form:"email" binding:"required,max=36,email" gorm:"type:varchar(36);unique_index;not null;comment:邮箱"`
+	WorkNumber     string    `json:"workNumber" form:"workNumber" binding:"required,max=24" gorm:"type:varchar(24);unique_index;not null;comment:工号"`
+	HireDate       time.Time `json:"hireDate" form:"hireDate" binding:"required" gorm:"type:date;not null;comment:入职时间"`
+	IsFrozen       bool      `json:"isFrozen" form:"isFrozen" binding:"boolean" gorm:"type:tinyint(1);not null;default:false;comment:是否冻结"`
+	IsDisabled     bool      `json:"isDisabled" form:"isDisabled" binding:"boolean" gorm:"type:tinyint(1);not null;default:false;comment:是否禁用"`
+	IsLeave        bool      `json:"isLeave" form:"isLeave" binding:"boolean" gorm:"type:tinyint(1);not null;default:false;comment:是否离职"`
+	PositionId     int       `json:"PositionId" form:"PositionId" binding:"required,number" gorm:"type:int;not null;comment:职位Id"` // 对应职位表
+	OrganizationId uint      `json:"organizationId" form:"organizationId" binding:"required,number" gorm:"type:int;not null;comment:组织ID"`
+}
+
+type AccountImportReq struct {
+	DryRun bool `json:"dryRun" form:"dryRun"` // 只校验不写入
+}
+
+// AccountImportRow 导入文件中的一行，列名与 AccountCreateReq 一致，机构和职位使用名称
+type AccountImportRow struct {
+	UserName     string `json:"userName" binding:"required,max=32"`
+	Account      string `json:"account" binding:"required,max=32"`
+	Mobile       string `json:"mobile" binding:"required,max=11"`
+	Email        string `json:"email" binding:"required,max=36,email"`
+	WorkNumber   string `json:"workNumber" binding:"required,max=24"`
+	HireDate     string `json:"hireDate" binding:"required,datetime=2006-01-02"`
+	Organization string `json:"organization" binding:"required"` // 机构名称路径，例如 公司/研发部/后端组
+	Position     string `json:"position" binding:"required,max=64"`
+}
+
+type AccountImportError struct {
+	Row    int               `json:"row"` // 文件中的行号，表头为第 1 行
+	Errors map[string]string `json:"errors"`
+}
+
+type AccountImportResp struct {
+	DryRun  bool                  `json:"dryRun"`
+	Total   int                   `json:"total"`
+	Created int                   `json:"created"`
+	Errors  []*AccountImportError `json:"errors"`
+}
+
+type AccountExportReq struct {
+	AccountQueryReq
+	Format string `json:"format" form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
+
+type AccountExportResp struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+type AccountProfileUpdateReq struct {
+	Mobile string `json:"mobile" form:"mobile" binding:"required,max=11"`
+}
+
+type AccountEmailChangeReq struct {
+	Email string `json:"email" form:"email" binding:"required,max=36,email"`
+}
+
+type AccountEmailVerifyReq struct {
+	Code string `json:"code" form:"code" binding:"required,len=6,number"`
+}
+
+type AccountMePasswordReq struct {
+	Password      string `json:"password" form:"password" binding:"required,max=128"`
+	NewPassword   string `json:"newPassword" form:"newPassword" binding:"required,max=128"`
+	ReNewPassword string `json:"reNewPassword" form:"reNewPassword" binding:"required,max=128,eqfield=NewPassword"`
+}
+
+// AccountMenusResp 当前账号的菜单树和授权的按钮权限标识，前端按权限标识控制按钮的显示
+type AccountMenusResp struct {
+	Menus       []*upmsModel.Menu `json:"menus"`
+	Permissions []string          `json:"permissions"`
+}
+
+type AccountForgotPasswordReq struct {
+	Account string `json:"account" form:"account" binding:"required,max=64"` // 账号或邮箱
+}
+
+type AccountResetPasswordByTokenReq struct {
+	Token         string `json:"token" form:"token" binding:"required,len=64,hexadecimal"`
+	NewPassword   string `json:"newPassword" form:"newPassword" binding:"required,max=128"`
+	ReNewPassword string `json:"reNewPassword" form:"reNewPassword" binding:"required,max=128,eqfield=NewPassword"`
+}
+
+// AccountManagerLevel 上级链中的一级，为某个机构的负责人
+type AccountManagerLevel struct {
+	OrganizationId   uint                  `json:"organizationId"`
+	OrganizationName string                `json:"organizationName"`
+	TreeName         string                `json:"treeName"` // 机构完整名称路径
+	Managers         []*usersModel.Account `json:"managers"`
+}
+
+type AccountReportQueryReq struct {
+	types.Pagination
+	Indirect bool `json:"indirect" form:"indirect" binding:"boolean"` // 是否包含间接下属
+}
+
+type AccountHandoverReq struct {
+	AccountId uint `json:"accountId" form:"accountId" binding:"required,number"` // 接手的账号
+}
+
+type AccountTransferReq struct {
+	OrganizationId uint           `json:"organizationId" form:"organizationId" binding:"required,number"`
+	PositionId     uint           `json:"positionId" form:"positionId" binding:"required,number"`
+	EffectiveDate  model.DateTime `json:"effectiveDate" form:"effectiveDate" binding:"required"` // 不晚于当天时立即生效
+	Reason         string         `json:"reason" form:"reason" binding:"max=128"`
+}
+
+type AccountTransferQueryReq struct {
+	types.Pagination
+	Status string `json:"status" form:"status" binding:"omitempty,oneof=pending applied canceled failed"`
+}
+
+type AccountTransferCancelReq struct {
+	Id         uint `json:"id" uri:"id" binding:"required,number"`
+	TransferId uint `json:"transferId" uri:"transferId" binding:"required,number"`
+}
+
+type AccountOffboardReq struct {
+	LeaveDate   model.DateTime `json:"leaveDate" form:"leaveDate" binding:"required"`
+	SuccessorId uint           `json:"successorId" form:"successorId" binding:"omitempty,number"` // 交接人，账号是机构负责人时必填
+	Reason      string         `json:"reason" form:"reason" binding:"max=128"`
+}
@@ -0,0 +1,17 @@
+package types
+
+import (
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"time"
+)
+
+type SecurityEventQueryReq struct {
+	types.Pagination
+	AccountId uint       `json:"accountId" form:"accountId"`
+	Account   string     `json:"account" form:"account"`
+	Type      string     `json:"type" form:"type"`
+	Ip        string     `json:"ip" form:"ip"`
+	Success   *bool      `json:"success" form:"success"`
+	StartTime *time.Time `json:"startTime" form:"startTime" time_format:"2006-01-02 15:04:05"`
+	EndTime   *time.Time `json:"endTime" form:"endTime" time_format:"2006-01-02 15:04:05"`
+}
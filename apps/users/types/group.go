@@ -0,0 +1,25 @@
+package types
+
+import "github.com/yanshicheng/ikube-gin-xjob/common/types"
+
+type GroupQueryReq struct {
+	types.Pagination
+	Name string `json:"name" form:"name"`
+}
+
+type GroupAccountQueryReq struct {
+	types.Pagination
+	Indirect bool `json:"indirect" form:"indirect"` // 同时查询全部下级用户组的成员
+}
+
+type GroupAccountReq struct {
+	AccountIds []uint `json:"accountIds" form:"accountIds" binding:"required,min=1"`
+}
+
+type GroupChildReq struct {
+	GroupIds []uint `json:"groupIds" form:"groupIds"` // 为空时清空下级用户组
+}
+
+type GroupRoleReq struct {
+	RoleIds []uint `json:"roleIds" form:"roleIds"` // 为空时解除全部角色
+}
@@ -1,9 +1,151 @@
-package types
-
-type OrganizationGetSearchReq struct {
-	Name string `json:"name" form:"name" uri:"name" `
-}
-
-type OrganizationPutReq struct {
-	Name string `json:"name" form:"name" uri:"name" binding:"required,max=32"`
-}
+package types
+
+import (
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"io"
+	"time"
+)
+
+type OrganizationGetSearchReq struct {
+	Name string `json:"name" form:"name" uri:"name" `
+}
+
+type OrganizationPutReq struct {
+	Name string `json:"name" form:"name" uri:"name" binding:"required,max=32"`
+}
+
+type OrganizationMoveReq struct {
+	ParentId uint `json:"parentId" form:"parentId" binding:"required,number"`
+}
+
+type OrganizationMoveQueryReq struct {
+	types.Pagination
+}
+
+type OrganizationStatsResp struct {
+	OrganizationId      uint   `json:"organizationId"`
+	TreeName            string `json:"treeName"`            // 从顶层到当前机构的名称路径
+	DescendantCount     int64  `json:"descendantCount"`     // 全部下级机构数量
+	AccountCount        int64  `json:"accountCount"`        // 直属账号数量
+	SubtreeAccountCount int64  `json:"subtreeAccountCount"` // 包含下级机构的账号数量
+}
+
+type OrganizationVersionResp struct {
+	Version int64 `json:"version"` // 机构树版本号，机构变更后递增
+}
+
+type OrganizationTypeResp struct {
+	Type      string   `json:"type"`
+	Title     string   `json:"title"`
+	Root      bool     `json:"root"`      // 是否可以作为主体机构
+	Parents   []string `json:"parents"`   // 允许的上级机构类型
+	MaxLevel  int      `json:"maxLevel"`  // 所在的最大层级
+	Positions bool     `json:"positions"` // 是否允许创建职位
+	Accounts  bool     `json:"accounts"`  // 是否允许账号归属
+}
+
+type OrganizationManagerReq struct {
+	AccountIds []uint `json:"accountIds" form:"accountIds" binding:"omitempty,dive,gt=0"` // 为空时清除全部负责人
+}
+
+type OrganizationMembersQueryReq struct {
+	types.Pagination
+	Date                    string `json:"date" form:"date" binding:"omitempty,datetime=2006-01-02"` // 默认当天
+	IncludeSubOrganizations bool   `json:"includeSubOrganizations" form:"includeSubOrganizations"`   // 同时查询下级机构的成员
+}
+
+type OrganizationHeadcountReq struct {
+	OrganizationId uint `json:"organizationId" form:"organizationId" binding:"omitempty,number"` // 为空时返回数据权限范围内的全部顶层机构
+}
+
+// HeadcountCount 按状态统计的人数，禁用不包含已离职
+type HeadcountCount struct {
+	Active   int64 `json:"active"`
+	Disabled int64 `json:"disabled"`
+	Left     int64 `json:"left"`
+}
+
+type PositionHeadcount struct {
+	PositionId   uint   `json:"positionId"`
+	PositionName string `json:"positionName"`
+	Count        int64  `json:"count"` // 不包含已离职
+}
+
+type OrganizationHeadcount struct {
+	OrganizationId uint                     `json:"organizationId"`
+	Name           string                   `json:"name"`
+	TreeName       string                   `json:"treeName"`
+	Type           string                   `json:"type"`
+	Direct         HeadcountCount           `json:"direct"`    // 直属账号
+	Total          HeadcountCount           `json:"total"`     // 包含数据权限范围内的全部下级机构
+	Positions      []*PositionHeadcount     `json:"positions"` // 包含数据权限范围内的全部下级机构，按人数倒序
+	Children       []*OrganizationHeadcount `json:"children,omitempty"`
+}
+
+type HeadcountPeriod struct {
+	Period string `json:"period"` // 按月为 2006-01，按天为 2006-01-02
+	Count  int64  `json:"count"`
+}
+
+type OrganizationHeadcountResp struct {
+	GeneratedAt   time.Time                `json:"generatedAt"` // 统计时间，缓存期内不变
+	Organizations []*OrganizationHeadcount `json:"organizations"`
+	Hires         []*HeadcountPeriod       `json:"hires"`  // 每月入职人数
+	Logins        []*HeadcountPeriod       `json:"logins"` // 每天登录人数，按最后登录时间统计
+}
+
+type OrganizationExportReq struct {
+	OrganizationId uint   `json:"organizationId" form:"organizationId" binding:"omitempty,number"`     // 为空时导出数据权限范围内的全部机构
+	Format         string `json:"format" form:"format" binding:"omitempty,oneof=json csv dot mermaid"` // 默认 json
+	Positions      bool   `json:"positions" form:"positions"`                                          // 是否包含机构的职位
+	Accounts       bool   `json:"accounts" form:"accounts"`                                            // 是否包含机构的在职账号姓名
+}
+
+type OrganizationExportResp struct {
+	FileName    string
+	ContentType string
+	Write       func(w io.Writer) error // 按机构树逐个节点写入，不在内存中生成完整文件
+}
+
+// OrganizationRestructureReq 合并或解散机构
+type OrganizationRestructureReq struct {
+	TargetId uint   `json:"targetId" form:"targetId" binding:"required,number"`              // 接收职位和账号的机构，合并时同时接收下级机构
+	Conflict string `json:"conflict" form:"conflict" binding:"omitempty,oneof=merge rename"` // 同名职位的处理方式，默认 merge
+	DryRun   bool   `json:"dryRun" form:"dryRun"`                                            // 只预览不写入
+}
+
+type RestructureOrganization struct {
+	Id      uint   `json:"id"`
+	Name    string `json:"name"`
+	OldPath string `json:"oldPath"`
+	NewPath string `json:"newPath"`
+}
+
+type RestructurePosition struct {
+	Id         uint   `json:"id"`
+	Name       string `json:"name"`
+	Action     string `json:"action"` // move 移动到目标机构，merge 合并到目标机构的同名职位，rename 重命名后移动
+	TargetId   uint   `json:"targetId"`
+	TargetName string `json:"targetName"`
+}
+
+type RestructureAccount struct {
+	Id                uint   `json:"id"`
+	UserName          string `json:"userName"`
+	OldOrganizationId uint   `json:"oldOrganizationId"`
+	OrganizationId    uint   `json:"organizationId"`
+	OldPositionId     uint   `json:"oldPositionId"`
+	PositionId        uint   `json:"positionId"`
+}
+
+// OrganizationRestructureResp 合并或解散涉及的全部数据，dryRun 时为预览结果
+type OrganizationRestructureResp struct {
+	DryRun         bool                       `json:"dryRun"`
+	OrganizationId uint                       `json:"organizationId"`
+	TargetId       uint                       `json:"targetId"`
+	Organizations  []*RestructureOrganization `json:"organizations"` // 移动的下级机构
+	Positions      []*RestructurePosition     `json:"positions"`
+	Accounts       []*RestructureAccount      `json:"accounts"`
+	Transfers      []uint                     `json:"transfers"` // 目标改为新机构或职位的待生效调动
+	Managers       []uint                     `json:"managers"`  // 解除的机构负责人账号
+}
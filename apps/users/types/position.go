@@ -1,5 +1,20 @@
 package types
 
+// PositionListSearchReq 不指定机构时查询数据权限范围内的全部职位
 type PositionListSearchReq struct {
-	OrganizationId uint `json:"organizationId"  binding:"required,number" form:"organizationId" uri:"organizationId" `
+	OrganizationId          uint   `json:"organizationId"  binding:"omitempty,number" form:"organizationId" uri:"organizationId" `
+	IncludeSubOrganizations bool   `json:"includeSubOrganizations" form:"includeSubOrganizations"` // 同时查询全部下级机构的职位
+	Grade                   string `json:"grade" form:"grade" binding:"max=16"`
+	Family                  string `json:"family" form:"family" binding:"max=32"`
+}
+
+// PositionReassignReq 把职位的全部账号调整到目标职位
+type PositionReassignReq struct {
+	TargetId uint `json:"targetId" form:"targetId" binding:"required,number"`
+}
+
+type PositionReassignResp struct {
+	PositionId uint   `json:"positionId"`
+	TargetId   uint   `json:"targetId"`
+	Accounts   []uint `json:"accounts"` // 调整的账号
 }
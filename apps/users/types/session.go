@@ -0,0 +1,33 @@
+package types
+
+import "time"
+
+// Session 登录会话，保存在 Redis 中，以令牌 jti 作为会话 ID
+type Session struct {
+	Id           string    `json:"id"`
+	AccountId    uint      `json:"accountId"`
+	Account      string    `json:"account"`
+	UserAgent    string    `json:"userAgent"`
+	Ip           string    `json:"ip"`
+	LoginTime    time.Time `json:"loginTime"`
+	LastActivity time.Time `json:"lastActivity"`
+	Current      bool      `json:"current"`             // 是否为当前请求所在的会话
+	RefreshId    string    `json:"refreshId,omitempty"` // 当前有效的刷新令牌编号，只保存在会话存储中，不返回给前端
+}
+
+type SessionSearchReq struct {
+	Id string `json:"id" form:"id" uri:"id" binding:"required,max=64"`
+}
+
+type SessionAccountReq struct {
+	AccountId uint `json:"accountId" form:"accountId" uri:"accountId" binding:"required,number"`
+}
+
+type SessionAccountRevokeReq struct {
+	AccountId uint   `json:"accountId" form:"accountId" uri:"accountId" binding:"required,number"`
+	Id        string `json:"id" form:"id" uri:"id" binding:"required,max=64"`
+}
+
+type AccountRefreshReq struct {
+	RefreshToken string `json:"refreshToken" form:"refreshToken" binding:"required"`
+}
@@ -0,0 +1,19 @@
+package types
+
+import "github.com/yanshicheng/ikube-gin-xjob/apps/users/model"
+
+type ApiTokenCreateReq struct {
+	Name       string   `json:"name" form:"name" binding:"required,max=32"`
+	Scopes     []string `json:"scopes" form:"scopes" binding:"required,min=1,dive,required,max=32"`
+	ExpireDays int      `json:"expireDays" form:"expireDays" binding:"min=0,max=3650"` // 0 表示永不过期
+}
+
+// ApiTokenCreateResp 创建令牌的返回，明文令牌只在此返回一次
+type ApiTokenCreateResp struct {
+	Token string `json:"token"`
+	*model.ApiToken
+}
+
+type ApiTokenAccountReq struct {
+	AccountId uint `json:"accountId" form:"accountId" uri:"accountId" binding:"required,number"`
+}
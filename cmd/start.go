@@ -1,148 +1,187 @@
-package cmd
-
-import (
-	"fmt"
-	ut "github.com/go-playground/universal-translator"
-	"github.com/spf13/cobra"
-	_ "github.com/yanshicheng/ikube-gin-xjob/apps/all"
-	"github.com/yanshicheng/ikube-gin-xjob/common/validator"
-	"github.com/yanshicheng/ikube-gin-xjob/global"
-	"github.com/yanshicheng/ikube-gin-xjob/pkg/config"
-	"github.com/yanshicheng/ikube-gin-xjob/pkg/http"
-	"github.com/yanshicheng/ikube-gin-xjob/pkg/logger"
-	"github.com/yanshicheng/ikube-gin-xjob/pkg/mysql"
-	"github.com/yanshicheng/ikube-gin-xjob/pkg/redis"
-	"github.com/yanshicheng/ikube-gin-xjob/pkg/version"
-	"github.com/yanshicheng/ikube-gin-xjob/router"
-	"github.com/yanshicheng/ikube-gin-xjob/utils"
-	"log"
-)
-
-// 注册所有服务
-
-// startCmd represents the start command
-var serviceCmd = &cobra.Command{
-	Use:   "start",
-	Short: fmt.Sprintf("%s API服务", version.IkubeopsProjectName),
-	Long:  fmt.Sprintf("%s API服务", version.IkubeopsProjectName),
-	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		cmd.SilenceUsage = true
-		// 初始化全局变量
-		err = config.InitIkubeConfig(confFile, confType, global.C)
-		if err != nil {
-			log.Printf("初始化配置文件失败: %s", err)
-			return err
-		}
-		// 检查 HttpPort 和 HealthPort 是否一致，一致则报错
-		if global.C.App.HttpPort == global.C.App.HealthPort {
-			log.Printf("HttpPort 和 HealthPort 不能一致")
-			return err
-		}
-		// 检查日志目录是否存在
-		if ok := utils.FolderExists(global.C.Logger.FilePath); !ok {
-			if err = utils.CreateFolder(global.C.Logger.FilePath); err != nil {
-				log.Printf("创建日志目录失败: %s", err)
-				return err
-			}
-		}
-		// 初始化日志
-		global.L, err = logger.InitIkubeLogger(
-			global.C.Logger.Output,
-			global.C.Logger.Output,
-			global.C.Logger.Level,
-			global.C.Logger.MaxFile,
-			global.C.Logger.Dev,
-			global.C.Logger.FilePath,
-			global.C.Logger.MaxSize,
-			global.C.Logger.MaxAge,
-			global.C.Logger.MaxBackups)
-		if err != nil {
-			log.Printf("初始化日志失败: %s", err)
-		}
-		global.LSys = global.L.Named("system")
-		global.LSys.Info("日志初始化成功!")
-
-		// 初始化数据库
-		if global.C.Mysql.Enable {
-			global.DB, err = mysql.InitIkubeGorm(
-				fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s", global.C.Mysql.User, global.C.Mysql.Password, global.C.Mysql.Host, global.C.Mysql.Port, global.C.Mysql.DbName, global.C.Mysql.Opts),
-				global.C.Logger.FilePath,
-				global.C.Mysql.MaxIdleConns,
-				global.C.Mysql.MaxOpenConns,
-				global.C.Mysql.LogToFile,
-				global.C.Mysql.Level,
-			)
-			if err != nil {
-				global.LSys.Error(fmt.Sprintf("初始化数据库失败: %s", err))
-				return err
-			} else {
-				if global.DB.Ping() != nil {
-					global.LSys.Error(fmt.Sprintf("Mysql 数据库连接失败: %s", err))
-					return err
-				}
-				global.LSys.Info("Mysql 数据库初始化成功!")
-			}
-		}
-
-		// 初始化 redis
-		if global.C.Redis.Enable {
-			global.RDB, err = redis.InitIkubeRedis(
-				fmt.Sprintf("%s:%d", global.C.Redis.Host, global.C.Redis.Port),
-				global.C.Redis.Password,
-				global.C.Redis.Db,
-				global.C.Redis.PoolSize,
-			)
-			if err != nil {
-				global.LSys.Error(fmt.Sprintf("Reids 初始化数据库失败: %s", err))
-				return err
-			} else {
-				if global.RDB.Ping() != nil {
-					global.LSys.Error(fmt.Sprintf("Redis 数据库连接失败: %s", err))
-				}
-				global.LSys.Info("Redis 数据库初始化成功!")
-			}
-		}
-
-		// 初始化Gin框架翻译器
-		var uni *ut.UniversalTranslator
-		if global.IkubeopsTrans, uni, err = validator.InitTrans(global.C.App.Language); err != nil {
-			global.LSys.Error(fmt.Sprintf("初始化Gin框架翻译器失败: %s", err))
-			return err
-		} else {
-
-		}
-		global.LSys.Info("Gin框架翻译器初始化成功!")
-
-		// 引入自定义验证器
-		if err = validator.RegisterValidatorsAndTranslations(validator.ValidatorSlice, uni); err != nil {
-			global.LSys.Error(fmt.Sprintf("注册验证器失败: %s", err))
-			return err
-		}
-		global.LSys.Info("验证器加载成功!")
-		// 启动服务
-		// 获取gin app 实例
-		businessRouter := router.InitGin()
-		// 初始化路由
-		healthRouter := router.HealthRouter()
-
-		// 初始化http管理器
-		serverManager := http.NewIkubeHttpManager(
-			global.C.App.MaxHeaderSize,
-			global.C.App.ReadTimeout,
-			global.C.App.ReadHeaderTimeout,
-			global.C.App.WriteTimeout,
-			global.C.App.ShutdownTimeout,
-			global.C.App.Tls,
-			global.C.App.CertFile,
-			global.C.App.KeyFile)
-		serverManager.AddServer(fmt.Sprintf("%s:%d", global.C.App.HttpAddr, global.C.App.HttpPort), "business", businessRouter)
-		serverManager.AddServer(fmt.Sprintf("%s:%d", global.C.App.HttpAddr, global.C.App.HealthPort), "healthy", healthRouter)
-		serverManager.Run()
-		return nil
-
-	},
-}
-
-func init() {
-	rootCommand.AddCommand(serviceCmd)
-}
+package cmd
+
+import (
+	"context"
+	"fmt"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/spf13/cobra"
+	_ "github.com/yanshicheng/ikube-gin-xjob/apps/all"
+	"github.com/yanshicheng/ikube-gin-xjob/common/validator"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/config"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/http"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/logger"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/mailer"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/mysql"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/redis"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/storage"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/version"
+	"github.com/yanshicheng/ikube-gin-xjob/router"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+	"log"
+)
+
+// 注册所有服务
+
+// startCmd represents the start command
+var serviceCmd = &cobra.Command{
+	Use:   "start",
+	Short: fmt.Sprintf("%s API服务", version.IkubeopsProjectName),
+	Long:  fmt.Sprintf("%s API服务", version.IkubeopsProjectName),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		cmd.SilenceUsage = true
+		// 初始化全局变量
+		err = config.InitIkubeConfig(confFile, confType, global.C)
+		if err != nil {
+			log.Printf("初始化配置文件失败: %s", err)
+			return err
+		}
+		// 检查 HttpPort 和 HealthPort 是否一致，一致则报错
+		if global.C.App.HttpPort == global.C.App.HealthPort {
+			log.Printf("HttpPort 和 HealthPort 不能一致")
+			return err
+		}
+		// 检查日志目录是否存在
+		if ok := utils.FolderExists(global.C.Logger.FilePath); !ok {
+			if err = utils.CreateFolder(global.C.Logger.FilePath); err != nil {
+				log.Printf("创建日志目录失败: %s", err)
+				return err
+			}
+		}
+		// 初始化日志
+		global.L, err = logger.InitIkubeLogger(
+			global.C.Logger.Output,
+			global.C.Logger.Output,
+			global.C.Logger.Level,
+			global.C.Logger.MaxFile,
+			global.C.Logger.Dev,
+			global.C.Logger.FilePath,
+			global.C.Logger.MaxSize,
+			global.C.Logger.MaxAge,
+			global.C.Logger.MaxBackups)
+		if err != nil {
+			log.Printf("初始化日志失败: %s", err)
+		}
+		global.LSys = global.L.Named("system")
+		global.LSys.Info("日志初始化成功!")
+
+		// 初始化数据库
+		if global.C.Mysql.Enable {
+			global.DB, err = mysql.InitIkubeGorm(
+				fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s", global.C.Mysql.User, global.C.Mysql.Password, global.C.Mysql.Host, global.C.Mysql.Port, global.C.Mysql.DbName, global.C.Mysql.Opts),
+				global.C.Logger.FilePath,
+				global.C.Mysql.MaxIdleConns,
+				global.C.Mysql.MaxOpenConns,
+				global.C.Mysql.LogToFile,
+				global.C.Mysql.Level,
+			)
+			if err != nil {
+				global.LSys.Error(fmt.Sprintf("初始化数据库失败: %s", err))
+				return err
+			} else {
+				if global.DB.Ping() != nil {
+					global.LSys.Error(fmt.Sprintf("Mysql 数据库连接失败: %s", err))
+					return err
+				}
+				global.LSys.Info("Mysql 数据库初始化成功!")
+			}
+		}
+
+		// 初始化 redis
+		if global.C.Redis.Enable {
+			global.RDB, err = redis.InitIkubeRedis(
+				fmt.Sprintf("%s:%d", global.C.Redis.Host, global.C.Redis.Port),
+				global.C.Redis.Password,
+				global.C.Redis.Db,
+				global.C.Redis.PoolSize,
+			)
+			if err != nil {
+				global.LSys.Error(fmt.Sprintf("Reids 初始化数据库失败: %s", err))
+				return err
+			} else {
+				if global.RDB.Ping() != nil {
+					global.LSys.Error(fmt.Sprintf("Redis 数据库连接失败: %s", err))
+				}
+				global.LSys.Info("Redis 数据库初始化成功!")
+			}
+		}
+
+		// 初始化对象存储
+		switch global.C.Storage.Type {
+		case "s3":
+			global.Storage, err = storage.NewS3Storage(
+				global.C.Storage.Endpoint,
+				global.C.Storage.AccessKey,
+				global.C.Storage.SecretKey,
+				global.C.Storage.Bucket,
+				global.C.Storage.Region,
+				global.C.Storage.UseSSL,
+				global.C.Storage.BaseURL,
+			)
+		default:
+			global.Storage, err = storage.NewLocalStorage(global.C.Storage.LocalPath, global.C.Storage.BaseURL)
+		}
+		if err != nil {
+			global.LSys.Error(fmt.Sprintf("初始化对象存储失败: %s", err))
+			return err
+		}
+		global.LSys.Info(fmt.Sprintf("对象存储初始化成功! 类型: %s", global.C.Storage.Type))
+
+		// 初始化邮件发送
+		if global.C.Mail.Enable {
+			global.Mailer = mailer.NewSMTPMailer(
+				global.C.Mail.Host,
+				global.C.Mail.Port,
+				global.C.Mail.Username,
+				global.C.Mail.Password,
+				global.C.Mail.From,
+				global.C.Mail.Tls,
+			)
+			global.LSys.Info("邮件发送初始化成功!")
+		}
+
+		// 初始化Gin框架翻译器
+		var uni *ut.UniversalTranslator
+		if global.IkubeopsTrans, uni, err = validator.InitTrans(global.C.App.Language); err != nil {
+			global.LSys.Error(fmt.Sprintf("初始化Gin框架翻译器失败: %s", err))
+			return err
+		} else {
+
+		}
+		global.LSys.Info("Gin框架翻译器初始化成功!")
+
+		// 引入自定义验证器
+		if err = validator.RegisterValidatorsAndTranslations(validator.ValidatorSlice, uni); err != nil {
+			global.LSys.Error(fmt.Sprintf("注册验证器失败: %s", err))
+			return err
+		}
+		global.LSys.Info("验证器加载成功!")
+		// 启动服务
+		// 获取gin app 实例
+		businessRouter := router.InitGin()
+		// 启动后台任务
+		router.InitJob(context.Background())
+		// 初始化路由
+		healthRouter := router.HealthRouter()
+
+		// 初始化http管理器
+		serverManager := http.NewIkubeHttpManager(
+			global.C.App.MaxHeaderSize,
+			global.C.App.ReadTimeout,
+			global.C.App.ReadHeaderTimeout,
+			global.C.App.WriteTimeout,
+			global.C.App.ShutdownTimeout,
+			global.C.App.Tls,
+			global.C.App.CertFile,
+			global.C.App.KeyFile)
+		serverManager.AddServer(fmt.Sprintf("%s:%d", global.C.App.HttpAddr, global.C.App.HttpPort), "business", businessRouter)
+		serverManager.AddServer(fmt.Sprintf("%s:%d", global.C.App.HttpAddr, global.C.App.HealthPort), "healthy", healthRouter)
+		serverManager.Run()
+		return nil
+
+	},
+}
+
+func init() {
+	rootCommand.AddCommand(serviceCmd)
+}
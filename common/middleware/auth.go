@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yanshicheng/ikube-gin-xjob/common/errorx"
+	"github.com/yanshicheng/ikube-gin-xjob/common/response"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"github.com/yanshicheng/ikube-gin-xjob/utils"
+)
+
+// TokenVerifier 非 JWT 令牌（例如 API 令牌）的校验函数，校验通过后返回与 JWT 相同结构的声明信息
+type TokenVerifier func(c *gin.Context, token string) (*utils.JWTClaims, error)
+
+// 按令牌前缀维护的校验函数
+var tokenVerifiers = map[string]TokenVerifier{}
+
+// RegistryTokenVerifier 注册指定前缀令牌的校验函数
+func RegistryTokenVerifier(prefix string, verifier TokenVerifier) {
+	tokenVerifiers[prefix] = verifier
+}
+
+// ClaimsChecker JWT 解析成功后的附加校验，例如会话是否已被吊销
+type ClaimsChecker func(c *gin.Context, claims *utils.JWTClaims) error
+
+var claimsCheckers []ClaimsChecker
+
+// RegistryClaimsChecker 注册 JWT 声明的附加校验
+func RegistryClaimsChecker(checker ClaimsChecker) {
+	claimsCheckers = append(claimsCheckers, checker)
+}
+
+// Auth 鉴权中间件，支持 JWT 访问令牌以及注册过的其他类型令牌
+func Auth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			response.FailedCode(c, errorx.ErrTokenMissing, "缺少认证信息")
+			c.Abort()
+			return
+		}
+		token, err := utils.ExtractBearerToken(header)
+		if err != nil {
+			response.FailedCode(c, errorx.ErrTokenInvalid, "认证信息格式错误")
+			c.Abort()
+			return
+		}
+		claims, code, err := verifyToken(c, token)
+		if err != nil {
+			global.LSys.Debug("鉴权失败: " + err.Error())
+			response.FailedCode(c, code, err.Error())
+			c.Abort()
+			return
+		}
+		c.Set(types.JwtClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// RequireAdmin 管理接口鉴权，必须在 Auth 之后使用，令牌中的角色包含任一管理员角色时放行
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := utils.GetClaims(c)
+		if err != nil {
+			response.FailedCode(c, errorx.ErrTokenInvalid, err.Error())
+			c.Abort()
+			return
+		}
+		for _, role := range claims.Application.Role {
+			for _, admin := range global.C.Auth.AdminRoles {
+				if role == admin {
+					c.Next()
+					return
+				}
+			}
+		}
+		response.FailedCode(c, errorx.ErrPermissionDenied, errPermissionDenied.Error())
+		c.Abort()
+	}
+}
+
+func verifyToken(c *gin.Context, token string) (*utils.JWTClaims, errorx.ErrorCode, error) {
+	// 其他类型令牌按前缀匹配
+	for prefix, verifier := range tokenVerifiers {
+		if strings.HasPrefix(token, prefix) {
+			claims, err := verifier(c, token)
+			if err != nil {
+				return nil, errorx.ErrTokenInvalid, err
+			}
+			return claims, errorx.ErrNormal, nil
+		}
+	}
+	claims, err := utils.ParseJWT("Bearer " + token)
+	if err != nil {
+		if utils.IsTokenExpired(err) {
+			return nil, errorx.ErrTokenExpired, errTokenExpired
+		}
+		return nil, errorx.ErrTokenInvalid, errTokenInvalid
+	}
+	// 刷新令牌不能用于访问接口
+	if claims.Type != types.TokenTypeAccess {
+		return nil, errorx.ErrTokenInvalid, errTokenInvalid
+	}
+	for _, checker := range claimsCheckers {
+		if err := checker(c, claims); err != nil {
+			return nil, errorx.ErrLoginInvalid, err
+		}
+	}
+	return claims, errorx.ErrNormal, nil
+}
+
+var (
+	errTokenExpired = errors.New("认证信息已过期，请重新登录")
+	errTokenInvalid = errors.New("认证信息无效")
+	// errPermissionDenied 非管理员调用管理接口
+	errPermissionDenied = errors.New("没有权限执行该操作，需要管理员角色")
+)
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// DeletedAtColumn 软删除列，未删除的记录为 0
+const DeletedAtColumn = "deleted_at"
+
+// UniqueIndexer 声明忽略已删除记录的唯一索引，key 为索引名，value 为索引列
+// 迁移时自动在索引末尾追加 deleted_at 列，删除后可以重新创建相同的数据
+type UniqueIndexer interface {
+	UniqueIndexes() map[string][]string
+}
+
+// AfterMigrator 迁移表结构后执行的数据回填，必须可以重复执行
+type AfterMigrator interface {
+	AfterMigrate(db *gorm.DB) error
+}
+
+// Migrate 自动迁移表结构，并维护 UniqueIndexer 声明的唯一索引，最后执行 AfterMigrator 的数据回填
+func Migrate(db *gorm.DB, models ...interface{}) error {
+	for _, m := range models {
+		if err := migrateDeletedAt(db, m); err != nil {
+			return err
+		}
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		return err
+	}
+	for _, m := range models {
+		if indexer, ok := m.(UniqueIndexer); ok {
+			if err := migrateUniqueIndexes(db, m, indexer.UniqueIndexes()); err != nil {
+				return err
+			}
+		}
+	}
+	for _, m := range models {
+		if migrator, ok := m.(AfterMigrator); ok {
+			if err := migrator.AfterMigrate(db); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deletedAtTempColumn 转换 deleted_at 时使用的临时列
+const deletedAtTempColumn = "deleted_at_ms"
+
+// migrateDeletedAt 将旧版本 datetime 类型的 deleted_at 转换为毫秒时间戳，未删除的记录为 0
+// 转换分为新增临时列、回填、删除旧列、重命名临时列四步，mysql 的 DDL 不能回滚，
+// 中途失败后再次迁移时根据两列的状态从中断的步骤继续
+func migrateDeletedAt(db *gorm.DB, m interface{}) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(m) {
+		return nil
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(m); err != nil {
+		return err
+	}
+	table := stmt.Quote(stmt.Schema.Table)
+	hasColumn, hasTemp := migrator.HasColumn(m, DeletedAtColumn), migrator.HasColumn(m, deletedAtTempColumn)
+	datetime := false
+	if hasColumn {
+		columnTypes, err := migrator.ColumnTypes(m)
+		if err != nil {
+			return err
+		}
+		for _, column := range columnTypes {
+			if column.Name() == DeletedAtColumn {
+				datetime = strings.EqualFold(column.DatabaseTypeName(), "datetime")
+			}
+		}
+		if !datetime && !hasTemp {
+			// 已经是时间戳
+			return nil
+		}
+	} else if !hasTemp {
+		// 新表由 AutoMigrate 创建
+		return nil
+	}
+	var sqls []string
+	switch {
+	case datetime:
+		// 未开始，或者已新增临时列但未删除旧列，回填可以重复执行
+		if !hasTemp {
+			sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s ADD COLUMN `deleted_at_ms` bigint unsigned NOT NULL DEFAULT 0", table))
+		}
+		sqls = append(sqls,
+			fmt.Sprintf("UPDATE %s SET `deleted_at_ms` = UNIX_TIMESTAMP(`deleted_at`) * 1000 WHERE `deleted_at` IS NOT NULL", table),
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN `deleted_at`", table),
+			fmt.Sprintf("ALTER TABLE %s CHANGE `deleted_at_ms` `deleted_at` bigint unsigned NOT NULL DEFAULT 0", table),
+		)
+	case hasColumn:
+		// 旧列删除后迁移中断，AutoMigrate 又新建了时间戳类型的 deleted_at，从临时列找回删除时间
+		sqls = append(sqls,
+			fmt.Sprintf("UPDATE %s SET `deleted_at` = `deleted_at_ms` WHERE `deleted_at` = 0", table),
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN `deleted_at_ms`", table),
+		)
+	default:
+		// 旧列已删除，只差重命名临时列
+		sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s CHANGE `deleted_at_ms` `deleted_at` bigint unsigned NOT NULL DEFAULT 0", table))
+	}
+	for _, sql := range sqls {
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("转换 %s.deleted_at 失败: %w", stmt.Schema.Table, err)
+		}
+	}
+	return nil
+}
+
+func migrateUniqueIndexes(db *gorm.DB, m interface{}, indexes map[string][]string) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(m); err != nil {
+		return err
+	}
+	table := stmt.Schema.Table
+	existing, err := db.Migrator().GetIndexes(m)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]string, len(existing))
+	for _, idx := range existing {
+		unique, _ := idx.Unique()
+		primary, _ := idx.PrimaryKey()
+		if !unique || primary {
+			continue
+		}
+		current[idx.Name()] = strings.Join(idx.Columns(), ",")
+	}
+	// 删除未声明或者列不一致的历史唯一索引，例如字段上的 unique 标签创建的单列索引
+	for name, columns := range current {
+		if want, ok := indexes[name]; ok && strings.Join(indexColumns(want), ",") == columns {
+			continue
+		}
+		if err := db.Exec(fmt.Sprintf("DROP INDEX %s ON %s", stmt.Quote(name), stmt.Quote(table))).Error; err != nil {
+			return fmt.Errorf("删除索引 %s.%s 失败: %w", table, name, err)
+		}
+		delete(current, name)
+	}
+	for name, columns := range indexes {
+		if _, ok := current[name]; ok {
+			continue
+		}
+		quoted := make([]string, 0, len(columns)+1)
+		for _, column := range indexColumns(columns) {
+			quoted = append(quoted, stmt.Quote(column))
+		}
+		if err := db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s)", stmt.Quote(name), stmt.Quote(table), strings.Join(quoted, ","))).Error; err != nil {
+			return fmt.Errorf("创建索引 %s.%s 失败: %w", table, name, err)
+		}
+	}
+	return nil
+}
+
+// indexColumns 返回追加了 deleted_at 的索引列，不修改传入的切片
+func indexColumns(columns []string) []string {
+	return append(append(make([]string, 0, len(columns)+1), columns...), DeletedAtColumn)
+}
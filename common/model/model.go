@@ -1,13 +1,13 @@
 package model
 
 import (
-	"gorm.io/gorm"
+	"gorm.io/plugin/soft_delete"
 	"time"
 )
 
 type Model struct {
-	ID        uint            `json:"id" gorm:"primaryKey;autoIncrement;comment:自增主键"`             // 自增主键
-	CreatedAt time.Time       `json:"createdAt" gorm:"type:datetime;autoCreateTime;comment:创建时间"`  // 创建时间
-	UpdatedAt time.Time       `json:"updatedAt" gorm:"type:datetime;autoUpdateTime;comment:更新时间"`  // 更新时间
-	DeletedAt *gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"type:datetime;index;comment:删除时间"` // 删除时间
+	ID        uint                  `json:"id" gorm:"primaryKey;autoIncrement;comment:自增主键"`                                   // 自增主键
+	CreatedAt time.Time             `json:"createdAt" gorm:"type:datetime;autoCreateTime;comment:创建时间"`                        // 创建时间
+	UpdatedAt time.Time             `json:"updatedAt" gorm:"type:datetime;autoUpdateTime;comment:更新时间"`                        // 更新时间
+	DeletedAt soft_delete.DeletedAt `json:"deletedAt,omitempty" gorm:"softDelete:milli;not null;default:0;index;comment:删除时间"` // 删除时间，毫秒时间戳，0 表示未删除
 }
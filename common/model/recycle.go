@@ -0,0 +1,43 @@
+package model
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"sort"
+)
+
+// Recyclable 回收站资源，注册后可以通过回收站接口查询、恢复和彻底删除已删除的记录
+type Recyclable struct {
+	Name  string      // 资源名称，用于接口路径
+	Title string      // 资源中文名称，用于提示信息
+	Model interface{} // 资源模型指针，必须嵌入 Model
+	// Validate 恢复前在同一事务内校验关联数据，必要时修正记录，record 为待恢复的记录，类型与 Model 一致，可以为空
+	Validate func(tx *gorm.DB, record interface{}) error
+	// Restored 恢复的事务提交后调用，用于清理缓存等，可以为空
+	Restored func(ctx context.Context)
+}
+
+var recyclables = map[string]*Recyclable{}
+
+// RegisterRecyclable 注册回收站资源，名称重复时后注册的覆盖先注册的
+func RegisterRecyclable(r ...*Recyclable) {
+	for _, item := range r {
+		recyclables[item.Name] = item
+	}
+}
+
+// GetRecyclable 按名称查询回收站资源
+func GetRecyclable(name string) (*Recyclable, bool) {
+	r, ok := recyclables[name]
+	return r, ok
+}
+
+// Recyclables 返回全部回收站资源，按名称排序
+func Recyclables() []*Recyclable {
+	list := make([]*Recyclable, 0, len(recyclables))
+	for _, r := range recyclables {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
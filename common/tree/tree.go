@@ -0,0 +1,181 @@
+package tree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Node 树节点，上级 ID 为 0 表示顶层节点
+type Node[T any] interface {
+	TreeId() uint
+	TreeParentId() uint
+	TreeChildren() []T
+	SetTreeChildren([]T)
+}
+
+// Tree 由扁平列表构建的树
+type Tree[T Node[T]] struct {
+	Roots   []T // 上级 ID 为 0 的节点
+	Orphans []T // 上级不在列表中的节点，由调用方决定作为根节点展示还是丢弃
+	Cycles  []T // 上级关系形成环的节点，在环上断开后作为根节点，其余节点仍然挂在它的下面
+	nodes   map[uint]T
+}
+
+// Build 按上级 ID 组装树形结构，less 为空时保持列表顺序，同级节点按 less 排序
+// 会覆盖节点原有的子节点，同一批节点可以重复构建。上级是自身的节点作为孤立节点
+func Build[T Node[T]](nodes []T, less func(a, b T) bool) *Tree[T] {
+	list := append([]T(nil), nodes...)
+	if less != nil {
+		sort.SliceStable(list, func(i, j int) bool { return less(list[i], list[j]) })
+	}
+	t := &Tree[T]{nodes: make(map[uint]T, len(list))}
+	for _, n := range list {
+		n.SetTreeChildren(nil)
+		t.nodes[n.TreeId()] = n
+	}
+	for _, n := range list {
+		parentId := n.TreeParentId()
+		if parentId == 0 {
+			t.Roots = append(t.Roots, n)
+		} else if parent, ok := t.nodes[parentId]; ok && parentId != n.TreeId() {
+			parent.SetTreeChildren(append(parent.TreeChildren(), n))
+		} else {
+			t.Orphans = append(t.Orphans, n)
+		}
+	}
+	// 从顶层节点和孤立节点无法到达的节点，上级关系中一定存在环
+	reached := make(map[uint]bool, len(list))
+	var mark func(nodes []T)
+	mark = func(nodes []T) {
+		for _, n := range nodes {
+			if !reached[n.TreeId()] {
+				reached[n.TreeId()] = true
+				mark(n.TreeChildren())
+			}
+		}
+	}
+	mark(t.Roots)
+	mark(t.Orphans)
+	for _, n := range list {
+		if reached[n.TreeId()] {
+			continue
+		}
+		// 沿上级向上查找，第一个重复出现的节点在环上，断开它和上级的关系
+		seen := make(map[uint]bool)
+		for !seen[n.TreeId()] {
+			seen[n.TreeId()] = true
+			n = t.nodes[n.TreeParentId()]
+		}
+		parent := t.nodes[n.TreeParentId()]
+		children := make([]T, 0, len(parent.TreeChildren()))
+		for _, child := range parent.TreeChildren() {
+			if child.TreeId() != n.TreeId() {
+				children = append(children, child)
+			}
+		}
+		parent.SetTreeChildren(children)
+		t.Cycles = append(t.Cycles, n)
+		mark([]T{n})
+	}
+	return t
+}
+
+// Forest 顶层节点、孤立节点和环上断开的节点一起作为根节点
+func (t *Tree[T]) Forest() []T {
+	forest := make([]T, 0, len(t.Roots)+len(t.Orphans)+len(t.Cycles))
+	return append(append(append(forest, t.Roots...), t.Orphans...), t.Cycles...)
+}
+
+// OrphanIds 上级不在列表中的节点 ID
+func (t *Tree[T]) OrphanIds() []uint {
+	return Ids(t.Orphans)
+}
+
+// CycleIds 上级关系形成环、在环上断开的节点 ID
+func (t *Tree[T]) CycleIds() []uint {
+	return Ids(t.Cycles)
+}
+
+// Ids 返回节点的 ID
+func Ids[T Node[T]](nodes []T) []uint {
+	ids := make([]uint, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.TreeId())
+	}
+	return ids
+}
+
+// Get 按 ID 查找节点
+func (t *Tree[T]) Get(id uint) (T, bool) {
+	n, ok := t.nodes[id]
+	return n, ok
+}
+
+// Path 返回从顶层到节点自身的路径，上级缺失时从最近的存在的上级开始，数据存在环时截断
+func (t *Tree[T]) Path(id uint) []T {
+	var path []T
+	for n, ok := t.nodes[id]; ok && len(path) < len(t.nodes); n, ok = t.nodes[n.TreeParentId()] {
+		path = append([]T{n}, path...)
+		if n.TreeParentId() == 0 {
+			break
+		}
+	}
+	return path
+}
+
+// Flatten 按先序遍历展开为列表
+func Flatten[T Node[T]](roots []T) []T {
+	var list []T
+	var walk func(nodes []T)
+	walk = func(nodes []T) {
+		for _, n := range nodes {
+			list = append(list, n)
+			walk(n.TreeChildren())
+		}
+	}
+	walk(roots)
+	return list
+}
+
+// Search 保留匹配的节点及其全部上级节点并组装为树，上级不在列表中的节点作为根节点
+func Search[T Node[T]](nodes []T, match func(T) bool, less func(a, b T) bool) []T {
+	byId := make(map[uint]T, len(nodes))
+	order := make(map[uint]int, len(nodes))
+	for i, n := range nodes {
+		byId[n.TreeId()] = n
+		order[n.TreeId()] = i
+	}
+	keep := make(map[uint]bool)
+	var kept []T
+	for _, n := range nodes {
+		if !match(n) {
+			continue
+		}
+		for cur, ok := n, true; ok && !keep[cur.TreeId()]; cur, ok = byId[cur.TreeParentId()] {
+			keep[cur.TreeId()] = true
+			kept = append(kept, cur)
+		}
+	}
+	// 保持原列表的顺序
+	sort.SliceStable(kept, func(i, j int) bool { return order[kept[i].TreeId()] < order[kept[j].TreeId()] })
+	return Build(kept, less).Forest()
+}
+
+// Height 返回以节点为根的子树高度，叶子节点为 1
+func Height[T Node[T]](n T) int {
+	height := 0
+	for _, child := range n.TreeChildren() {
+		if h := Height(child); h > height {
+			height = h
+		}
+	}
+	return height + 1
+}
+
+// ChildLevel 根据上级节点的层级计算子节点层级，超过最大层级时返回错误
+func ChildLevel(parentLevel, maxLevel int) (int, error) {
+	if parentLevel+1 > maxLevel {
+		return 0, fmt.Errorf("层级不能超过 %d 级", maxLevel)
+	}
+	return parentLevel + 1, nil
+}
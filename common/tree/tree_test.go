@@ -0,0 +1,172 @@
+package tree_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yanshicheng/ikube-gin-xjob/common/tree"
+)
+
+type node struct {
+	id       uint
+	parentId uint
+	name     string
+	children []*node
+}
+
+func (n *node) TreeId() uint              { return n.id }
+func (n *node) TreeParentId() uint        { return n.parentId }
+func (n *node) TreeChildren() []*node     { return n.children }
+func (n *node) SetTreeChildren(c []*node) { n.children = c }
+
+func newNode(id, parentId uint, name string) *node {
+	return &node{id: id, parentId: parentId, name: name}
+}
+
+// sample 1 公司 -> 2 研发部 -> 4 后端组，1 公司 -> 3 市场部
+func sample() []*node {
+	return []*node{
+		newNode(4, 2, "后端组"),
+		newNode(3, 1, "市场部"),
+		newNode(2, 1, "研发部"),
+		newNode(1, 0, "公司"),
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []*node
+		less    func(a, b *node) bool
+		roots   []uint
+		orphans []uint
+		cycles  []uint
+		flatten []uint
+	}{
+		{
+			name:    "保持列表顺序",
+			nodes:   sample(),
+			roots:   []uint{1},
+			flatten: []uint{1, 3, 2, 4},
+		},
+		{
+			name:    "按 less 排序同级节点",
+			nodes:   sample(),
+			less:    func(a, b *node) bool { return a.id < b.id },
+			roots:   []uint{1},
+			flatten: []uint{1, 2, 4, 3},
+		},
+		{
+			name:    "上级不在列表中",
+			nodes:   []*node{newNode(1, 0, "公司"), newNode(5, 9, "外包组"), newNode(6, 5, "测试组")},
+			roots:   []uint{1},
+			orphans: []uint{5},
+			flatten: []uint{1, 5, 6},
+		},
+		{
+			name:    "上级是自身",
+			nodes:   []*node{newNode(1, 0, "公司"), newNode(7, 7, "自环")},
+			roots:   []uint{1},
+			orphans: []uint{7},
+			flatten: []uint{1, 7},
+		},
+		{
+			name: "上级关系形成环",
+			nodes: []*node{
+				newNode(1, 0, "公司"),
+				newNode(2, 3, "环 A"),
+				newNode(3, 2, "环 B"),
+				newNode(4, 3, "环下级"),
+			},
+			roots:   []uint{1},
+			cycles:  []uint{2},
+			flatten: []uint{1, 2, 3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := tree.Build(tt.nodes, tt.less)
+			assert.Equal(t, tt.roots, tree.Ids(tr.Roots), "根节点")
+			assert.Equal(t, ids(tt.orphans), tr.OrphanIds(), "孤立节点")
+			assert.Equal(t, ids(tt.cycles), tr.CycleIds(), "环上断开的节点")
+			assert.Equal(t, tt.flatten, tree.Ids(tree.Flatten(tr.Forest())), "先序遍历")
+		})
+	}
+}
+
+func TestBuildRepeat(t *testing.T) {
+	nodes := sample()
+	tree.Build(nodes, nil)
+	tr := tree.Build(nodes, nil)
+	assert.Len(t, tree.Flatten(tr.Roots), len(nodes), "重复构建不应重复挂载子节点")
+}
+
+func TestPath(t *testing.T) {
+	tr := tree.Build(sample(), nil)
+	assert.Equal(t, []uint{1, 2, 4}, tree.Ids(tr.Path(4)))
+	assert.Equal(t, []uint{1}, tree.Ids(tr.Path(1)))
+	assert.Empty(t, tr.Path(99), "节点不存在")
+
+	orphan := tree.Build([]*node{newNode(5, 9, "外包组"), newNode(6, 5, "测试组")}, nil)
+	assert.Equal(t, []uint{5, 6}, tree.Ids(orphan.Path(6)), "上级缺失时从最近的上级开始")
+
+	cycle := tree.Build([]*node{newNode(2, 3, "环 A"), newNode(3, 2, "环 B")}, nil)
+	assert.Len(t, cycle.Path(2), 2, "存在环时截断")
+}
+
+func TestSearch(t *testing.T) {
+	match := func(name string) func(*node) bool {
+		return func(n *node) bool { return n.name == name }
+	}
+	result := tree.Search(sample(), match("后端组"), nil)
+	assert.Equal(t, []uint{1, 2, 4}, tree.Ids(tree.Flatten(result)), "保留匹配节点的全部上级")
+
+	result = tree.Search(sample(), match("不存在"), nil)
+	assert.Empty(t, result)
+
+	nodes := []*node{newNode(5, 9, "外包组"), newNode(6, 5, "测试组")}
+	result = tree.Search(nodes, match("测试组"), nil)
+	assert.Equal(t, []uint{5}, tree.Ids(result), "上级不在列表中的节点作为根节点")
+
+	nodes = []*node{newNode(2, 3, "环 A"), newNode(3, 2, "环 B")}
+	result = tree.Search(nodes, match("环 A"), nil)
+	assert.ElementsMatch(t, []uint{2, 3}, tree.Ids(tree.Flatten(result)), "存在环时不应死循环")
+}
+
+func TestHeight(t *testing.T) {
+	tr := tree.Build(sample(), nil)
+	root, _ := tr.Get(1)
+	assert.Equal(t, 3, tree.Height(root))
+	leaf, _ := tr.Get(3)
+	assert.Equal(t, 1, tree.Height(leaf))
+}
+
+func TestChildLevel(t *testing.T) {
+	tests := []struct {
+		parentLevel int
+		maxLevel    int
+		want        int
+		wantErr     bool
+	}{
+		{parentLevel: 0, maxLevel: 5, want: 1},
+		{parentLevel: 4, maxLevel: 5, want: 5},
+		{parentLevel: 5, maxLevel: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		level, err := tree.ChildLevel(tt.parentLevel, tt.maxLevel)
+		if tt.wantErr {
+			assert.Error(t, err, "超过最大层级")
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, level)
+	}
+}
+
+// ids 期望值为空时与 Ids 的返回值一致
+func ids(list []uint) []uint {
+	if list == nil {
+		return []uint{}
+	}
+	return list
+}
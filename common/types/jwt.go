@@ -1 +1,11 @@
-package types
+package types
+
+// JwtClaimsKey 认证中间件写入 gin.Context 的声明信息键
+const JwtClaimsKey = "jwtClaims"
+
+// 令牌类型
+const (
+	TokenTypeAccess  = "access"  // 访问令牌
+	TokenTypeRefresh = "refresh" // 刷新令牌
+	TokenTypeApi     = "api"     // API 令牌
+)
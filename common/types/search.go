@@ -1,5 +1,5 @@
-package types
-
-type SearchId struct {
-	Id uint `json:"id" form:"id" uri:"id" binding:"required,number"`
-}
+package types
+
+type SearchId struct {
+	Id uint `json:"id" form:"id" uri:"id" binding:"required,number"`
+}
@@ -1,265 +1,265 @@
-// Package docs Code generated by swaggo/swag. DO NOT EDIT
-package docs
-
-import "github.com/swaggo/swag"
-
-const docTemplate = `{
-    "schemes": {{ marshal .Schemes }},
-    "swagger": "2.0",
-    "info": {
-        "description": "{{escape .Description}}",
-        "title": "{{.Title}}",
-        "termsOfService": "http://swagger.io/terms/",
-        "contact": {
-            "name": "官网地址",
-            "url": "http://www.ikubeops.com",
-            "email": "ikubeops@gmail.com"
-        },
-        "version": "{{.Version}}"
-    },
-    "host": "{{.Host}}",
-    "basePath": "{{.BasePath}}",
-    "paths": {
-        "/api/user/info": {
-            "get": {
-                "security": [
-                    {
-                        "types.SearchId": []
-                    }
-                ],
-                "description": "获取当前用户的详细信息",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "获取用户信息",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/model.Book"
-                        }
-                    }
-                }
-            }
-        },
-        "/book-shelf/book": {
-            "post": {
-                "description": "创建书籍接口",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "书籍管理"
-                ],
-                "summary": "创建书籍接口",
-                "parameters": [
-                    {
-                        "description": "书籍名称",
-                        "name": "req",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.Book"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "desc",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/types.Data"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/model.Book"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "errorx.ErrorCode": {
-            "type": "integer",
-            "enum": [
-                0,
-                99999,
-                11000,
-                11001,
-                11002,
-                11003,
-                11004,
-                10110,
-                10111,
-                10130,
-                10131,
-                18000,
-                18004,
-                10101,
-                10102,
-                10103,
-                10104,
-                10105,
-                10106,
-                10500
-            ],
-            "x-enum-comments": {
-                "ErrBusinessLogic": "业务逻辑错误",
-                "ErrDataConflict": "数据冲突",
-                "ErrDataCreation": "数据创建失败",
-                "ErrDataDeletion": "数据删除失败",
-                "ErrDataNotFound": "数据未找到",
-                "ErrDatabase": "数据库相关错误",
-                "ErrGeneric": "正常",
-                "ErrLoginExpired": "登录过期",
-                "ErrLoginInvalid": "登录信息无效",
-                "ErrParamParse": "参数解析失败",
-                "ErrPermissionDenied": "权限不足",
-                "ErrRoleNotFound": "角色未找到",
-                "ErrToOperation": "\"to\" 操作相关错误",
-                "ErrTokenBlacklisted": "Token 被列入黑名单",
-                "ErrTokenExpired": "Token 失效",
-                "ErrTokenInvalid": "Token 解析失败",
-                "ErrTokenMissing": "缺少 Token",
-                "ErrTokenRefresh": "Token 刷新失败"
-            },
-            "x-enum-varnames": [
-                "ErrNormal",
-                "ErrGeneric",
-                "ErrTokenMissing",
-                "ErrTokenExpired",
-                "ErrTokenInvalid",
-                "ErrTokenRefresh",
-                "ErrTokenBlacklisted",
-                "ErrLoginExpired",
-                "ErrLoginInvalid",
-                "ErrPermissionDenied",
-                "ErrRoleNotFound",
-                "ErrDatabase",
-                "ErrDataConflict",
-                "ErrBusinessLogic",
-                "ErrParamParse",
-                "ErrDataNotFound",
-                "ErrDataCreation",
-                "ErrDataDeletion",
-                "ErrToOperation",
-                "ErrServerErr"
-            ]
-        },
-        "gorm.DeletedAt": {
-            "type": "object",
-            "properties": {
-                "time": {
-                    "type": "string"
-                },
-                "valid": {
-                    "description": "Valid is true if Time is not NULL",
-                    "type": "boolean"
-                }
-            }
-        },
-        "model.Book": {
-            "type": "object",
-            "required": [
-                "PageNumber",
-                "Title"
-            ],
-            "properties": {
-                "Desc": {
-                    "type": "string"
-                },
-                "Meta": {
-                    "description": "使用 json.RawMessage 来存储未解析的 JSON 数据",
-                    "type": "object"
-                },
-                "PageNumber": {
-                    "type": "integer"
-                },
-                "Title": {
-                    "type": "string",
-                    "maxLength": 20,
-                    "example": "My Book"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "deletedAt": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "updatedAt": {
-                    "type": "string"
-                }
-            }
-        },
-        "types.Data": {
-            "type": "object",
-            "properties": {
-                "Code": {
-                    "$ref": "#/definitions/errorx.ErrorCode"
-                },
-                "Data": {},
-                "DataType": {
-                    "$ref": "#/definitions/types.DataType"
-                },
-                "Message": {
-                    "type": "string"
-                }
-            }
-        },
-        "types.DataType": {
-            "type": "string",
-            "enum": [
-                "string",
-                "json",
-                "slice"
-            ],
-            "x-enum-varnames": [
-                "DataTypeString",
-                "DataTypeJson",
-                "DataTypeSlice"
-            ]
-        }
-    },
-    "securityDefinitions": {
-        "ApiKeyAuth": {
-            "type": "apiKey",
-            "name": "Authorization",
-            "in": "header"
-        }
-    }
-}`
-
-// SwaggerInfo holds exported Swagger Info so clients can modify it
-var SwaggerInfo = &swag.Spec{
-	Version:          "0.0.1",
-	Host:             "",
-	BasePath:         "",
-	Schemes:          []string{},
-	Title:            "IkubeOps OpenApi API",
-	Description:      "gin 脚手架",
-	InfoInstanceName: "swagger",
-	SwaggerTemplate:  docTemplate,
-	LeftDelim:        "{{",
-	RightDelim:       "}}",
-}
-
-func init() {
-	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
-}
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
+package docs
+
+import "github.com/swaggo/swag"
+
+const docTemplate = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "termsOfService": "http://swagger.io/terms/",
+        "contact": {
+            "name": "官网地址",
+            "url": "http://www.ikubeops.com",
+            "email": "ikubeops@gmail.com"
+        },
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/api/user/info": {
+            "get": {
+                "security": [
+                    {
+                        "types.SearchId": []
+                    }
+                ],
+                "description": "获取当前用户的详细信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "获取用户信息",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Book"
+                        }
+                    }
+                }
+            }
+        },
+        "/book-shelf/book": {
+            "post": {
+                "description": "创建书籍接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "书籍管理"
+                ],
+                "summary": "创建书籍接口",
+                "parameters": [
+                    {
+                        "description": "书籍名称",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Book"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "desc",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/types.Data"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Book"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "errorx.ErrorCode": {
+            "type": "integer",
+            "enum": [
+                0,
+                99999,
+                11000,
+                11001,
+                11002,
+                11003,
+                11004,
+                10110,
+                10111,
+                10130,
+                10131,
+                18000,
+                18004,
+                10101,
+                10102,
+                10103,
+                10104,
+                10105,
+                10106,
+                10500
+            ],
+            "x-enum-comments": {
+                "ErrBusinessLogic": "业务逻辑错误",
+                "ErrDataConflict": "数据冲突",
+                "ErrDataCreation": "数据创建失败",
+                "ErrDataDeletion": "数据删除失败",
+                "ErrDataNotFound": "数据未找到",
+                "ErrDatabase": "数据库相关错误",
+                "ErrGeneric": "正常",
+                "ErrLoginExpired": "登录过期",
+                "ErrLoginInvalid": "登录信息无效",
+                "ErrParamParse": "参数解析失败",
+                "ErrPermissionDenied": "权限不足",
+                "ErrRoleNotFound": "角色未找到",
+                "ErrToOperation": "\"to\" 操作相关错误",
+                "ErrTokenBlacklisted": "Token 被列入黑名单",
+                "ErrTokenExpired": "Token 失效",
+                "ErrTokenInvalid": "Token 解析失败",
+                "ErrTokenMissing": "缺少 Token",
+                "ErrTokenRefresh": "Token 刷新失败"
+            },
+            "x-enum-varnames": [
+                "ErrNormal",
+                "ErrGeneric",
+                "ErrTokenMissing",
+                "ErrTokenExpired",
+                "ErrTokenInvalid",
+                "ErrTokenRefresh",
+                "ErrTokenBlacklisted",
+                "ErrLoginExpired",
+                "ErrLoginInvalid",
+                "ErrPermissionDenied",
+                "ErrRoleNotFound",
+                "ErrDatabase",
+                "ErrDataConflict",
+                "ErrBusinessLogic",
+                "ErrParamParse",
+                "ErrDataNotFound",
+                "ErrDataCreation",
+                "ErrDataDeletion",
+                "ErrToOperation",
+                "ErrServerErr"
+            ]
+        },
+        "gorm.DeletedAt": {
+            "type": "object",
+            "properties": {
+                "time": {
+                    "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if Time is not NULL",
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.Book": {
+            "type": "object",
+            "required": [
+                "PageNumber",
+                "Title"
+            ],
+            "properties": {
+                "Desc": {
+                    "type": "string"
+                },
+                "Meta": {
+                    "description": "使用 json.RawMessage 来存储未解析的 JSON 数据",
+                    "type": "object"
+                },
+                "PageNumber": {
+                    "type": "integer"
+                },
+                "Title": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "example": "My Book"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.Data": {
+            "type": "object",
+            "properties": {
+                "Code": {
+                    "$ref": "#/definitions/errorx.ErrorCode"
+                },
+                "Data": {},
+                "DataType": {
+                    "$ref": "#/definitions/types.DataType"
+                },
+                "Message": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.DataType": {
+            "type": "string",
+            "enum": [
+                "string",
+                "json",
+                "slice"
+            ],
+            "x-enum-varnames": [
+                "DataTypeString",
+                "DataTypeJson",
+                "DataTypeSlice"
+            ]
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
+    }
+}`
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = &swag.Spec{
+	Version:          "0.0.1",
+	Host:             "",
+	BasePath:         "",
+	Schemes:          []string{},
+	Title:            "IkubeOps OpenApi API",
+	Description:      "gin 脚手架",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+}
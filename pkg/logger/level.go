@@ -1,41 +1,41 @@
-package logger
-
-import "go.uber.org/zap/zapcore"
-
-// LogLevel 是一个枚举，表示可用的日志级别
-type Level int8
-
-// Logging levels.
-const (
-	DebugLevel Level = iota - 1
-	InfoLevel
-	WarnLevel
-	ErrorLevel
-	PanicLevel
-	FatalLevel
-)
-
-type StdoutEncoding int8
-
-const (
-	ConsoleEncoding StdoutEncoding = iota
-	JsonEncoding
-)
-
-var zapLevels = map[Level]zapcore.Level{
-	DebugLevel: zapcore.DebugLevel,
-	InfoLevel:  zapcore.InfoLevel,
-	WarnLevel:  zapcore.WarnLevel,
-	ErrorLevel: zapcore.ErrorLevel,
-	FatalLevel: zapcore.FatalLevel,
-	PanicLevel: zapcore.PanicLevel,
-}
-
-// zapLevel 对应的zaplevel
-func (l Level) zapLevel() zapcore.Level {
-	z, found := zapLevels[l]
-	if found {
-		return z
-	}
-	return zapcore.InfoLevel
-}
+package logger
+
+import "go.uber.org/zap/zapcore"
+
+// LogLevel 是一个枚举，表示可用的日志级别
+type Level int8
+
+// Logging levels.
+const (
+	DebugLevel Level = iota - 1
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	PanicLevel
+	FatalLevel
+)
+
+type StdoutEncoding int8
+
+const (
+	ConsoleEncoding StdoutEncoding = iota
+	JsonEncoding
+)
+
+var zapLevels = map[Level]zapcore.Level{
+	DebugLevel: zapcore.DebugLevel,
+	InfoLevel:  zapcore.InfoLevel,
+	WarnLevel:  zapcore.WarnLevel,
+	ErrorLevel: zapcore.ErrorLevel,
+	FatalLevel: zapcore.FatalLevel,
+	PanicLevel: zapcore.PanicLevel,
+}
+
+// zapLevel 对应的zaplevel
+func (l Level) zapLevel() zapcore.Level {
+	z, found := zapLevels[l]
+	if found {
+		return z
+	}
+	return zapcore.InfoLevel
+}
@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, to []string, subject, body string) error
+}
+
+var _ Mailer = (*SMTPMailer)(nil)
+
+// SMTPMailer 通过 SMTP 发送纯文本邮件
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	tls      bool // true 使用 SMTPS(465) 直连，false 时服务端支持则使用 STARTTLS
+	timeout  time.Duration
+}
+
+// NewSMTPMailer 初始化 SMTP 邮件发送器
+func NewSMTPMailer(host string, port int, username, password, from string, useTLS bool) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+		tls:      useTLS,
+		timeout:  10 * time.Second,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, to []string, subject, body string) error {
+	if len(to) == 0 {
+		return fmt.Errorf("mailer: no recipients")
+	}
+	addr := net.JoinHostPort(m.host, fmt.Sprintf("%d", m.port))
+	dialer := &net.Dialer{Timeout: m.timeout}
+	var conn net.Conn
+	var err error
+	if m.tls {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: m.host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(m.timeout))
+	}
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if !m.tls {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+				return err
+			}
+		}
+	}
+	if m.username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMessage(m.from, to, subject, body)); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage 组装 UTF-8 纯文本邮件
+func buildMessage(from string, to []string, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,93 @@
+package mailer_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/mailer"
+)
+
+// fakeSMTP 最小 SMTP 服务，记录收到的信封和正文
+type fakeSMTP struct {
+	from string
+	to   []string
+	data string
+}
+
+func (f *fakeSMTP) serve(t *testing.T, ln net.Listener, done chan<- struct{}) {
+	defer close(done)
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Errorf("accept: %v", err)
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	write := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	write("220 fake smtp")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			write("250 fake")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			f.from = strings.Trim(strings.TrimPrefix(cmd, "MAIL FROM:"), "<>")
+			write("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			f.to = append(f.to, strings.Trim(strings.TrimPrefix(cmd, "RCPT TO:"), "<>"))
+			write("250 ok")
+		case cmd == "DATA":
+			write("354 go ahead")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			f.data = b.String()
+			write("250 queued")
+		case cmd == "QUIT":
+			write("221 bye")
+			return
+		default:
+			write("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	defer ln.Close()
+	server := &fakeSMTP{}
+	done := make(chan struct{})
+	go server.serve(t, ln, done)
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	m := mailer.NewSMTPMailer(host, portNum, "", "", "noreply@ikubeops.local", false)
+	err = m.Send(context.Background(), []string{"user@ikubeops.local"}, "验证码", "您的验证码是 123456")
+	assert.NoError(t, err, "发送邮件失败")
+	<-done
+
+	assert.Equal(t, "noreply@ikubeops.local", server.from)
+	assert.Equal(t, []string{"user@ikubeops.local"}, server.to)
+	assert.Contains(t, server.data, "Subject: =?UTF-8?b?")
+	assert.Contains(t, server.data, "您的验证码是 123456")
+}
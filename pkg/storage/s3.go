@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var _ Storage = (*S3Storage)(nil)
+
+// S3Storage S3 兼容的对象存储（AWS S3、MinIO、OSS 等）
+type S3Storage struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+}
+
+// NewS3Storage 初始化 S3 存储，baseURL 为空时使用 endpoint/bucket 作为访问地址
+func NewS3Storage(endpoint, accessKey, secretKey, bucket, region string, useSSL bool, baseURL string) (*S3Storage, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("storage: bucket is required")
+	}
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		scheme := "http"
+		if useSSL {
+			scheme = "https"
+		}
+		baseURL = fmt.Sprintf("%s://%s/%s", scheme, strings.TrimRight(endpoint, "/"), bucket)
+	}
+	return &S3Storage{client: client, bucket: bucket, baseURL: baseURL}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, key, reader, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	// GetObject 是惰性请求，先取元信息以便尽早发现对象不存在
+	if _, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotExist
+		}
+		return nil, err
+	}
+	return s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) URL(key string) string {
+	return joinURL(s.baseURL, key)
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNotExist 对象不存在
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// Storage 对象存储接口，key 使用 / 分隔的相对路径，例如 account/1/avatar.png
+type Storage interface {
+	// Put 写入对象，size 未知时传 -1
+	Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// URL 返回对象的公开访问地址
+	URL(key string) string
+}
+
+// joinURL 拼接访问地址，baseURL 为空时返回以 / 开头的相对路径
+func joinURL(baseURL, key string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(key, "/")
+}
+
+// cleanKey 校验并规范化对象 key，不允许跳出根目录
+func cleanKey(key string) (string, error) {
+	key = strings.TrimLeft(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", errors.New("storage: empty key")
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return "", errors.New("storage: invalid key " + key)
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,137 @@
+package storage_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/storage"
+)
+
+func testStorage(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	data := []byte("ikube avatar")
+
+	err := s.Put(ctx, "account/1/avatar.png", bytes.NewReader(data), int64(len(data)), "image/png")
+	assert.NoError(t, err, "写入对象失败")
+
+	reader, err := s.Get(ctx, "account/1/avatar.png")
+	if assert.NoError(t, err, "读取对象失败") {
+		got, err := io.ReadAll(reader)
+		reader.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, data, got, "读取的内容与写入不一致")
+	}
+
+	assert.NoError(t, s.Delete(ctx, "account/1/avatar.png"), "删除对象失败")
+	_, err = s.Get(ctx, "account/1/avatar.png")
+	assert.ErrorIs(t, err, storage.ErrNotExist, "删除后对象应不存在")
+	assert.NoError(t, s.Delete(ctx, "account/1/avatar.png"), "删除不存在的对象不应报错")
+
+	assert.Error(t, s.Put(ctx, "../escape.png", bytes.NewReader(data), int64(len(data)), "image/png"), "不允许跳出根目录")
+}
+
+func TestLocalStorage(t *testing.T) {
+	s, err := storage.NewLocalStorage(t.TempDir(), "http://127.0.0.1:9909/static/")
+	if err != nil {
+		t.Fatalf("初始化本地存储失败: %v", err)
+	}
+	testStorage(t, s)
+	assert.Equal(t, "http://127.0.0.1:9909/static/account/default.png", s.URL("account/default.png"))
+}
+
+func TestS3Storage(t *testing.T) {
+	server := httptest.NewServer(newFakeS3())
+	defer server.Close()
+
+	endpoint := strings.TrimPrefix(server.URL, "http://")
+	s, err := storage.NewS3Storage(endpoint, "access", "secret", "ikube", "us-east-1", false, "")
+	if err != nil {
+		t.Fatalf("初始化 S3 存储失败: %v", err)
+	}
+	testStorage(t, s)
+	assert.Equal(t, server.URL+"/ikube/account/default.png", s.URL("account/default.png"))
+}
+
+// fakeS3 只实现 PUT/GET/HEAD/DELETE 单个对象的最小 S3 服务，不校验签名
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readS3Body(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = body
+		w.Header().Set("ETag", `"fake"`)
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("ETag", `"fake"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// readS3Body 读取请求体，兼容 aws-chunked 流式签名格式
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+	var body []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body, nil
+		}
+		chunk := make([]byte, size+2) // 数据后跟 \r\n
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, err
+		}
+		body = append(body, chunk[:size]...)
+	}
+}
@@ -1,114 +1,312 @@
-package types
-
-import "github.com/yanshicheng/ikube-gin-xjob/pkg/logger"
-
-type AppConfig struct {
-	HttpAddr          string `mapstructure:"http_addr" json:"http_addr" yaml:"http_addr" env:"APP_HTTP_ADDR"`
-	HttpPort          int    `mapstructure:"http_port" json:"http_port" http_port:"http_port" env:"APP_HTTP_PORT"`
-	HealthPort        int    `mapstructure:"health_port" json:"health_port" yaml:"health_port" env:"APP_HEALTH_PORT"`
-	Language          string `mapstructure:"language" json:"language" yaml:"language" env:"APP_LANGUAGE"`
-	MaxHeaderSize     int    `mapstructure:"max_header_size" json:"max_header_size" yaml:"max_header_size" env:"APP_MAX_HEADER_SIZE"`
-	ReadTimeout       int    `mapstructure:"read_timeout" json:"read_timeout" yaml:"read_timeout" env:"APP_READ_TIMEOUT"`
-	ReadHeaderTimeout int    `mapstructure:"read_header_timeout" json:"read_header_timeout" yaml:"read_header_timeout" env:"APP_READ_HEADER_TIMEOUT"`
-	WriteTimeout      int    `mapstructure:"write_timeout" json:"write_timeout" yaml:"write_timeout" env:"APP_WRITE_TIMEOUT"`
-	Tls               bool   `mapstructure:"tls" json:"tls" yaml:"tls" env:"APP_TLS"`
-	CertFile          string `mapstructure:"cert_file" json:"cert_file" yaml:"cert_file" env:"APP_CERT_FILE"`
-	KeyFile           string `mapstructure:"key_file" json:"key_file" yaml:"key_file" env:"APP_KEY_FILE"`
-	ShutdownTimeout   int    `mapstructure:"shutdown_timeout" json:"shutdown_timeout" yaml:"shutdown_timeout" env:"APP_SHUTDOWN_TIMEOUT"`
-}
-
-type MysqlConfig struct {
-	Host         string `mapstructure:"host" json:"host" yaml:"host" env:"MYSQL_HOST" `
-	Port         int    `mapstructure:"port" json:"port" yaml:"port"  env:"MYSQL_PORT"`
-	User         string `mapstructure:"user" json:"user" yaml:"user"  env:"MYSQL_USER"`
-	Password     string `mapstructure:"password" json:"password" yaml:"password"  env:"MYSQL_PASSWORD"`
-	DbName       string `mapstructure:"db_name" json:"db_name" yaml:"db_name"  env:"MYSQL_DB_NAME"`
-	MaxOpenConns int    `mapstructure:"max_open_conns" json:"max_open_conns" yaml:"max_open_conns"  env:"MYSQL_MAX_OPEN_CONNS"`
-	MaxIdleConns int    `mapstructure:"max_idle_conns" json:"max_idle_conns" yaml:"max_idle_conns"  env:"MYSQL_MAX_IDLE_CONNS"`
-	Opts         string `mapstructure:"opts" json:"opts" yaml:"opts" env:"MYSQL_OPTS"`
-	Level        string `mapstructure:"level" json:"level" yaml:"level" env:"MYSQL_LEVEL"`
-	LogToFile    bool   `mapstructure:"log-to-file" json:"logToFile" yaml:"log-to-file"  env:"MYSQL_LOG_TO_FILE"`
-	Enable       bool   `mapstructure:"enable" json:"enable" yaml:"enable" env:"MYSQL_ENABLE"`
-}
-
-type RedisConfig struct {
-	Host     string `mapstructure:"host" json:"host" yaml:"host" env:"REDIS_HOST"`
-	Port     int    `mapstructure:"port" json:"port" yaml:"port" env:"REDIS_PORT"`
-	Db       int    `mapstructure:"db" json:"db" yaml:"db" env:"REDIS_DB"`
-	Password string `mapstructure:"password" json:"password" yaml:"password" env:"REDIS_PASSWORD"`
-	PoolSize int    `mapstructure:"pool_size" json:"pool_size" yaml:"pool_size" env:"REDIS_POLL_SIZE"`
-	Enable   bool   `mapstructure:"enable" json:"enable" yaml:"enable" env:"REDIS_ENABLE"`
-}
-
-type Config struct {
-	App    AppConfig          `mapstructure:"app" json:"app" yaml:"app" env:"IKUBEOPS"`
-	Logger logger.IkubeLogger `mapstructure:"logger" json:"logger" yaml:"logger" env:"IKUBEOPS"`
-	Mysql  MysqlConfig        `mapstructure:"mysql" json:"mysql" yaml:"mysql" env:"IKUBEOPS"`
-	Redis  RedisConfig        `mapstructure:"redis" json:"redis" yaml:"redis" env:"IKUBEOPS"`
-}
-
-func NewAppConfig() AppConfig {
-	return AppConfig{
-		HttpPort:          9900,
-		HealthPort:        9999,
-		HttpAddr:          "0.0.0.0",
-		Language:          "zh",
-		MaxHeaderSize:     1,
-		ReadTimeout:       60,
-		ReadHeaderTimeout: 60,
-		WriteTimeout:      60,
-		Tls:               false,
-		KeyFile:           "",
-		CertFile:          "",
-		ShutdownTimeout:   60,
-	}
-}
-
-func NewLoggerConfig() logger.IkubeLogger {
-	return logger.IkubeLogger{
-		Output:     "console",
-		Format:     "console",
-		Level:      "debug",
-		MaxFile:    false,
-		Dev:        true,
-		FilePath:   "./logs",
-		MaxSize:    10,
-		MaxAge:     30,
-		MaxBackups: 100,
-	}
-}
-func NewMysqlConfig() MysqlConfig {
-	return MysqlConfig{
-		Host:         "127.0.0.1",
-		Port:         3306,
-		User:         "root",
-		Password:     "123456",
-		DbName:       "test",
-		MaxOpenConns: 100,
-		MaxIdleConns: 20,
-		Level:        "info",
-		Opts:         "",
-		LogToFile:    false,
-		Enable:       false,
-	}
-}
-
-func NewRedisConfig() RedisConfig {
-	return RedisConfig{
-		Host:     "127.0.0.1",
-		Port:     6379,
-		Db:       0,
-		Password: "12345678",
-		PoolSize: 100,
-		Enable:   false,
-	}
-}
-
-func NewDefaultConfig() *Config {
-	return &Config{
-		App:    NewAppConfig(),
-		Logger: NewLoggerConfig(),
-		Mysql:  NewMysqlConfig(),
-		Redis:  NewRedisConfig(),
-	}
-}
+package types
+
+import (
+	"fmt"
+	"github.com/yanshicheng/ikube-gin-xjob/pkg/logger"
+)
+
+type AppConfig struct {
+	HttpAddr          string `mapstructure:"http_addr" json:"http_addr" yaml:"http_addr" env:"APP_HTTP_ADDR"`
+	HttpPort          int    `mapstructure:"http_port" json:"http_port" http_port:"http_port" env:"APP_HTTP_PORT"`
+	HealthPort        int    `mapstructure:"health_port" json:"health_port" yaml:"health_port" env:"APP_HEALTH_PORT"`
+	Language          string `mapstructure:"language" json:"language" yaml:"language" env:"APP_LANGUAGE"`
+	MaxHeaderSize     int    `mapstructure:"max_header_size" json:"max_header_size" yaml:"max_header_size" env:"APP_MAX_HEADER_SIZE"`
+	ReadTimeout       int    `mapstructure:"read_timeout" json:"read_timeout" yaml:"read_timeout" env:"APP_READ_TIMEOUT"`
+	ReadHeaderTimeout int    `mapstructure:"read_header_timeout" json:"read_header_timeout" yaml:"read_header_timeout" env:"APP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      int    `mapstructure:"write_timeout" json:"write_timeout" yaml:"write_timeout" env:"APP_WRITE_TIMEOUT"`
+	Tls               bool   `mapstructure:"tls" json:"tls" yaml:"tls" env:"APP_TLS"`
+	CertFile          string `mapstructure:"cert_file" json:"cert_file" yaml:"cert_file" env:"APP_CERT_FILE"`
+	KeyFile           string `mapstructure:"key_file" json:"key_file" yaml:"key_file" env:"APP_KEY_FILE"`
+	ShutdownTimeout   int    `mapstructure:"shutdown_timeout" json:"shutdown_timeout" yaml:"shutdown_timeout" env:"APP_SHUTDOWN_TIMEOUT"`
+}
+
+type MysqlConfig struct {
+	Host         string `mapstructure:"host" json:"host" yaml:"host" env:"MYSQL_HOST" `
+	Port         int    `mapstructure:"port" json:"port" yaml:"port"  env:"MYSQL_PORT"`
+	User         string `mapstructure:"user" json:"user" yaml:"user"  env:"MYSQL_USER"`
+	Password     string `mapstructure:"password" json:"password" yaml:"password"  env:"MYSQL_PASSWORD"`
+	DbName       string `mapstructure:"db_name" json:"db_name" yaml:"db_name"  env:"MYSQL_DB_NAME"`
+	MaxOpenConns int    `mapstructure:"max_open_conns" json:"max_open_conns" yaml:"max_open_conns"  env:"MYSQL_MAX_OPEN_CONNS"`
+	MaxIdleConns int    `mapstructure:"max_idle_conns" json:"max_idle_conns" yaml:"max_idle_conns"  env:"MYSQL_MAX_IDLE_CONNS"`
+	Opts         string `mapstructure:"opts" json:"opts" yaml:"opts" env:"MYSQL_OPTS"`
+	Level        string `mapstructure:"level" json:"level" yaml:"level" env:"MYSQL_LEVEL"`
+	LogToFile    bool   `mapstructure:"log-to-file" json:"logToFile" yaml:"log-to-file"  env:"MYSQL_LOG_TO_FILE"`
+	Enable       bool   `mapstructure:"enable" json:"enable" yaml:"enable" env:"MYSQL_ENABLE"`
+}
+
+type RedisConfig struct {
+	Host     string `mapstructure:"host" json:"host" yaml:"host" env:"REDIS_HOST"`
+	Port     int    `mapstructure:"port" json:"port" yaml:"port" env:"REDIS_PORT"`
+	Db       int    `mapstructure:"db" json:"db" yaml:"db" env:"REDIS_DB"`
+	Password string `mapstructure:"password" json:"password" yaml:"password" env:"REDIS_PASSWORD"`
+	PoolSize int    `mapstructure:"pool_size" json:"pool_size" yaml:"pool_size" env:"REDIS_POLL_SIZE"`
+	Enable   bool   `mapstructure:"enable" json:"enable" yaml:"enable" env:"REDIS_ENABLE"`
+}
+
+type SessionConfig struct {
+	MaxPerAccount int `mapstructure:"max_per_account" json:"max_per_account" yaml:"max_per_account" env:"SESSION_MAX_PER_ACCOUNT"` // 单账号最大并发会话数，0 表示不限制
+}
+
+type AuthConfig struct {
+	AdminRoles []string `mapstructure:"admin_roles" json:"admin_roles" yaml:"admin_roles" env:"AUTH_ADMIN_ROLES"` // 管理员角色，拥有其中任一角色的账号才能调用管理接口
+}
+
+type AuditConfig struct {
+	RetentionDays int `mapstructure:"retention_days" json:"retention_days" yaml:"retention_days" env:"AUDIT_RETENTION_DAYS"` // 安全事件保留天数，0 表示永久保留
+}
+
+type StorageConfig struct {
+	Type               string `mapstructure:"type" json:"type" yaml:"type" env:"STORAGE_TYPE"`                                                                 // 存储类型 local | s3
+	BaseURL            string `mapstructure:"base_url" json:"base_url" yaml:"base_url" env:"STORAGE_BASE_URL"`                                                 // 公开访问地址前缀
+	LocalPath          string `mapstructure:"local_path" json:"local_path" yaml:"local_path" env:"STORAGE_LOCAL_PATH"`                                         // 本地存储根目录
+	Endpoint           string `mapstructure:"endpoint" json:"endpoint" yaml:"endpoint" env:"STORAGE_ENDPOINT"`                                                 // S3 地址，不带协议
+	AccessKey          string `mapstructure:"access_key" json:"access_key" yaml:"access_key" env:"STORAGE_ACCESS_KEY"`                                         // S3 AccessKey
+	SecretKey          string `mapstructure:"secret_key" json:"secret_key" yaml:"secret_key" env:"STORAGE_SECRET_KEY"`                                         // S3 SecretKey
+	Bucket             string `mapstructure:"bucket" json:"bucket" yaml:"bucket" env:"STORAGE_BUCKET"`                                                         // S3 存储桶
+	Region             string `mapstructure:"region" json:"region" yaml:"region" env:"STORAGE_REGION"`                                                         // S3 区域
+	UseSSL             bool   `mapstructure:"use_ssl" json:"use_ssl" yaml:"use_ssl" env:"STORAGE_USE_SSL"`                                                     // S3 是否使用 https
+	AvatarMaxSize      int    `mapstructure:"avatar_max_size" json:"avatar_max_size" yaml:"avatar_max_size" env:"STORAGE_AVATAR_MAX_SIZE"`                     // 头像上传大小上限，单位 kb
+	AvatarDimension    int    `mapstructure:"avatar_dimension" json:"avatar_dimension" yaml:"avatar_dimension" env:"STORAGE_AVATAR_DIMENSION"`                 // 头像缩放后的边长，单位 px
+	AvatarMaxDimension int    `mapstructure:"avatar_max_dimension" json:"avatar_max_dimension" yaml:"avatar_max_dimension" env:"STORAGE_AVATAR_MAX_DIMENSION"` // 上传头像的宽高上限，单位 px
+}
+
+type MailConfig struct {
+	Host     string `mapstructure:"host" json:"host" yaml:"host" env:"MAIL_HOST"`
+	Port     int    `mapstructure:"port" json:"port" yaml:"port" env:"MAIL_PORT"`
+	Username string `mapstructure:"username" json:"username" yaml:"username" env:"MAIL_USERNAME"`
+	Password string `mapstructure:"password" json:"password" yaml:"password" env:"MAIL_PASSWORD"`
+	From     string `mapstructure:"from" json:"from" yaml:"from" env:"MAIL_FROM"`
+	Tls      bool   `mapstructure:"tls" json:"tls" yaml:"tls" env:"MAIL_TLS"` // true 使用 SMTPS 直连，false 时服务端支持则使用 STARTTLS
+	Enable   bool   `mapstructure:"enable" json:"enable" yaml:"enable" env:"MAIL_ENABLE"`
+}
+
+type PasswordResetConfig struct {
+	URL string `mapstructure:"url" json:"url" yaml:"url" env:"PASSWORD_RESET_URL"` // 重置密码页面地址，令牌以 token 参数追加
+	TTL int    `mapstructure:"ttl" json:"ttl" yaml:"ttl" env:"PASSWORD_RESET_TTL"` // 重置链接有效期，单位分钟
+}
+
+type OrganizationCacheConfig struct {
+	Enable       bool `mapstructure:"enable" json:"enable" yaml:"enable" env:"ORGANIZATION_CACHE_ENABLE"`                             // 是否缓存机构树
+	TTL          int  `mapstructure:"ttl" json:"ttl" yaml:"ttl" env:"ORGANIZATION_CACHE_TTL"`                                         // redis 中机构树的有效期，单位分钟
+	SyncInterval int  `mapstructure:"sync_interval" json:"sync_interval" yaml:"sync_interval" env:"ORGANIZATION_CACHE_SYNC_INTERVAL"` // 核对版本号的间隔，单位秒，用于补偿丢失的变更通知
+}
+
+type OrganizationTypeConfig struct {
+	Title     string   `mapstructure:"title" json:"title" yaml:"title"`             // 类型名称
+	Root      bool     `mapstructure:"root" json:"root" yaml:"root"`                // 是否可以作为主体机构
+	Parents   []string `mapstructure:"parents" json:"parents" yaml:"parents"`       // 允许的上级机构类型
+	MaxLevel  int      `mapstructure:"max_level" json:"max_level" yaml:"max_level"` // 该类型机构所在的最大层级，0 表示只受机构最大层级限制
+	Positions bool     `mapstructure:"positions" json:"positions" yaml:"positions"` // 是否允许创建职位
+	Accounts  bool     `mapstructure:"accounts" json:"accounts" yaml:"accounts"`    // 是否允许账号归属
+}
+
+type OrganizationConfig struct {
+	MaxLevel int                               `mapstructure:"max_level" json:"max_level" yaml:"max_level" env:"ORGANIZATION_MAX_LEVEL"` // 机构最大层级
+	Types    map[string]OrganizationTypeConfig `mapstructure:"types" json:"types" yaml:"types"`                                          // 机构类型规则，键为类型：company、division、department、team
+}
+
+type MenuConfig struct {
+	MaxLevel int `mapstructure:"max_level" json:"max_level" yaml:"max_level" env:"MENU_MAX_LEVEL"` // 菜单最大层级
+}
+
+type OffboardingConfig struct {
+	RetentionDays int `mapstructure:"retention_days" json:"retention_days" yaml:"retention_days" env:"OFFBOARDING_RETENTION_DAYS"` // 离职后保留个人信息的天数，到期后匿名化，0 表示不匿名化
+}
+
+type HeadcountConfig struct {
+	TTL    int `mapstructure:"ttl" json:"ttl" yaml:"ttl" env:"HEADCOUNT_TTL"`             // 人员统计缓存有效期，单位分钟
+	Months int `mapstructure:"months" json:"months" yaml:"months" env:"HEADCOUNT_MONTHS"` // 按月统计入职人数的月数，包含当月
+	Days   int `mapstructure:"days" json:"days" yaml:"days" env:"HEADCOUNT_DAYS"`         // 按天统计登录人数的天数，包含当天
+}
+
+type Config struct {
+	App           AppConfig               `mapstructure:"app" json:"app" yaml:"app" env:"IKUBEOPS"`
+	Logger        logger.IkubeLogger      `mapstructure:"logger" json:"logger" yaml:"logger" env:"IKUBEOPS"`
+	Mysql         MysqlConfig             `mapstructure:"mysql" json:"mysql" yaml:"mysql" env:"IKUBEOPS"`
+	Redis         RedisConfig             `mapstructure:"redis" json:"redis" yaml:"redis" env:"IKUBEOPS"`
+	Session       SessionConfig           `mapstructure:"session" json:"session" yaml:"session" env:"IKUBEOPS"`
+	Auth          AuthConfig              `mapstructure:"auth" json:"auth" yaml:"auth" env:"IKUBEOPS"`
+	Audit         AuditConfig             `mapstructure:"audit" json:"audit" yaml:"audit" env:"IKUBEOPS"`
+	Storage       StorageConfig           `mapstructure:"storage" json:"storage" yaml:"storage" env:"IKUBEOPS"`
+	Mail          MailConfig              `mapstructure:"mail" json:"mail" yaml:"mail" env:"IKUBEOPS"`
+	PasswordReset PasswordResetConfig     `mapstructure:"password_reset" json:"password_reset" yaml:"password_reset" env:"IKUBEOPS"`
+	OrgCache      OrganizationCacheConfig `mapstructure:"organization_cache" json:"organization_cache" yaml:"organization_cache" env:"IKUBEOPS"`
+	Organization  OrganizationConfig      `mapstructure:"organization" json:"organization" yaml:"organization" env:"IKUBEOPS"`
+	Menu          MenuConfig              `mapstructure:"menu" json:"menu" yaml:"menu" env:"IKUBEOPS"`
+	Offboarding   OffboardingConfig       `mapstructure:"offboarding" json:"offboarding" yaml:"offboarding" env:"IKUBEOPS"`
+	Headcount     HeadcountConfig         `mapstructure:"headcount" json:"headcount" yaml:"headcount" env:"IKUBEOPS"`
+}
+
+func NewAppConfig() AppConfig {
+	return AppConfig{
+		HttpPort:          9900,
+		HealthPort:        9999,
+		HttpAddr:          "0.0.0.0",
+		Language:          "zh",
+		MaxHeaderSize:     1,
+		ReadTimeout:       60,
+		ReadHeaderTimeout: 60,
+		WriteTimeout:      60,
+		Tls:               false,
+		KeyFile:           "",
+		CertFile:          "",
+		ShutdownTimeout:   60,
+	}
+}
+
+func NewLoggerConfig() logger.IkubeLogger {
+	return logger.IkubeLogger{
+		Output:     "console",
+		Format:     "console",
+		Level:      "debug",
+		MaxFile:    false,
+		Dev:        true,
+		FilePath:   "./logs",
+		MaxSize:    10,
+		MaxAge:     30,
+		MaxBackups: 100,
+	}
+}
+func NewMysqlConfig() MysqlConfig {
+	return MysqlConfig{
+		Host:         "127.0.0.1",
+		Port:         3306,
+		User:         "root",
+		Password:     "123456",
+		DbName:       "test",
+		MaxOpenConns: 100,
+		MaxIdleConns: 20,
+		Level:        "info",
+		Opts:         "",
+		LogToFile:    false,
+		Enable:       false,
+	}
+}
+
+func NewRedisConfig() RedisConfig {
+	return RedisConfig{
+		Host:     "127.0.0.1",
+		Port:     6379,
+		Db:       0,
+		Password: "12345678",
+		PoolSize: 100,
+		Enable:   false,
+	}
+}
+
+func NewSessionConfig() SessionConfig {
+	return SessionConfig{
+		MaxPerAccount: 5,
+	}
+}
+
+func NewAuthConfig() AuthConfig {
+	return AuthConfig{
+		AdminRoles: []string{"admin"},
+	}
+}
+
+func NewAuditConfig() AuditConfig {
+	return AuditConfig{
+		RetentionDays: 180,
+	}
+}
+
+func NewStorageConfig() StorageConfig {
+	return StorageConfig{
+		Type:               "local",
+		BaseURL:            "/static",
+		LocalPath:          "static",
+		Region:             "us-east-1",
+		UseSSL:             false,
+		AvatarMaxSize:      2048,
+		AvatarDimension:    256,
+		AvatarMaxDimension: 4096,
+	}
+}
+
+// Validate 头像相关的配置不能为负数，为 0 时使用默认值
+func (s *StorageConfig) Validate() error {
+	if s.AvatarMaxSize < 0 || s.AvatarDimension < 0 || s.AvatarMaxDimension < 0 {
+		return fmt.Errorf("storage avatar_max_size, avatar_dimension and avatar_max_dimension must not be negative")
+	}
+	return nil
+}
+
+func NewMailConfig() MailConfig {
+	return MailConfig{
+		Host:   "127.0.0.1",
+		Port:   25,
+		From:   "noreply@ikubeops.local",
+		Tls:    false,
+		Enable: false,
+	}
+}
+
+func NewPasswordResetConfig() PasswordResetConfig {
+	return PasswordResetConfig{
+		URL: "http://127.0.0.1:9909/#/reset-password",
+		TTL: 30,
+	}
+}
+
+func NewOrganizationCacheConfig() OrganizationCacheConfig {
+	return OrganizationCacheConfig{
+		Enable:       true,
+		TTL:          60,
+		SyncInterval: 30,
+	}
+}
+
+// NewOrganizationConfig 默认规则：公司作为主体机构，不允许创建职位；事业部、部门可以挂在公司和事业部下，部门可以多级嵌套；团队只能挂在部门和团队下
+func NewOrganizationConfig() OrganizationConfig {
+	return OrganizationConfig{
+		MaxLevel: 5,
+		Types: map[string]OrganizationTypeConfig{
+			"company":    {Title: "公司", Root: true, Parents: []string{"company"}, Accounts: true},
+			"division":   {Title: "事业部", Parents: []string{"company", "division"}, Positions: true, Accounts: true},
+			"department": {Title: "部门", Parents: []string{"company", "division", "department"}, Positions: true, Accounts: true},
+			"team":       {Title: "团队", Parents: []string{"department", "team"}, Positions: true, Accounts: true},
+		},
+	}
+}
+
+func NewMenuConfig() MenuConfig {
+	return MenuConfig{
+		MaxLevel: 3,
+	}
+}
+
+func NewOffboardingConfig() OffboardingConfig {
+	return OffboardingConfig{
+		RetentionDays: 365,
+	}
+}
+
+func NewHeadcountConfig() HeadcountConfig {
+	return HeadcountConfig{
+		TTL:    10,
+		Months: 12,
+		Days:   30,
+	}
+}
+
+func NewDefaultConfig() *Config {
+	return &Config{
+		App:           NewAppConfig(),
+		Logger:        NewLoggerConfig(),
+		Mysql:         NewMysqlConfig(),
+		Redis:         NewRedisConfig(),
+		Session:       NewSessionConfig(),
+		Auth:          NewAuthConfig(),
+		Audit:         NewAuditConfig(),
+		Storage:       NewStorageConfig(),
+		Mail:          NewMailConfig(),
+		PasswordReset: NewPasswordResetConfig(),
+		OrgCache:      NewOrganizationCacheConfig(),
+		Organization:  NewOrganizationConfig(),
+		Menu:          NewMenuConfig(),
+		Offboarding:   NewOffboardingConfig(),
+		Headcount:     NewHeadcountConfig(),
+	}
+}
@@ -1,17 +1,26 @@
-package router
-
-import (
-	"github.com/gin-gonic/gin"
-)
-
-type GinService interface {
-	AuthRegistry(r gin.IRouter)
-	PublicRegistry(r gin.IRouter)
-	Config()
-	Name() string
-}
-
-type LogicService interface {
-	Config()
-	Name() string
-}
+package router
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+type GinService interface {
+	AuthRegistry(r gin.IRouter)
+	PublicRegistry(r gin.IRouter)
+	Config()
+	Name() string
+}
+
+type LogicService interface {
+	Config()
+	Name() string
+}
+
+// JobService 后台定时任务
+type JobService interface {
+	Name() string
+	Interval() time.Duration
+	Run(ctx context.Context) error
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// ResizeSquare 居中裁剪为正方形后缩放到指定边长
+func ResizeSquare(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+	// 原图小于目标尺寸时不放大
+	if side < size {
+		size = side
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+	return dst
+}
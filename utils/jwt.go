@@ -1,96 +1,150 @@
-package utils
-
-import (
-	"fmt"
-	"github.com/dgrijalva/jwt-go"
-	"github.com/yanshicheng/ikube-gin-xjob/global"
-	"strings"
-	"time"
-)
-
-type ApplicationRole struct {
-	Application string   `json:"application"`
-	Role        []string `json:"role"`
-}
-
-type JWTClaims struct {
-	Account            string          `json:"account"`
-	Application        ApplicationRole `json:"application"`
-	jwt.StandardClaims                 // 内嵌标准的声明
-}
-
-type JWTResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
-}
-
-// ParseJWT 解析并验证 JWT
-func ParseJWT(tokenString string) (*JWTClaims, error) {
-	claims := &JWTClaims{}
-	// 提取 JWT
-	JwtToken, err := extractTokenFromBearerString(tokenString)
-	if err != nil {
-		return nil, err
-	}
-	// 解析 JWT
-	token, err := jwt.ParseWithClaims(JwtToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return global.JwtKey, nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-	// 验证 JWT
-	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
-	}
-
-	return claims, nil
-}
-
-// extractTokenFromBearerString 从 Bearer 令牌字符串中提取 JWT
-func extractTokenFromBearerString(bearerToken string) (string, error) {
-	if len(bearerToken) < 7 || !strings.HasPrefix(bearerToken, "Bearer ") {
-		return "", fmt.Errorf("invalid bearer token")
-	}
-
-	return bearerToken[7:], nil
-}
-
-func GenerateToken(account string, aRole ApplicationRole) (*JWTResponse, error) {
-	now := time.Now()
-	jti, err := GenerateRandomID()
-	if err != nil {
-		return nil, err
-	}
-
-	claims := JWTClaims{
-		Account:     account,
-		Application: aRole,
-		StandardClaims: jwt.StandardClaims{
-			Issuer:    "www.ikubeops.com",
-			NotBefore: now.Unix(),                       // 生效时间：Unix时间戳，token在此时间之前不可用
-			IssuedAt:  now.Unix(),                       // 发行时间：Unix时间戳，指明token何时被发行
-			ExpiresAt: now.Add(15 * time.Minute).Unix(), // 过期时间：Unix时间戳，指明token何时过期
-			Id:        jti,
-		},
-	}
-
-	// 创建访问令牌
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	accessTokenString, err := token.SignedString(global.JwtKey)
-	if err != nil {
-		return nil, err
-	}
-	// 创建刷新令牌，通常具有更长的有效期
-	claims.ExpiresAt = now.Add(24 * time.Hour).Unix()
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	refreshTokenString, err := refreshToken.SignedString(global.JwtKey)
-	if err != nil {
-		return nil, err
-	}
-	return &JWTResponse{
-		AccessToken:  accessTokenString,
-		RefreshToken: refreshTokenString,
-	}, nil
-}
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/yanshicheng/ikube-gin-xjob/common/types"
+	"github.com/yanshicheng/ikube-gin-xjob/global"
+	"strings"
+	"time"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute // 访问令牌有效期
+	RefreshTokenTTL = 24 * time.Hour   // 刷新令牌有效期，同时也是会话的有效期
+)
+
+type ApplicationRole struct {
+	Application string   `json:"application"`
+	Role        []string `json:"role"`
+}
+
+type JWTClaims struct {
+	AccountId          uint            `json:"accountId"`
+	Account            string          `json:"account"`
+	Type               string          `json:"type"` // 令牌类型 access/refresh/api
+	Application        ApplicationRole `json:"application"`
+	RefreshId          string          `json:"rid,omitempty"` // 刷新令牌的编号，每次刷新都会更换，旧的刷新令牌随之失效
+	jwt.StandardClaims                 // 内嵌标准的声明
+}
+
+type JWTResponse struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	SessionId    string `json:"sessionId"` // 即令牌 jti，用于会话管理
+	RefreshId    string `json:"-"`         // 刷新令牌的编号，保存在会话中用于校验刷新令牌
+}
+
+// ParseJWT 解析并验证 JWT
+func ParseJWT(tokenString string) (*JWTClaims, error) {
+	claims := &JWTClaims{}
+	// 提取 JWT
+	JwtToken, err := extractTokenFromBearerString(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	// 解析 JWT
+	token, err := jwt.ParseWithClaims(JwtToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(global.JwtKey), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	// 验证 JWT
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	return claims, nil
+}
+
+// IsTokenExpired 判断解析错误是否为令牌过期
+func IsTokenExpired(err error) bool {
+	var ve *jwt.ValidationError
+	return errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorExpired != 0
+}
+
+// ExtractBearerToken 从 Authorization 头中提取令牌
+func ExtractBearerToken(bearerToken string) (string, error) {
+	return extractTokenFromBearerString(bearerToken)
+}
+
+// extractTokenFromBearerString 从 Bearer 令牌字符串中提取 JWT
+func extractTokenFromBearerString(bearerToken string) (string, error) {
+	if len(bearerToken) < 7 || !strings.HasPrefix(bearerToken, "Bearer ") {
+		return "", fmt.Errorf("invalid bearer token")
+	}
+
+	return bearerToken[7:], nil
+}
+
+// GetClaims 获取认证中间件写入上下文的声明信息
+func GetClaims(c *gin.Context) (*JWTClaims, error) {
+	value, ok := c.Get(types.JwtClaimsKey)
+	if !ok {
+		return nil, fmt.Errorf("未获取到认证信息")
+	}
+	claims, ok := value.(*JWTClaims)
+	if !ok {
+		return nil, fmt.Errorf("认证信息格式错误")
+	}
+	return claims, nil
+}
+
+func GenerateToken(accountId uint, account string, aRole ApplicationRole) (*JWTResponse, error) {
+	jti, err := GenerateRandomID()
+	if err != nil {
+		return nil, err
+	}
+	return GenerateTokenWithId(jti, accountId, account, aRole)
+}
+
+// GenerateTokenWithId 使用指定 jti 签发令牌，刷新令牌时沿用原会话 ID，刷新令牌每次都使用新的编号
+func GenerateTokenWithId(jti string, accountId uint, account string, aRole ApplicationRole) (*JWTResponse, error) {
+	refreshId, err := GenerateRandomID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	claims := JWTClaims{
+		AccountId:   accountId,
+		Account:     account,
+		Type:        types.TokenTypeAccess,
+		Application: aRole,
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    "www.ikubeops.com",
+			NotBefore: now.Unix(),                     // 生效时间：Unix时间戳，token在此时间之前不可用
+			IssuedAt:  now.Unix(),                     // 发行时间：Unix时间戳，指明token何时被发行
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(), // 过期时间：Unix时间戳，指明token何时过期
+			Id:        jti,
+		},
+	}
+
+	// 创建访问令牌
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	accessTokenString, err := token.SignedString([]byte(global.JwtKey))
+	if err != nil {
+		return nil, err
+	}
+	// 创建刷新令牌，通常具有更长的有效期
+	claims.Type = types.TokenTypeRefresh
+	claims.ExpiresAt = now.Add(RefreshTokenTTL).Unix()
+	claims.RefreshId = refreshId
+	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	refreshTokenString, err := refreshToken.SignedString([]byte(global.JwtKey))
+	if err != nil {
+		return nil, err
+	}
+	return &JWTResponse{
+		AccessToken:  accessTokenString,
+		RefreshToken: refreshTokenString,
+		SessionId:    jti,
+		RefreshId:    refreshId,
+	}, nil
+}
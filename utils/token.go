@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ApiTokenPrefix API 令牌统一前缀，用于与 JWT 区分
+const ApiTokenPrefix = "ikx_"
+
+// GenerateApiToken 生成 API 令牌，返回完整令牌和用于识别的前缀
+// 令牌格式: ikx_<8位标识>_<40位随机串>
+func GenerateApiToken() (token, prefix string, err error) {
+	id, err := randomHex(4)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := randomHex(20)
+	if err != nil {
+		return "", "", err
+	}
+	prefix = ApiTokenPrefix + id
+	return fmt.Sprintf("%s_%s", prefix, secret), prefix, nil
+}
+
+// ApiTokenPrefixOf 从完整令牌中截取前缀
+func ApiTokenPrefixOf(token string) string {
+	if i := strings.LastIndex(token, "_"); i > len(ApiTokenPrefix) {
+		return token[:i]
+	}
+	return ""
+}
+
+// HashToken 对令牌做 sha256 摘要，数据库中只保存摘要
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateResetToken 生成找回密码使用的一次性随机令牌
+func GenerateResetToken() (string, error) {
+	return randomHex(32)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}